@@ -4,32 +4,77 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
 	"github.com/ga4gh/htsget-refserver/internal/htsserver"
 )
 
+// validateConfig checks the config file passed with -config, listing all
+// problems found on stderr
+//
+// Arguments
+//	args ([]string): command line arguments following the subcommand
+// Returns
+//	(int): exit code, non-zero if the config file is invalid
+func validateConfig(args []string) int {
+	flags := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configFile := flags.String("config", "", "path to json, yaml or toml config file")
+	flags.Parse(args)
+
+	problems := htsconfig.ValidateConfigFile(*configFile)
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem.Error())
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s) found\n", *configFile, len(problems))
+		return 1
+	}
+	fmt.Printf("%s: valid\n", *configFile)
+	return 0
+}
+
 // main program entrypoint
 func main() {
 
+	// check the config file without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+
 	// load configuration object
 	htsconfig.GetConfig()
 	configLoadError := htsconfig.GetConfigLoadError()
 	if configLoadError != nil {
-		panic(configLoadError.Error())
+		fmt.Fprintln(os.Stderr, "Problem loading configuration: "+configLoadError.Error())
+		os.Exit(1)
+	}
+
+	// write logs to the configured log file
+	logger, err := htslog.OpenLogger(htsconfig.GetLogFile(), htsconfig.GetLogLevel())
+	if err != nil {
+		panic("Problem opening log file: " + err.Error())
 	}
+	htslog.SetLogger(logger)
+
+	// reload the configuration on SIGHUP, or when the config file changes
+	stopWatching := htsconfig.WatchConfig()
+	defer stopWatching()
 
 	// load server routes
 	router, err := htsserver.SetRouter()
 	if err != nil {
-		panic("Problem setting up server.")
+		panic("Problem setting up server: " + err.Error())
 	}
 	http.Handle("/", router)
 
 	// start server
 	port := htsconfig.GetPort()
-	fmt.Printf("Server started on port %s!\n", port)
-	http.ListenAndServe(":"+port, nil)
+	htslog.Info("server started", htslog.Fields{"port": port})
+	err = http.ListenAndServe(":"+port, nil)
+	htslog.Error("server stopped", htslog.Fields{"error": err.Error()})
 }
@@ -1,6 +1,7 @@
 package assumerole
 
 import (
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/ga4gh/htsget-refserver/internal/awsutils"
 	"log"
 	"net/http"
@@ -9,14 +10,21 @@ import (
 
 type Options struct {
 	Debug bool
+	// Credentials overrides the default credentials chain, mainly for tests
+	Credentials aws.CredentialsProvider
 }
 
 type Logger interface {
 	Printf(string, ...interface{})
 }
 
+// AssumeRole attaches a credentials provider to each request's context,
+// rather than exporting credentials to the process environment, which is
+// shared by concurrent requests. the provider is shared by all requests,
+// caching the credentials until shortly before they expire
 type AssumeRole struct {
-	Log Logger
+	Log         Logger
+	Credentials aws.CredentialsProvider
 }
 
 func New(options Options) *AssumeRole {
@@ -24,6 +32,16 @@ func New(options Options) *AssumeRole {
 	if options.Debug && ar.Log == nil {
 		ar.Log = log.New(os.Stdout, "[assumerole] ", log.LstdFlags)
 	}
+	ar.Credentials = options.Credentials
+	if ar.Credentials == nil {
+		provider, err := awsutils.NewDefaultCredentialsProvider()
+		if err != nil {
+			ar.logf("error loading credentials provider")
+			ar.logf(err.Error())
+		} else {
+			ar.Credentials = provider
+		}
+	}
 	return ar
 }
 
@@ -34,16 +52,19 @@ func Handler(options Options) func(next http.Handler) http.Handler {
 
 func (ar *AssumeRole) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		awsutils.UnsetCredentials()
-		cred, err := awsutils.GetCredentials()
+		if ar.Credentials == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cred, err := ar.Credentials.Retrieve(r.Context())
 		if err != nil {
 			ar.logf("error getting credentials with assume role")
 			ar.logf(err.Error())
 		} else {
 			ar.logf("using credentials source " + cred.Source)
-			awsutils.SetCredentials(*cred)
 		}
-		next.ServeHTTP(w, r)
+		ctx := awsutils.WithCredentialsProvider(r.Context(), ar.Credentials)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
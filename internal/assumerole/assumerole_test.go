@@ -1,24 +1,85 @@
 package assumerole
 
 import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/ga4gh/htsget-refserver/internal/awsutils"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"sync"
 	"testing"
 )
 
-var mockHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	_, _ = w.Write([]byte("bar"))
+// credentialsHandler writes the access key of the request's credentials
+var credentialsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	provider := awsutils.CredentialsProviderFromContext(r.Context())
+	if provider == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	cred, err := provider.Retrieve(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write([]byte(cred.AccessKeyID))
 })
 
+func staticCredentials(accessKeyID string) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: "MOCKSECRET",
+			Source:          "mock",
+		}, nil
+	})
+}
+
 // go test -run TestNew ./internal/assumerole/ -v -count 1
 func TestNew(t *testing.T) {
 	ar := New(Options{Debug: true})
 	assert.True(t, ar != nil)
 }
 
+// go test -run TestHandlerConcurrent ./internal/assumerole/ -v -count 1
+func TestHandlerConcurrent(t *testing.T) {
+	envAccessKeyID, envSet := os.LookupEnv(awsutils.AwsAccessKeyId)
+
+	// handlers assuming different roles, serving requests concurrently
+	handlers := []http.Handler{}
+	for i := 0; i < 4; i++ {
+		ar := New(Options{Credentials: staticCredentials("MOCKKEY" + strconv.Itoa(i))})
+		handlers = append(handlers, ar.Handler(credentialsHandler))
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 200)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "http://localhost/reads/foo", nil)
+			res := httptest.NewRecorder()
+			handlers[i%len(handlers)].ServeHTTP(res, req)
+			results[i] = res.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	// each request only sees the credentials of its own handler
+	for i, result := range results {
+		assert.Equal(t, "MOCKKEY"+strconv.Itoa(i%len(handlers)), result)
+	}
+
+	// the process environment is left untouched
+	val, exist := os.LookupEnv(awsutils.AwsAccessKeyId)
+	assert.Equal(t, envSet, exist)
+	assert.Equal(t, envAccessKeyID, val)
+}
+
 // go test -run TestHandler ./internal/assumerole/ -v -count 1
 func TestHandler(t *testing.T) {
 
@@ -29,9 +90,8 @@ func TestHandler(t *testing.T) {
 	h := Handler(Options{Debug: true})
 	req, _ := http.NewRequest("GET", "http://localhost/reads/foo", nil)
 	res := httptest.NewRecorder()
-	h(mockHandler).ServeHTTP(res, req)
+	h(credentialsHandler).ServeHTTP(res, req)
 
-	val, exist := os.LookupEnv(awsutils.AwsAccessKeyId)
-	assert.True(t, exist)
-	assert.True(t, len(val) > 0)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.True(t, len(res.Body.String()) > 0)
 }
@@ -0,0 +1,100 @@
+package awsutils
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// CredentialsExpiryWindow time before expiry cached credentials are refreshed
+const CredentialsExpiryWindow = 5 * time.Minute
+
+type credentialsContextKey struct{}
+
+// CachingCredentialsProvider caches the credentials of the wrapped provider,
+// retrieving new credentials shortly before they expire. it is safe for
+// concurrent use, and meant to be shared by all requests
+type CachingCredentialsProvider struct {
+	provider aws.CredentialsProvider
+	window   time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	cred     *aws.Credentials
+}
+
+func NewCachingCredentialsProvider(provider aws.CredentialsProvider, window time.Duration) *CachingCredentialsProvider {
+	return &CachingCredentialsProvider{
+		provider: provider,
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+// NewDefaultCredentialsProvider loads the provider of the default credentials
+// chain (environment, shared config incl. assume role profiles, instance
+// roles), cached until shortly before the credentials expire
+func NewDefaultCredentialsProvider() (*CachingCredentialsProvider, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	return NewCachingCredentialsProvider(cfg.Credentials, CredentialsExpiryWindow), nil
+}
+
+func (p *CachingCredentialsProvider) expiresSoon() bool {
+	return p.cred.CanExpire && !p.now().Add(p.window).Before(p.cred.Expires)
+}
+
+func (p *CachingCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cred != nil && !p.expiresSoon() {
+		return *p.cred, nil
+	}
+	// the sdk caches credentials until they actually expire, so its cache is
+	// invalidated to refresh them early
+	if cache, ok := p.provider.(*aws.CredentialsCache); ok {
+		cache.Invalidate()
+	}
+	cred, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	p.cred = &cred
+	return cred, nil
+}
+
+// WithCredentialsProvider returns a copy of the context carrying the
+// credentials provider, used for all AWS access made on behalf of a request
+func WithCredentialsProvider(ctx context.Context, provider aws.CredentialsProvider) context.Context {
+	return context.WithValue(ctx, credentialsContextKey{}, provider)
+}
+
+// CredentialsProviderFromContext gets the credentials provider carried by the
+// context, nil if there is none and the default credentials chain applies
+func CredentialsProviderFromContext(ctx context.Context) aws.CredentialsProvider {
+	if ctx == nil {
+		return nil
+	}
+	provider, _ := ctx.Value(credentialsContextKey{}).(aws.CredentialsProvider)
+	return provider
+}
+
+// CredentialsEnv formats credentials as environment variables, passed to
+// subprocesses accessing AWS, e.g. htslib reading s3:// references
+func CredentialsEnv(cred aws.Credentials) []string {
+	env := []string{
+		AwsAccessKeyId + "=" + cred.AccessKeyID,
+		AwsSecretAccessKey + "=" + cred.SecretAccessKey,
+		AwsSessionToken + "=" + cred.SessionToken,
+	}
+	if cred.CanExpire {
+		env = append(env, AwsSessionTokenExpiration+"="+strconv.FormatInt(cred.Expires.Unix(), 10))
+	}
+	return env
+}
@@ -0,0 +1,131 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockCred = aws.Credentials{
+	AccessKeyID:     "MOCKQ6HSRDFZ5JKZMOCK",
+	SecretAccessKey: "M0CKSDr32mKyG/cbjceUj4IdiQEnlsKwNYtOT/V",
+	SessionToken:    "MockFwoGZXIvYXdzEEcaDP9PvgOm1tNvvW2bFiLsARkAtzMOCKTbAX",
+}
+
+var credTestNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// countingProvider issues new credentials, numbered by the retrieval count
+type countingProvider struct {
+	count   int
+	expires time.Duration
+	err     error
+}
+
+func (p *countingProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if p.err != nil {
+		return aws.Credentials{}, p.err
+	}
+	p.count++
+	return aws.Credentials{
+		AccessKeyID:     "MOCKKEY" + strconv.Itoa(p.count),
+		SecretAccessKey: "MOCKSECRET",
+		CanExpire:       p.expires > 0,
+		Expires:         credTestNow.Add(p.expires),
+	}, nil
+}
+
+var cachingCredentialsProviderTC = []struct {
+	expires  time.Duration
+	elapsed  time.Duration
+	expCount int
+}{
+	// static credentials are retrieved once
+	{0, 24 * time.Hour, 1},
+	// cached until within the expiry window
+	{time.Hour, 30 * time.Minute, 1},
+	{time.Hour, 54 * time.Minute, 1},
+	// refreshed before expiring
+	{time.Hour, 55 * time.Minute, 2},
+	{time.Hour, 2 * time.Hour, 2},
+}
+
+// go test -run TestCachingCredentialsProvider ./internal/awsutils/ -v -count 1
+func TestCachingCredentialsProvider(t *testing.T) {
+	for _, tc := range cachingCredentialsProviderTC {
+		provider := &countingProvider{expires: tc.expires}
+		caching := NewCachingCredentialsProvider(provider, CredentialsExpiryWindow)
+		caching.now = func() time.Time { return credTestNow }
+
+		cred, err := caching.Retrieve(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, "MOCKKEY1", cred.AccessKeyID)
+
+		caching.now = func() time.Time { return credTestNow.Add(tc.elapsed) }
+		cred, err = caching.Retrieve(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expCount, provider.count)
+		assert.Equal(t, "MOCKKEY"+strconv.Itoa(tc.expCount), cred.AccessKeyID)
+	}
+
+	// errors are not cached
+	provider := &countingProvider{err: errors.New("no credentials")}
+	caching := NewCachingCredentialsProvider(provider, CredentialsExpiryWindow)
+	_, err := caching.Retrieve(context.TODO())
+	assert.NotNil(t, err)
+	provider.err = nil
+	cred, err := caching.Retrieve(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "MOCKKEY1", cred.AccessKeyID)
+}
+
+// go test -run TestCredentialsProviderFromContext ./internal/awsutils/ -v -count 1
+func TestCredentialsProviderFromContext(t *testing.T) {
+	assert.Nil(t, CredentialsProviderFromContext(context.Background()))
+	assert.Nil(t, CredentialsProviderFromContext(nil))
+
+	provider := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return mockCred, nil
+	})
+	ctx := WithCredentialsProvider(context.Background(), provider)
+	cred, err := CredentialsProviderFromContext(ctx).Retrieve(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, mockCred.AccessKeyID, cred.AccessKeyID)
+}
+
+// go test -run TestCredentialsEnv ./internal/awsutils/ -v -count 1
+func TestCredentialsEnv(t *testing.T) {
+	assert.Equal(t, []string{
+		AwsAccessKeyId + "=" + mockCred.AccessKeyID,
+		AwsSecretAccessKey + "=" + mockCred.SecretAccessKey,
+		AwsSessionToken + "=" + mockCred.SessionToken,
+	}, CredentialsEnv(mockCred))
+
+	expiring := mockCred
+	expiring.CanExpire = true
+	expiring.Expires = credTestNow
+	env := CredentialsEnv(expiring)
+	assert.Equal(t, AwsSessionTokenExpiration+"="+strconv.FormatInt(credTestNow.Unix(), 10), env[3])
+}
+
+// go test -run TestS3DtoCredentials ./internal/awsutils/ -v -count 1
+func TestS3DtoCredentials(t *testing.T) {
+	os.Setenv(AwsRegion, "us-east-1")
+	provider := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return mockCred, nil
+	})
+	presignedURL, err := PresignS3ObjectRange(S3Dto{
+		ObjPath:     "s3://bucket/path/to/object.bam",
+		Credentials: provider,
+	}, "bytes=0-1023", 15*time.Minute)
+	assert.Nil(t, err)
+	parsed, _ := url.Parse(presignedURL)
+	assert.Contains(t, parsed.Query().Get("X-Amz-Credential"), mockCred.AccessKeyID)
+	assert.Equal(t, mockCred.SessionToken, parsed.Query().Get("X-Amz-Security-Token"))
+}
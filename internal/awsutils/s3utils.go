@@ -2,10 +2,14 @@ package awsutils
 
 import (
 	"context"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"io"
 	"strings"
+	"time"
 )
 
 type S3ClientApi interface {
@@ -13,9 +17,15 @@ type S3ClientApi interface {
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
 }
 
+type S3PresignApi interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
 type S3Dto struct {
-	ObjPath string
-	Client  S3ClientApi
+	ObjPath     string
+	Client      S3ClientApi
+	Presigner   S3PresignApi
+	Credentials aws.CredentialsProvider
 }
 
 func (dto *S3Dto) getBucketAndKey() (string, string) {
@@ -26,20 +36,52 @@ func (dto *S3Dto) getBucketAndKey() (string, string) {
 	return bucketName, objKeyName
 }
 
-func (dto *S3Dto) NewS3Client() S3ClientApi {
+// loadConfig loads the default AWS configuration, which sets the region and
+// endpoint resolver of s3 clients
+func (dto *S3Dto) loadConfig() (aws.Config, error) {
+	// request-scoped credentials take precedence over the default chain
+	optFns := []func(*config.LoadOptions) error{}
+	if dto.Credentials != nil {
+		optFns = append(optFns, config.WithCredentialsProvider(dto.Credentials))
+	}
+	return config.LoadDefaultConfig(context.TODO(), optFns...)
+}
+
+func (dto *S3Dto) NewS3Client() (S3ClientApi, error) {
 	if dto.Client != nil {
-		return dto.Client
+		return dto.Client, nil
+	}
+
+	defaultCfg, err := dto.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return s3.NewFromConfig(defaultCfg), nil
+}
+
+func (dto *S3Dto) NewS3PresignClient() (S3PresignApi, error) {
+	if dto.Presigner != nil {
+		return dto.Presigner, nil
 	}
 
-	defaultCfg, err := config.LoadDefaultConfig(context.TODO())
+	client, err := dto.NewS3Client()
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	// presigning is only supported by the sdk client, not by other
+	// implementations of S3ClientApi
+	sdkClient, ok := client.(*s3.Client)
+	if !ok {
+		return nil, errors.New("could not create s3 presign client")
 	}
-	return s3.NewFromConfig(defaultCfg)
+	return s3.NewPresignClient(sdkClient), nil
 }
 
 func HeadS3Object(dto S3Dto) (int64, error) {
-	client := dto.NewS3Client()
+	client, err := dto.NewS3Client()
+	if err != nil {
+		return 0, err
+	}
 	bucketName, objKeyName := dto.getBucketAndKey()
 
 	headResp, herr := client.HeadObject(context.TODO(), &s3.HeadObjectInput{
@@ -51,3 +93,79 @@ func HeadS3Object(dto S3Dto) (int64, error) {
 	}
 	return headResp.ContentLength, nil
 }
+
+func GetS3ObjectRange(dto S3Dto, byteRange string) (io.ReadCloser, error) {
+	client, err := dto.NewS3Client()
+	if err != nil {
+		return nil, err
+	}
+	bucketName, objKeyName := dto.getBucketAndKey()
+
+	getResp, gerr := client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objKeyName),
+		Range:  aws.String(byteRange),
+	})
+	if gerr != nil {
+		return nil, gerr
+	}
+	return getResp.Body, nil
+}
+
+// PresignS3ObjectRange creates a presigned HTTPS GET url for the byte range of
+// the object, valid for the expiry duration. the range is signed, so the url
+// must be requested with the same Range header
+func PresignS3ObjectRange(dto S3Dto, byteRange string, expires time.Duration) (string, error) {
+	presigner, err := dto.NewS3PresignClient()
+	if err != nil {
+		return "", err
+	}
+	bucketName, objKeyName := dto.getBucketAndKey()
+
+	presignedReq, perr := presigner.PresignGetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objKeyName),
+		Range:  aws.String(byteRange),
+	}, s3.WithPresignExpires(expires))
+	if perr != nil {
+		return "", perr
+	}
+	return presignedReq.URL, nil
+}
+
+// S3BucketURL gets the path style url of the bucket the path is in, at the s3
+// endpoint of the configured region, or the endpoint given by the configured
+// endpoint resolver
+func S3BucketURL(dto S3Dto) (string, error) {
+	cfg, err := dto.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	bucketName, _ := dto.getBucketAndKey()
+	return s3BucketURL(cfg, bucketName)
+}
+
+func s3BucketURL(cfg aws.Config, bucketName string) (string, error) {
+	if cfg.Region == "" {
+		return "", errors.New("no AWS region is configured")
+	}
+	endpoint, err := resolveS3Endpoint(cfg)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(endpoint.URL, "/") + "/" + bucketName + "/", nil
+}
+
+// resolveS3Endpoint resolves the s3 endpoint of the configured region with the
+// configured endpoint resolver, falling back to the default resolver as s3
+// clients do
+func resolveS3Endpoint(cfg aws.Config) (aws.Endpoint, error) {
+	if cfg.EndpointResolver != nil {
+		endpoint, err := cfg.EndpointResolver.ResolveEndpoint(s3.ServiceID, cfg.Region)
+		var notFound *aws.EndpointNotFoundError
+		if !errors.As(err, &notFound) {
+			return endpoint, err
+		}
+	}
+	return s3.NewDefaultEndpointResolver().ResolveEndpoint(cfg.Region, s3.EndpointResolverOptions{})
+}
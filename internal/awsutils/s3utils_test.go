@@ -3,16 +3,25 @@ package awsutils
 import (
 	"context"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 type S3MockClient struct{}
 
 func (client *S3MockClient) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
-	panic("implement me")
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(*params.Bucket + "/" + *params.Key + " " + *params.Range)),
+	}, nil
 }
 
 func (client *S3MockClient) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
@@ -31,6 +40,118 @@ func TestHeadS3Object(t *testing.T) {
 	assert.Equal(t, int64(1111), contentLength)
 }
 
+// go test -run TestS3BucketURL ./internal/awsutils/ -v -count 1
+func TestS3BucketURL(t *testing.T) {
+	region, regionSet := os.LookupEnv(AwsRegion)
+	defer func() {
+		if regionSet {
+			os.Setenv(AwsRegion, region)
+		} else {
+			os.Unsetenv(AwsRegion)
+		}
+	}()
+	os.Setenv(AwsRegion, "eu-west-2")
+	bucketURL, err := S3BucketURL(S3Dto{ObjPath: "s3://bucket/path/to/"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://s3.eu-west-2.amazonaws.com/bucket/", bucketURL)
+	bucketURL, err = S3BucketURL(S3Dto{ObjPath: "s3://bucket"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://s3.eu-west-2.amazonaws.com/bucket/", bucketURL)
+}
+
+// s3BucketURLTC test cases for s3BucketURL
+var s3BucketURLTC = []struct {
+	region    string
+	resolver  aws.EndpointResolver
+	expURL    string
+	expErrMsg string
+}{
+	{"us-east-1", nil, "https://s3.us-east-1.amazonaws.com/bucket/", ""},
+	{"ca-central-1", nil, "https://s3.ca-central-1.amazonaws.com/bucket/", ""},
+	{"us-east-1", aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		return aws.Endpoint{URL: "http://localhost:9000/"}, nil
+	}), "http://localhost:9000/bucket/", ""},
+	{"us-east-1", aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+	}), "https://s3.us-east-1.amazonaws.com/bucket/", ""},
+	{"us-east-1", aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		return aws.Endpoint{}, fmt.Errorf("resolver failed")
+	}), "", "resolver failed"},
+	{"", nil, "", "no AWS region is configured"},
+}
+
+// go test -run TestS3BucketURLEndpoint ./internal/awsutils/ -v -count 1
+func TestS3BucketURLEndpoint(t *testing.T) {
+	for _, tc := range s3BucketURLTC {
+		bucketURL, err := s3BucketURL(aws.Config{Region: tc.region, EndpointResolver: tc.resolver}, "bucket")
+		assert.Equal(t, tc.expURL, bucketURL)
+		if tc.expErrMsg == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.EqualError(t, err, tc.expErrMsg)
+		}
+	}
+}
+
+// go test -run TestGetS3ObjectRange ./internal/awsutils/ -v -count 1
+func TestGetS3ObjectRange(t *testing.T) {
+	body, err := GetS3ObjectRange(S3Dto{
+		ObjPath: "s3://bucket/path/to/object.bam",
+		Client:  &S3MockClient{},
+	}, "bytes=0-1023")
+	assert.Nil(t, err)
+	content, _ := ioutil.ReadAll(body)
+	assert.Equal(t, "bucket/path/to/object.bam bytes=0-1023", string(content))
+}
+
+type S3MockPresigner struct{}
+
+func (presigner *S3MockPresigner) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	options := s3.PresignOptions{}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &v4.PresignedHTTPRequest{
+		URL: "https://" + *params.Bucket + ".s3.amazonaws.com/" + *params.Key + "?range=" + *params.Range + "&expires=" + options.Expires.String(),
+	}, nil
+}
+
+// go test -run TestPresignS3ObjectRange ./internal/awsutils/ -v -count 1
+func TestPresignS3ObjectRange(t *testing.T) {
+	presignedURL, err := PresignS3ObjectRange(S3Dto{
+		ObjPath:   "s3://bucket/path/to/object.bam",
+		Presigner: &S3MockPresigner{},
+	}, "bytes=0-1023", 15*time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://bucket.s3.amazonaws.com/path/to/object.bam?range=bytes=0-1023&expires=15m0s", presignedURL)
+
+	// presigning with the sdk client is done offline, signing the range header
+	client := s3.New(s3.Options{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return mockCred, nil
+		}),
+	})
+	presignedURL, err = PresignS3ObjectRange(S3Dto{
+		ObjPath: "s3://bucket/path/to/object.bam",
+		Client:  client,
+	}, "bytes=0-1023", 15*time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(presignedURL, "https://bucket.s3.us-east-1.amazonaws.com/path/to/object.bam?"))
+	parsed, _ := url.Parse(presignedURL)
+	assert.Equal(t, "900", parsed.Query().Get("X-Amz-Expires"))
+	assert.Equal(t, mockCred.SessionToken, parsed.Query().Get("X-Amz-Security-Token"))
+	assert.Contains(t, parsed.Query().Get("X-Amz-SignedHeaders"), "range")
+	assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+
+	// clients not supporting presigning
+	_, err = PresignS3ObjectRange(S3Dto{
+		ObjPath: "s3://bucket/path/to/object.bam",
+		Client:  &S3MockClient{},
+	}, "bytes=0-1023", 15*time.Minute)
+	assert.NotNil(t, err)
+}
+
 // go test -run TestIntegrationHeadS3Object ./internal/awsutils/ -v -count 1
 func TestIntegrationHeadS3Object(t *testing.T) {
 
@@ -38,8 +159,7 @@ func TestIntegrationHeadS3Object(t *testing.T) {
 		t.Skipf("[Skip] Required to setup and `export AWS_PROFILE=%s` in integration testing CI environment", TestAwsProfileForIT)
 	}
 
-	SetProfile(TestAwsProfileForIT)
-	SetRegion("us-east-1")
+	os.Setenv(AwsRegion, "us-east-1")
 
 	publicObjPath := "s3://giab/data/NA12878/Garvan_NA12878_HG001_HiSeq_Exome/project.NIST_NIST7035_H7AP8ADXX_TAAGGCGA_1_NA12878.bwa.markDuplicates.bam"
 	expContentLength := int64(3020450026)
@@ -54,3 +174,35 @@ func TestIntegrationHeadS3Object(t *testing.T) {
 	fmt.Println("Content Length: ", contentLength)
 	assert.Equal(t, expContentLength, contentLength)
 }
+
+// go test -run TestS3ClientError ./internal/awsutils/ -v -count 1
+func TestS3ClientError(t *testing.T) {
+	configFile, configFileSet := os.LookupEnv("AWS_CONFIG_FILE")
+	defer func() {
+		if configFileSet {
+			os.Setenv("AWS_CONFIG_FILE", configFile)
+		} else {
+			os.Unsetenv("AWS_CONFIG_FILE")
+		}
+	}()
+	// the default config can't be loaded from a malformed shared config file
+	malformed := filepath.Join(t.TempDir(), "config")
+	assert.Nil(t, ioutil.WriteFile(malformed, []byte("[profile malformed\n"), 0644))
+	os.Setenv("AWS_CONFIG_FILE", malformed)
+
+	dto := S3Dto{ObjPath: "s3://bucket/path/to/object.bam"}
+	client, err := dto.NewS3Client()
+	assert.NotNil(t, err)
+	assert.Nil(t, client)
+	_, err = HeadS3Object(dto)
+	assert.NotNil(t, err)
+	body, err := GetS3ObjectRange(dto, "bytes=0-1023")
+	assert.NotNil(t, err)
+	assert.Nil(t, body)
+	_, err = PresignS3ObjectRange(dto, "bytes=0-1023", time.Minute)
+	assert.NotNil(t, err)
+
+	// presigning requires the sdk client
+	_, err = PresignS3ObjectRange(S3Dto{ObjPath: dto.ObjPath, Client: &S3MockClient{}}, "bytes=0-1023", time.Minute)
+	assert.EqualError(t, err, "could not create s3 presign client")
+}
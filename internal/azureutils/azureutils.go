@@ -0,0 +1,239 @@
+package azureutils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type AzureDto struct {
+	ObjPath    string
+	Endpoint   string
+	AccountKey string
+	Client     *http.Client
+}
+
+// azureBlob a blob resolved from an az:// path or blob service url
+type azureBlob struct {
+	account   string
+	container string
+	name      string
+	url       string
+}
+
+// IsAzurePath checks if the path is an az:// path or a blob service url
+func IsAzurePath(path string) bool {
+	if strings.HasPrefix(path, AzureProto) {
+		return true
+	}
+	parsed, err := url.Parse(path)
+	return err == nil && parsed.Scheme == "https" && strings.HasSuffix(parsed.Hostname(), AzureBlobHostSuffix)
+}
+
+// getEndpoint gets the blob service endpoint of the account
+func (dto *AzureDto) getEndpoint(account string) string {
+	endpoint := dto.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(AzureStorageBlobEndpoint)
+	}
+	if endpoint == "" {
+		return "https://" + account + AzureBlobHostSuffix
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func (dto *AzureDto) getAccountKey() string {
+	if dto.AccountKey != "" {
+		return dto.AccountKey
+	}
+	return os.Getenv(AzureStorageKey)
+}
+
+func (dto *AzureDto) getClient() *http.Client {
+	if dto.Client != nil {
+		return dto.Client
+	}
+	return http.DefaultClient
+}
+
+// getAccountAndBlobPath splits az://<account>/<container>/<blob> paths, and
+// https://<account>.blob.core.windows.net/<container>/<blob> urls into the
+// account and the <container>/<blob> path
+func (dto *AzureDto) getAccountAndBlobPath() (string, string, error) {
+	if strings.HasPrefix(dto.ObjPath, AzureProto) {
+		trimmedPath := strings.TrimPrefix(dto.ObjPath, AzureProto)
+		account := strings.Split(trimmedPath, "/")[0]
+		return account, strings.TrimPrefix(trimmedPath, account+"/"), nil
+	}
+	parsed, err := url.Parse(dto.ObjPath)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSuffix(parsed.Hostname(), AzureBlobHostSuffix), strings.TrimPrefix(parsed.Path, "/"), nil
+}
+
+// getBlob resolves az://<account>/<container>/<blob> paths, and
+// https://<account>.blob.core.windows.net/<container>/<blob> urls
+func (dto *AzureDto) getBlob() (*azureBlob, error) {
+	blob := new(azureBlob)
+	account, blobPath, err := dto.getAccountAndBlobPath()
+	if err != nil {
+		return nil, err
+	}
+	blob.account = account
+	blob.container = strings.Split(blobPath, "/")[0]
+	blob.name = strings.TrimPrefix(blobPath, blob.container+"/")
+	if blob.account == "" || blob.container == "" || blob.name == "" || blob.name == blobPath {
+		return nil, errors.New("invalid azure blob path " + dto.ObjPath)
+	}
+	blob.url = dto.getEndpoint(blob.account) + "/" + blob.container + "/" + escapeBlobName(blob.name)
+	return blob, nil
+}
+
+// escapeBlobName escapes each "/" separated segment of a blob name
+func escapeBlobName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sasToken signs a read-only service SAS token for the blob
+func (dto *AzureDto) sasToken(blob *azureBlob, expiry time.Time) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(dto.getAccountKey())
+	if err != nil {
+		return "", errors.New("invalid azure storage account key")
+	}
+	signedExpiry := expiry.UTC().Format("2006-01-02T15:04:05Z")
+	canonicalizedResource := "/blob/" + blob.account + "/" + blob.container + "/" + blob.name
+
+	// permissions, start, expiry, resource, identifier, ip, protocol,
+	// version, resource type, snapshot time, and response header overrides
+	stringToSign := strings.Join([]string{
+		"r", "", signedExpiry, canonicalizedResource, "", "", "",
+		AzureSASVersion, "b", "", "", "", "", "", "",
+	}, "\n")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+
+	query := url.Values{}
+	query.Set("sv", AzureSASVersion)
+	query.Set("sr", "b")
+	query.Set("sp", "r")
+	query.Set("se", signedExpiry)
+	query.Set("sig", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return query.Encode(), nil
+}
+
+// requestURL gets the url the server requests the blob with, signed if an
+// account key is available
+func (dto *AzureDto) requestURL() (string, error) {
+	blob, err := dto.getBlob()
+	if err != nil {
+		return "", err
+	}
+	if dto.getAccountKey() == "" {
+		return blob.url, nil
+	}
+	token, err := dto.sasToken(blob, time.Now().Add(AzureRequestSASExpiry*time.Second))
+	if err != nil {
+		return "", err
+	}
+	return blob.url + "?" + token, nil
+}
+
+func (dto *AzureDto) do(method string, byteRange string) (*http.Response, error) {
+	requestURL, err := dto.requestURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", AzureSASVersion)
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	return dto.getClient().Do(req)
+}
+
+func azureError(objPath string, res *http.Response) error {
+	return errors.New("could not access " + objPath + ": " + res.Status)
+}
+
+// GetAzureBlobSize gets the size of the blob from its properties
+func GetAzureBlobSize(dto AzureDto) (int64, error) {
+	res, err := dto.do(http.MethodHead, "")
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, azureError(dto.ObjPath, res)
+	}
+	return res.ContentLength, nil
+}
+
+// GetAzureBlobRange reads the byte range of the blob's content
+func GetAzureBlobRange(dto AzureDto, byteRange string) (io.ReadCloser, error) {
+	res, err := dto.do(http.MethodGet, byteRange)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		res.Body.Close()
+		return nil, azureError(dto.ObjPath, res)
+	}
+	return res.Body, nil
+}
+
+// CanSignAzureURLs checks if an account key is available to sign SAS tokens
+func CanSignAzureURLs(dto AzureDto) bool {
+	return dto.getAccountKey() != ""
+}
+
+// SignAzureBlobURL creates a url of the blob with a read-only SAS token,
+// valid for the expiry duration
+func SignAzureBlobURL(dto AzureDto, expires time.Duration, now time.Time) (string, error) {
+	blob, err := dto.getBlob()
+	if err != nil {
+		return "", err
+	}
+	token, err := dto.sasToken(blob, now.Add(expires))
+	if err != nil {
+		return "", err
+	}
+	return blob.url + "?" + token, nil
+}
+
+// GetAzureBlobURL gets the unsigned url of the blob, for public containers
+func GetAzureBlobURL(dto AzureDto) (string, error) {
+	blob, err := dto.getBlob()
+	if err != nil {
+		return "", err
+	}
+	return blob.url, nil
+}
+
+// ContainerURL gets the url of the container the path is in. the blob name
+// may be partial or missing, e.g. for the root of a data source
+func ContainerURL(dto AzureDto) (string, error) {
+	account, blobPath, err := dto.getAccountAndBlobPath()
+	if err != nil {
+		return "", err
+	}
+	container := strings.Split(blobPath, "/")[0]
+	if account == "" || container == "" {
+		return "", errors.New("invalid azure container path " + dto.ObjPath)
+	}
+	return dto.getEndpoint(account) + "/" + container + "?restype=container", nil
+}
@@ -0,0 +1,240 @@
+package azureutils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// azuriteAccount and azuriteKey well-known Azurite development credentials
+const azuriteAccount = "devstoreaccount1"
+const azuriteKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+var azureSignTestNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+var fakeAzuriteBlobs = map[string]string{
+	"container/path/to/object.bam": "0123456789",
+}
+
+// validSAS checks the SAS token of the request was signed with the key for
+// the blob, and has not expired
+func validSAS(r *http.Request, account string, blobPath string) bool {
+	query := r.URL.Query()
+	if query.Get("sr") != "b" || query.Get("sp") != "r" {
+		return false
+	}
+	expiry, err := time.Parse("2006-01-02T15:04:05Z", query.Get("se"))
+	if err != nil || time.Now().After(expiry) {
+		return false
+	}
+	stringToSign := "r\n\n" + query.Get("se") + "\n/blob/" + account + "/" + blobPath + "\n\n\n\n" + query.Get("sv") + "\nb\n\n\n\n\n\n"
+	key, _ := base64.StdEncoding.DecodeString(azuriteKey)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return query.Get("sig") == base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newFakeAzurite serves blobs from path-style urls, as Azurite does,
+// optionally requiring SAS tokens
+func newFakeAzurite(requireSAS bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/"+azuriteAccount+"/")
+		content, ok := fakeAzuriteBlobs[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if requireSAS && !validSAS(r, azuriteAccount, path) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, path, time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+}
+
+var azureIsAzurePathTC = []struct {
+	path string
+	exp  bool
+}{
+	{"az://account/container/blob.bam", true},
+	{"https://account.blob.core.windows.net/container/blob.bam", true},
+	{"http://account.blob.core.windows.net/container/blob.bam", false},
+	{"https://example.org/container/blob.bam", false},
+	{"s3://bucket/blob.bam", false},
+}
+
+// go test -run TestIsAzurePath ./internal/azureutils/ -v -count 1
+func TestIsAzurePath(t *testing.T) {
+	for _, tc := range azureIsAzurePathTC {
+		assert.Equal(t, tc.exp, IsAzurePath(tc.path), tc.path)
+	}
+}
+
+var azureGetBlobTC = []struct {
+	objPath  string
+	endpoint string
+	expURL   string
+	expErr   bool
+}{
+	{"az://account/container/path/to/blob 1.bam", "", "https://account.blob.core.windows.net/container/path/to/blob%201.bam", false},
+	{"https://account.blob.core.windows.net/container/blob.bam", "", "https://account.blob.core.windows.net/container/blob.bam", false},
+	{"az://devstoreaccount1/container/blob.bam", "http://127.0.0.1:10000/devstoreaccount1/", "http://127.0.0.1:10000/devstoreaccount1/container/blob.bam", false},
+	{"az://account/container", "", "", true},
+	{"az://account", "", "", true},
+}
+
+// go test -run TestAzureDtoGetBlob ./internal/azureutils/ -v -count 1
+func TestAzureDtoGetBlob(t *testing.T) {
+	for _, tc := range azureGetBlobTC {
+		dto := AzureDto{ObjPath: tc.objPath, Endpoint: tc.endpoint}
+		blob, err := dto.getBlob()
+		assert.Equal(t, tc.expErr, err != nil, tc.objPath)
+		if err == nil {
+			assert.Equal(t, tc.expURL, blob.url, tc.objPath)
+		}
+	}
+
+	// the endpoint of az:// paths can be set through the environment
+	endpoint, endpointSet := os.LookupEnv(AzureStorageBlobEndpoint)
+	os.Setenv(AzureStorageBlobEndpoint, "http://127.0.0.1:10000/devstoreaccount1")
+	blobURL, _ := GetAzureBlobURL(AzureDto{ObjPath: "az://devstoreaccount1/container/blob.bam"})
+	assert.Equal(t, "http://127.0.0.1:10000/devstoreaccount1/container/blob.bam", blobURL)
+	if endpointSet {
+		os.Setenv(AzureStorageBlobEndpoint, endpoint)
+	} else {
+		os.Unsetenv(AzureStorageBlobEndpoint)
+	}
+}
+
+var azureContainerURLTC = []struct {
+	objPath  string
+	endpoint string
+	expURL   string
+	expErr   bool
+}{
+	{"az://account/container/path/to/", "", "https://account.blob.core.windows.net/container?restype=container", false},
+	{"az://account/container", "", "https://account.blob.core.windows.net/container?restype=container", false},
+	{"https://account.blob.core.windows.net/container/prefix", "", "https://account.blob.core.windows.net/container?restype=container", false},
+	{"az://devstoreaccount1/container/", "http://127.0.0.1:10000/devstoreaccount1/", "http://127.0.0.1:10000/devstoreaccount1/container?restype=container", false},
+	{"az://account/", "", "", true},
+	{"az://", "", "", true},
+}
+
+// go test -run TestContainerURL ./internal/azureutils/ -v -count 1
+func TestContainerURL(t *testing.T) {
+	for _, tc := range azureContainerURLTC {
+		containerURL, err := ContainerURL(AzureDto{ObjPath: tc.objPath, Endpoint: tc.endpoint})
+		assert.Equal(t, tc.expErr, err != nil, tc.objPath)
+		assert.Equal(t, tc.expURL, containerURL, tc.objPath)
+	}
+}
+
+// go test -run TestGetAzureBlobSize ./internal/azureutils/ -v -count 1
+func TestGetAzureBlobSize(t *testing.T) {
+	server := newFakeAzurite(true)
+	defer server.Close()
+	dto := AzureDto{
+		ObjPath:    "az://devstoreaccount1/container/path/to/object.bam",
+		Endpoint:   server.URL + "/" + azuriteAccount,
+		AccountKey: azuriteKey,
+	}
+
+	size, err := GetAzureBlobSize(dto)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), size)
+
+	dto.ObjPath = "az://devstoreaccount1/container/path/to/missing.bam"
+	_, err = GetAzureBlobSize(dto)
+	assert.NotNil(t, err)
+
+	// requests without a SAS token are rejected by the server
+	_, err = GetAzureBlobSize(AzureDto{
+		ObjPath:  "az://devstoreaccount1/container/path/to/object.bam",
+		Endpoint: server.URL + "/" + azuriteAccount,
+	})
+	assert.NotNil(t, err)
+}
+
+// go test -run TestGetAzureBlobRange ./internal/azureutils/ -v -count 1
+func TestGetAzureBlobRange(t *testing.T) {
+	server := newFakeAzurite(true)
+	defer server.Close()
+	dto := AzureDto{
+		ObjPath:    "az://devstoreaccount1/container/path/to/object.bam",
+		Endpoint:   server.URL + "/" + azuriteAccount,
+		AccountKey: azuriteKey,
+	}
+
+	for byteRange, exp := range map[string]string{
+		"bytes=2-4": "234",
+		"bytes=7-":  "789",
+		"":          "0123456789",
+	} {
+		body, err := GetAzureBlobRange(dto, byteRange)
+		assert.Nil(t, err)
+		content, _ := ioutil.ReadAll(body)
+		body.Close()
+		assert.Equal(t, exp, string(content))
+	}
+}
+
+// go test -run TestSignAzureBlobURL ./internal/azureutils/ -v -count 1
+func TestSignAzureBlobURL(t *testing.T) {
+	server := newFakeAzurite(true)
+	defer server.Close()
+	dto := AzureDto{
+		ObjPath:    "az://devstoreaccount1/container/path/to/object.bam",
+		Endpoint:   server.URL + "/" + azuriteAccount,
+		AccountKey: azuriteKey,
+	}
+	assert.True(t, CanSignAzureURLs(dto))
+	assert.False(t, CanSignAzureURLs(AzureDto{ObjPath: dto.ObjPath}))
+
+	signedURL, err := SignAzureBlobURL(dto, time.Hour, azureSignTestNow)
+	assert.Nil(t, err)
+	parsed, _ := url.Parse(signedURL)
+	assert.Equal(t, "2021-03-01T13:00:00Z", parsed.Query().Get("se"))
+	assert.Equal(t, AzureSASVersion, parsed.Query().Get("sv"))
+
+	// urls signed now can be followed with a range
+	signedURL, _ = SignAzureBlobURL(dto, time.Hour, time.Now())
+	req, _ := http.NewRequest("GET", signedURL, nil)
+	req.Header.Set("Range", "bytes=0-3")
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	content, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+	assert.Equal(t, "0123", string(content))
+
+	// expired and tampered urls are rejected
+	for _, rejected := range []string{
+		func() string {
+			extended, _ := url.Parse(signedURL)
+			query := extended.Query()
+			query.Set("se", "2099-01-01T00:00:00Z")
+			extended.RawQuery = query.Encode()
+			return extended.String()
+		}(),
+		func() string { expired, _ := SignAzureBlobURL(dto, time.Hour, azureSignTestNow); return expired }(),
+	} {
+		res, err := http.Get(rejected)
+		assert.Nil(t, err)
+		res.Body.Close()
+		assert.NotEqual(t, http.StatusOK, res.StatusCode)
+	}
+
+	_, err = SignAzureBlobURL(AzureDto{ObjPath: dto.ObjPath, AccountKey: "not base64!"}, time.Hour, azureSignTestNow)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,22 @@
+package azureutils
+
+const AzureProto = "az://"
+
+// AzureBlobHostSuffix host suffix of blob service endpoints
+const AzureBlobHostSuffix = ".blob.core.windows.net"
+
+// AzureStorageKey env var holding the base64 encoded storage account key,
+// used to sign SAS tokens
+const AzureStorageKey = "AZURE_STORAGE_KEY"
+
+// AzureStorageBlobEndpoint env var overriding the blob service endpoint of
+// az:// paths, including the account for path-style endpoints, e.g. Azurite
+// at http://127.0.0.1:10000/devstoreaccount1
+const AzureStorageBlobEndpoint = "AZURE_STORAGE_BLOB_ENDPOINT"
+
+// AzureSASVersion storage service version SAS tokens are signed for
+const AzureSASVersion = "2019-12-12"
+
+// AzureRequestSASExpiry expiry of SAS tokens the server signs for its own
+// requests
+const AzureRequestSASExpiry = 900
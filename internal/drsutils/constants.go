@@ -0,0 +1,22 @@
+package drsutils
+
+import "time"
+
+const DRSProto = "drs://"
+
+// DRSAPIPath base path of the DRS API on hosts named by drs:// URIs
+const DRSAPIPath = "/ga4gh/drs/v1"
+
+// DRSScheme scheme the DRS API is requested with
+const DRSScheme = "https"
+
+// DRSSupportedAccessTypes access method types resolved DRS URIs can point to,
+// those the url data access object can read from
+var DRSSupportedAccessTypes = []string{"https", "http", "s3", "gs"}
+
+// DRSRequestTimeout time DRS API requests may take, including reading the
+// response body
+const DRSRequestTimeout = 30 * time.Second
+
+// DRSCacheSize maximum number of resolved URIs kept by a resolver
+const DRSCacheSize = 1024
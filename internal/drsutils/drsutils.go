@@ -0,0 +1,179 @@
+package drsutils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// accessURL a url an object's bytes can be fetched from, and any headers the
+// request must carry
+type accessURL struct {
+	URL     string   `json:"url"`
+	Headers []string `json:"headers"`
+}
+
+// accessMethod one of the ways a DRS object can be accessed, either directly
+// through its access url, or through an access id exchanged for an access url
+type accessMethod struct {
+	Type      string     `json:"type"`
+	AccessURL *accessURL `json:"access_url"`
+	AccessID  string     `json:"access_id"`
+}
+
+// drsObject the parts of a DRS object used to resolve it
+type drsObject struct {
+	ID            string         `json:"id"`
+	AccessMethods []accessMethod `json:"access_methods"`
+}
+
+// resolution a resolved access url, and when it was resolved
+type resolution struct {
+	url      string
+	resolved time.Time
+}
+
+// Resolver resolves drs:// URIs to access urls through the DRS API of the
+// host named by the URI, caching up to cacheSize resolutions
+type Resolver struct {
+	Client    *http.Client
+	Scheme    string
+	now       func() time.Time
+	mu        sync.Mutex
+	cache     map[string]resolution
+	cacheSize int
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		Client:    &http.Client{Timeout: DRSRequestTimeout},
+		Scheme:    DRSScheme,
+		now:       time.Now,
+		cache:     make(map[string]resolution),
+		cacheSize: DRSCacheSize,
+	}
+}
+
+// IsDRSURI checks if the path is a drs:// URI
+func IsDRSURI(path string) bool {
+	return strings.HasPrefix(path, DRSProto)
+}
+
+// parseDRSURI splits hostname based drs://<host>/<id> URIs into the host
+// serving the DRS API, and the object id
+func parseDRSURI(uri string) (string, string, error) {
+	trimmedURI := strings.TrimPrefix(uri, DRSProto)
+	host := strings.Split(trimmedURI, "/")[0]
+	id := strings.TrimPrefix(trimmedURI, host+"/")
+	if host == "" || id == "" || id == trimmedURI {
+		return "", "", errors.New("invalid drs uri " + uri + ", expected drs://<host>/<id>")
+	}
+	return host, id, nil
+}
+
+// ServiceInfoURL gets the url of the service info of the DRS API serving
+// the URI. the object id may be partial or missing
+func ServiceInfoURL(uri string) (string, error) {
+	host := strings.Split(strings.TrimPrefix(uri, DRSProto), "/")[0]
+	if !IsDRSURI(uri) || host == "" {
+		return "", errors.New("invalid drs uri " + uri + ", expected drs://<host>/<id>")
+	}
+	return DRSScheme + "://" + host + DRSAPIPath + "/service-info", nil
+}
+
+// get requests a DRS API path from the host, decoding the JSON response
+func (r *Resolver) get(host string, path string, v interface{}) error {
+	res, err := r.Client.Get(r.Scheme + "://" + host + DRSAPIPath + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("DRS request for " + path + " on " + host + " failed: " + res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func isSupportedAccessType(accessType string) bool {
+	for _, supported := range DRSSupportedAccessTypes {
+		if accessType == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch resolves the URI through the DRS API, using the first access method
+// of a supported type. access urls which must be requested with additional
+// headers are skipped, as they cannot be passed on to clients
+func (r *Resolver) fetch(uri string) (string, error) {
+	host, id, err := parseDRSURI(uri)
+	if err != nil {
+		return "", err
+	}
+	object := new(drsObject)
+	if err := r.get(host, "/objects/"+url.PathEscape(id), object); err != nil {
+		return "", err
+	}
+	for _, method := range object.AccessMethods {
+		if !isSupportedAccessType(method.Type) {
+			continue
+		}
+		access := method.AccessURL
+		if access == nil && method.AccessID != "" {
+			access = new(accessURL)
+			path := "/objects/" + url.PathEscape(id) + "/access/" + url.PathEscape(method.AccessID)
+			if err := r.get(host, path, access); err != nil {
+				return "", err
+			}
+		}
+		if access != nil && access.URL != "" && len(access.Headers) == 0 {
+			return access.URL, nil
+		}
+	}
+	return "", errors.New("DRS object " + uri + " has no supported access method")
+}
+
+// Resolve gets the access url of the object named by the drs:// URI.
+// resolutions are reused until they are older than maxAge
+func (r *Resolver) Resolve(uri string, maxAge time.Duration) (string, error) {
+	now := r.now()
+	r.mu.Lock()
+	cached, ok := r.cache[uri]
+	r.mu.Unlock()
+	if ok && now.Before(cached.resolved.Add(maxAge)) {
+		return cached.url, nil
+	}
+
+	resolvedURL, err := r.fetch(uri)
+	if err != nil {
+		return "", err
+	}
+	r.store(uri, resolution{url: resolvedURL, resolved: now}, maxAge)
+	return resolvedURL, nil
+}
+
+// store caches a resolution. when the cache is full, resolutions older than
+// maxAge are evicted, then the oldest resolution if none were
+func (r *Resolver) store(uri string, res resolution, maxAge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cache[uri]; !ok && len(r.cache) >= r.cacheSize {
+		oldest := ""
+		for cachedURI, cached := range r.cache {
+			if !res.resolved.Before(cached.resolved.Add(maxAge)) {
+				delete(r.cache, cachedURI)
+			} else if oldest == "" || cached.resolved.Before(r.cache[oldest].resolved) {
+				oldest = cachedURI
+			}
+		}
+		if len(r.cache) >= r.cacheSize {
+			delete(r.cache, oldest)
+		}
+	}
+	r.cache[uri] = res
+}
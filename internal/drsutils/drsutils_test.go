@@ -0,0 +1,191 @@
+package drsutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDRSObjects DRS objects served by the stub DRS server
+var stubDRSObjects = map[string]interface{}{
+	"direct": map[string]interface{}{
+		"id": "direct",
+		"access_methods": []interface{}{
+			map[string]interface{}{"type": "ftp", "access_url": map[string]interface{}{"url": "ftp://example.org/direct.bam"}},
+			map[string]interface{}{"type": "https", "access_url": map[string]interface{}{"url": "https://example.org/direct.bam"}},
+		},
+	},
+	"byaccessid": map[string]interface{}{
+		"id": "byaccessid",
+		"access_methods": []interface{}{
+			map[string]interface{}{"type": "s3", "access_id": "s3-access"},
+		},
+	},
+	"withheaders": map[string]interface{}{
+		"id": "withheaders",
+		"access_methods": []interface{}{
+			map[string]interface{}{"type": "https", "access_url": map[string]interface{}{
+				"url":     "https://example.org/withheaders.bam",
+				"headers": []string{"Authorization: Bearer secret"},
+			}},
+		},
+	},
+	"unsupported": map[string]interface{}{
+		"id": "unsupported",
+		"access_methods": []interface{}{
+			map[string]interface{}{"type": "globus", "access_url": map[string]interface{}{"url": "globus://example.org/unsupported.bam"}},
+		},
+	},
+}
+
+var stubDRSAccess = map[string]interface{}{
+	"byaccessid/s3-access": map[string]interface{}{"url": "s3://bucket/byaccessid.bam"},
+}
+
+// newStubDRSServer serves the stub DRS objects, counting requests
+func newStubDRSServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		path := strings.TrimPrefix(r.URL.Path, DRSAPIPath+"/objects/")
+		var body interface{}
+		var ok bool
+		if strings.Contains(path, "/access/") {
+			body, ok = stubDRSAccess[strings.Replace(path, "/access/", "/", 1)]
+		} else {
+			body, ok = stubDRSObjects[path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+}
+
+var drsParseDRSURITC = []struct {
+	uri     string
+	expHost string
+	expID   string
+	expErr  bool
+}{
+	{"drs://drs.example.org/object-1", "drs.example.org", "object-1", false},
+	{"drs://localhost:8080/object-1", "localhost:8080", "object-1", false},
+	{"drs://drs.example.org/", "", "", true},
+	{"drs://drs.example.org", "", "", true},
+	{"drs:///object-1", "", "", true},
+}
+
+// go test -run TestParseDRSURI ./internal/drsutils/ -v -count 1
+func TestParseDRSURI(t *testing.T) {
+	for _, tc := range drsParseDRSURITC {
+		host, id, err := parseDRSURI(tc.uri)
+		assert.Equal(t, tc.expErr, err != nil, tc.uri)
+		assert.Equal(t, tc.expHost, host, tc.uri)
+		assert.Equal(t, tc.expID, id, tc.uri)
+	}
+	assert.True(t, IsDRSURI("drs://drs.example.org/object-1"))
+	assert.False(t, IsDRSURI("https://drs.example.org/object-1"))
+}
+
+// go test -run TestServiceInfoURL ./internal/drsutils/ -v -count 1
+func TestServiceInfoURL(t *testing.T) {
+	for _, uri := range []string{"drs://drs.example.org/object-1", "drs://drs.example.org/", "drs://drs.example.org"} {
+		serviceInfoURL, err := ServiceInfoURL(uri)
+		assert.Nil(t, err, uri)
+		assert.Equal(t, "https://drs.example.org/ga4gh/drs/v1/service-info", serviceInfoURL, uri)
+	}
+	for _, uri := range []string{"drs:///object-1", "https://drs.example.org/object-1"} {
+		_, err := ServiceInfoURL(uri)
+		assert.NotNil(t, err, uri)
+	}
+}
+
+// go test -run TestResolve ./internal/drsutils/ -v -count 1
+func TestResolve(t *testing.T) {
+	var requests int32
+	server := newStubDRSServer(&requests)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	resolver := NewResolver()
+	resolver.Scheme = "http"
+
+	for id, exp := range map[string]string{
+		"direct":      "https://example.org/direct.bam",
+		"byaccessid":  "s3://bucket/byaccessid.bam",
+		"withheaders": "",
+		"unsupported": "",
+		"missing":     "",
+	} {
+		resolvedURL, err := resolver.Resolve("drs://"+host+"/"+id, time.Minute)
+		assert.Equal(t, exp == "", err != nil, id)
+		assert.Equal(t, exp, resolvedURL, id)
+	}
+}
+
+// go test -run TestResolveCache ./internal/drsutils/ -v -count 1
+func TestResolveCache(t *testing.T) {
+	var requests int32
+	server := newStubDRSServer(&requests)
+	defer server.Close()
+	uri := "drs://" + strings.TrimPrefix(server.URL, "http://") + "/byaccessid"
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	resolver := NewResolver()
+	resolver.Scheme = "http"
+	resolver.now = func() time.Time { return now }
+
+	// the object and access requests are made once, within the max age
+	for i := 0; i < 3; i++ {
+		resolvedURL, err := resolver.Resolve(uri, time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, "s3://bucket/byaccessid.bam", resolvedURL)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	now = now.Add(2 * time.Minute)
+	resolver.Resolve(uri, time.Minute)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	// failed resolutions are not cached
+	missing := "drs://" + strings.TrimPrefix(server.URL, "http://") + "/missing"
+	resolver.Resolve(missing, time.Minute)
+	resolver.Resolve(missing, time.Minute)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&requests))
+}
+
+// go test -run TestResolveCacheSize ./internal/drsutils/ -v -count 1
+func TestResolveCacheSize(t *testing.T) {
+	var requests int32
+	server := newStubDRSServer(&requests)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	resolver := NewResolver()
+	resolver.Scheme = "http"
+	resolver.now = func() time.Time { return now }
+	resolver.cacheSize = 1
+
+	assert.Equal(t, DRSRequestTimeout, resolver.Client.Timeout)
+
+	// the oldest resolution is evicted once the cache is full
+	resolver.Resolve("drs://"+host+"/direct", time.Minute)
+	now = now.Add(time.Second)
+	resolver.Resolve("drs://"+host+"/byaccessid", time.Minute)
+	assert.Equal(t, 1, len(resolver.cache))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	resolver.Resolve("drs://"+host+"/direct", time.Minute)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	// all expired resolutions are evicted from a full cache
+	resolver.cacheSize = 2
+	resolver.store("drs://"+host+"/a", resolution{"https://example.org/a.bam", now}, time.Minute)
+	now = now.Add(2 * time.Minute)
+	resolver.store("drs://"+host+"/b", resolution{"https://example.org/b.bam", now}, time.Minute)
+	assert.Equal(t, 1, len(resolver.cache))
+	assert.Contains(t, resolver.cache, "drs://"+host+"/b")
+}
@@ -0,0 +1,20 @@
+package gcsutils
+
+const GCSProto = "gs://"
+
+// GCSEndpoint default endpoint of the GCS JSON API, and of signed urls
+const GCSEndpoint = "https://storage.googleapis.com"
+
+// GCSEmulatorHost env var pointing the client at a local GCS emulator, as
+// honoured by the official client libraries
+const GCSEmulatorHost = "STORAGE_EMULATOR_HOST"
+
+// GCSOAuthToken env var holding an OAuth access token, as used by htslib
+const GCSOAuthToken = "GCS_OAUTH_TOKEN"
+
+// GoogleApplicationCredentials env var pointing at a service account key
+// file, used to sign urls
+const GoogleApplicationCredentials = "GOOGLE_APPLICATION_CREDENTIALS"
+
+// GCSMaxSignedURLExpiry longest time V4 signed urls can remain valid for
+const GCSMaxSignedURLExpiry = 604800
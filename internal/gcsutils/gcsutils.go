@@ -0,0 +1,121 @@
+package gcsutils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type GCSDto struct {
+	ObjPath        string
+	Endpoint       string
+	Token          string
+	Client         *http.Client
+	ServiceAccount *ServiceAccount
+}
+
+func (dto *GCSDto) getBucketAndObject() (string, string) {
+	trimmedPath := strings.TrimPrefix(dto.ObjPath, GCSProto)
+	bucketName := strings.Split(trimmedPath, "/")[0]
+	objName := strings.TrimPrefix(trimmedPath, bucketName+"/")
+	return bucketName, objName
+}
+
+// getEndpoint gets the JSON API endpoint, the emulator if one is configured
+func (dto *GCSDto) getEndpoint() string {
+	endpoint := dto.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(GCSEmulatorHost)
+	}
+	if endpoint == "" {
+		return GCSEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func (dto *GCSDto) getToken() string {
+	if dto.Token != "" {
+		return dto.Token
+	}
+	return os.Getenv(GCSOAuthToken)
+}
+
+func (dto *GCSDto) getClient() *http.Client {
+	if dto.Client != nil {
+		return dto.Client
+	}
+	return http.DefaultClient
+}
+
+// objectURL gets the JSON API url of the object. the object name is escaped
+// as a single path segment, including any "/"
+func (dto *GCSDto) objectURL() string {
+	bucketName, objName := dto.getBucketAndObject()
+	return dto.getEndpoint() + "/storage/v1/b/" + url.PathEscape(bucketName) + "/o/" + url.PathEscape(objName)
+}
+
+func (dto *GCSDto) do(rawURL string, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := dto.getToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	return dto.getClient().Do(req)
+}
+
+func gcsError(objPath string, res *http.Response) error {
+	return errors.New("could not access " + objPath + ": " + res.Status)
+}
+
+// GetGCSObjectSize gets the size of the object from its metadata
+func GetGCSObjectSize(dto GCSDto) (int64, error) {
+	res, err := dto.do(dto.objectURL(), "")
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, gcsError(dto.ObjPath, res)
+	}
+
+	// sizes are encoded as strings, being 64-bit integers
+	metadata := struct {
+		Size string `json:"size"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&metadata); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(metadata.Size, 10, 64)
+}
+
+// GetGCSObjectRange reads the byte range of the object's content
+func GetGCSObjectRange(dto GCSDto, byteRange string) (io.ReadCloser, error) {
+	res, err := dto.do(dto.objectURL()+"?alt=media", byteRange)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		res.Body.Close()
+		return nil, gcsError(dto.ObjPath, res)
+	}
+	return res.Body, nil
+}
+
+// BucketURL gets the JSON API url of the bucket the path is in
+func BucketURL(dto GCSDto) string {
+	bucketName, _ := dto.getBucketAndObject()
+	return dto.getEndpoint() + "/storage/v1/b/" + url.PathEscape(bucketName)
+}
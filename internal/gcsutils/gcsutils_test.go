@@ -0,0 +1,126 @@
+package gcsutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fakeGCSObjects = map[string]string{
+	"bucket/path/to/object.bam": "0123456789",
+}
+
+// newFakeGCSServer serves object metadata and media from the JSON API paths,
+// as the GCS emulator does, optionally requiring a bearer token
+func newFakeGCSServer(token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		segments := strings.Split(r.URL.EscapedPath(), "/")
+		if len(segments) != 7 || segments[2] != "v1" || segments[3] != "b" || segments[5] != "o" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		objName, _ := url.PathUnescape(segments[6])
+		content, ok := fakeGCSObjects[segments[4]+"/"+objName]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			http.ServeContent(w, r, objName, time.Time{}, bytes.NewReader([]byte(content)))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"name": objName,
+			"size": strconv.Itoa(len(content)),
+		})
+	}))
+}
+
+// go test -run TestGCSDtoEndpoint ./internal/gcsutils/ -v -count 1
+func TestGCSDtoEndpoint(t *testing.T) {
+	emulatorHost, emulatorSet := os.LookupEnv(GCSEmulatorHost)
+	defer func() {
+		if emulatorSet {
+			os.Setenv(GCSEmulatorHost, emulatorHost)
+		} else {
+			os.Unsetenv(GCSEmulatorHost)
+		}
+	}()
+
+	dto := GCSDto{ObjPath: "gs://bucket/path/to/object.bam"}
+	os.Unsetenv(GCSEmulatorHost)
+	assert.Equal(t, "https://storage.googleapis.com/storage/v1/b/bucket/o/path%2Fto%2Fobject.bam", dto.objectURL())
+	assert.Equal(t, "https://storage.googleapis.com/storage/v1/b/bucket", BucketURL(GCSDto{ObjPath: "gs://bucket/path/to/"}))
+	os.Setenv(GCSEmulatorHost, "localhost:4443")
+	assert.Equal(t, "http://localhost:4443", dto.getEndpoint())
+	dto.Endpoint = "https://gcs.example.org/"
+	assert.Equal(t, "https://gcs.example.org", dto.getEndpoint())
+}
+
+// go test -run TestGetGCSObjectSize ./internal/gcsutils/ -v -count 1
+func TestGetGCSObjectSize(t *testing.T) {
+	server := newFakeGCSServer("token0001")
+	defer server.Close()
+
+	size, err := GetGCSObjectSize(GCSDto{
+		ObjPath:  "gs://bucket/path/to/object.bam",
+		Endpoint: server.URL,
+		Token:    "token0001",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), size)
+
+	_, err = GetGCSObjectSize(GCSDto{
+		ObjPath:  "gs://bucket/path/to/missing.bam",
+		Endpoint: server.URL,
+		Token:    "token0001",
+	})
+	assert.NotNil(t, err)
+
+	// the token is required by the server
+	_, err = GetGCSObjectSize(GCSDto{
+		ObjPath:  "gs://bucket/path/to/object.bam",
+		Endpoint: server.URL,
+	})
+	assert.NotNil(t, err)
+}
+
+// go test -run TestGetGCSObjectRange ./internal/gcsutils/ -v -count 1
+func TestGetGCSObjectRange(t *testing.T) {
+	server := newFakeGCSServer("")
+	defer server.Close()
+	dto := GCSDto{
+		ObjPath:  "gs://bucket/path/to/object.bam",
+		Endpoint: server.URL,
+	}
+
+	for byteRange, exp := range map[string]string{
+		"bytes=2-4": "234",
+		"bytes=7-":  "789",
+		"":          "0123456789",
+	} {
+		body, err := GetGCSObjectRange(dto, byteRange)
+		assert.Nil(t, err)
+		content, _ := ioutil.ReadAll(body)
+		body.Close()
+		assert.Equal(t, exp, string(content))
+	}
+
+	dto.ObjPath = "gs://bucket/path/to/missing.bam"
+	_, err := GetGCSObjectRange(dto, "bytes=0-1")
+	assert.NotNil(t, err)
+}
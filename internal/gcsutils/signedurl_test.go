@@ -0,0 +1,105 @@
+package gcsutils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var signTestNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// writeServiceAccount writes a service account key file for the key
+func writeServiceAccount(t *testing.T, dir string, key *rsa.PrivateKey) string {
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyJSON, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "htsget@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	})
+	path := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(path, keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// go test -run TestLoadServiceAccount ./internal/gcsutils/ -v -count 1
+func TestLoadServiceAccount(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "gcsutils")
+	defer os.RemoveAll(tempDir)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	account, err := LoadServiceAccount(writeServiceAccount(t, tempDir, key))
+	assert.Nil(t, err)
+	assert.Equal(t, "htsget@project.iam.gserviceaccount.com", account.ClientEmail)
+	assert.Equal(t, key.N, account.key.N)
+
+	userCredentials := filepath.Join(tempDir, "user.json")
+	ioutil.WriteFile(userCredentials, []byte(`{"type": "authorized_user"}`), 0600)
+	_, err = LoadServiceAccount(userCredentials)
+	assert.NotNil(t, err)
+
+	_, err = LoadServiceAccount(filepath.Join(tempDir, "missing.json"))
+	assert.NotNil(t, err)
+}
+
+// go test -run TestSignGCSObjectRange ./internal/gcsutils/ -v -count 1
+func TestSignGCSObjectRange(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "gcsutils")
+	defer os.RemoveAll(tempDir)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	account, _ := LoadServiceAccount(writeServiceAccount(t, tempDir, key))
+
+	dto := GCSDto{
+		ObjPath:        "gs://bucket/path/to/object+1.bam",
+		Endpoint:       GCSEndpoint,
+		ServiceAccount: account,
+	}
+	assert.True(t, CanSignGCSURLs(dto))
+	signedURL, err := SignGCSObjectRange(dto, "bytes=0-1023", 15*time.Minute, signTestNow)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(signedURL, "https://storage.googleapis.com/bucket/path/to/object%2B1.bam?"))
+
+	parsed, _ := url.Parse(signedURL)
+	query := parsed.Query()
+	assert.Equal(t, "GOOG4-RSA-SHA256", query.Get("X-Goog-Algorithm"))
+	assert.Equal(t, "htsget@project.iam.gserviceaccount.com/20210301/auto/storage/goog4_request", query.Get("X-Goog-Credential"))
+	assert.Equal(t, "20210301T120000Z", query.Get("X-Goog-Date"))
+	assert.Equal(t, "900", query.Get("X-Goog-Expires"))
+	assert.Equal(t, "host;range", query.Get("X-Goog-SignedHeaders"))
+
+	// the signature verifies against the canonical request, including the
+	// range header
+	canonicalQuery := strings.Split(parsed.RawQuery, "&X-Goog-Signature=")[0]
+	canonicalRequest := "GET\n/bucket/path/to/object%2B1.bam\n" + canonicalQuery + "\nhost:storage.googleapis.com\nrange:bytes=0-1023\n\nhost;range\nUNSIGNED-PAYLOAD"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "GOOG4-RSA-SHA256\n20210301T120000Z\n20210301/auto/storage/goog4_request\n" + hex.EncodeToString(requestHash[:])
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, _ := hex.DecodeString(query.Get("X-Goog-Signature"))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	// expiry is capped at 7 days
+	signedURL, _ = SignGCSObjectRange(dto, "bytes=0-1023", 30*24*time.Hour, signTestNow)
+	parsed, _ = url.Parse(signedURL)
+	assert.Equal(t, "604800", parsed.Query().Get("X-Goog-Expires"))
+}
+
+// go test -run TestUriEscape ./internal/gcsutils/ -v -count 1
+func TestUriEscape(t *testing.T) {
+	assert.Equal(t, "path/to/a%20b%3Ac~_-.bam", uriEscape("path/to/a b:c~_-.bam", true))
+	assert.Equal(t, "path%2Fto", uriEscape("path/to", false))
+}
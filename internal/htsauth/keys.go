@@ -0,0 +1,235 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module keys loads the public keys that token signatures are verified
+// against, from a JWKS file and/or a PEM encoded issuer key
+package htsauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+)
+
+// KeySet public keys trusted to sign tokens, by key id
+//
+// Attributes
+//	keys (map[string]interface{}): *rsa.PublicKey / *ecdsa.PublicKey by key id
+type KeySet struct {
+	keys map[string]interface{}
+}
+
+// jwk a single JSON web key, RSA or EC public key properties only
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewKeySet instantiates an empty key set
+//
+// Returns
+//	(*KeySet): key set without keys
+func NewKeySet() *KeySet {
+	keySet := new(KeySet)
+	keySet.keys = make(map[string]interface{})
+	return keySet
+}
+
+// Len gets the number of keys in the set
+//
+//	Type: KeySet
+// Returns
+//	(int): number of keys
+func (keySet *KeySet) Len() int {
+	return len(keySet.keys)
+}
+
+// candidates gets the keys that may have signed a token. if the token names
+// a key, only that key is returned, otherwise all keys are tried
+func (keySet *KeySet) candidates(kid string) []interface{} {
+	if kid != "" {
+		if key, ok := keySet.keys[kid]; ok {
+			return []interface{}{key}
+		}
+		return nil
+	}
+	keys := []interface{}{}
+	for _, key := range keySet.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// AddJWKS adds the signing keys of a JWKS document to the set
+//
+//	Type: KeySet
+// Arguments
+//	r (io.Reader): JWKS JSON document
+// Returns
+//	(error): if not nil, the document or one of its keys is invalid
+func (keySet *KeySet) AddJWKS(r io.Reader) error {
+	var jwks struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(r).Decode(&jwks); err != nil {
+		return err
+	}
+	for _, key := range jwks.Keys {
+		// keys reserved for encryption are not trusted for signatures
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return err
+		}
+		keySet.keys[key.Kid] = publicKey
+	}
+	return nil
+}
+
+// AddPEM adds a PEM encoded public key (PKIX, PKCS1 or certificate) to the
+// set. the key is used for tokens that don't name a key id
+//
+//	Type: KeySet
+// Arguments
+//	r (io.Reader): PEM encoded public key
+// Returns
+//	(error): if not nil, no supported public key could be decoded
+func (keySet *KeySet) AddPEM(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no PEM data found")
+	}
+
+	var publicKey interface{}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		certificate, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			publicKey = certificate.PublicKey
+		}
+	default:
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return err
+	}
+	switch publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		keySet.keys[""] = publicKey
+		return nil
+	}
+	return errors.New("unsupported public key type")
+}
+
+// LoadKeySet loads the key set from the configured JWKS and/or PEM files
+//
+// Arguments
+//	jwksFile (string): path to JWKS file, ignored if empty
+//	pemFile (string): path to PEM encoded issuer key, ignored if empty
+// Returns
+//	(*KeySet): trusted signing keys
+//	(error): if not nil, a file could not be read, or no keys were found
+func LoadKeySet(jwksFile string, pemFile string) (*KeySet, error) {
+	keySet := NewKeySet()
+	loaders := []struct {
+		path string
+		add  func(io.Reader) error
+	}{
+		{jwksFile, keySet.AddJWKS},
+		{pemFile, keySet.AddPEM},
+	}
+	for _, loader := range loaders {
+		if loader.path == "" {
+			continue
+		}
+		file, err := os.Open(loader.path)
+		if err != nil {
+			return nil, err
+		}
+		err = loader.add(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.New(loader.path + ": " + err.Error())
+		}
+	}
+	if keySet.Len() == 0 {
+		return nil, errors.New("no token signing keys configured")
+	}
+	return keySet, nil
+}
+
+// publicKey decodes the RSA or EC public key of the JWK
+func (key *jwk) publicKey() (interface{}, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}
+		curve, ok := curves[key.Crv]
+		if !ok {
+			return nil, errors.New("unsupported EC curve: " + key.Crv)
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC public key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.New("unsupported key type: " + key.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,136 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module keys_test tests module keys
+package htsauth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testJWKS JWKS document holding the public test RSA and EC keys
+func testJWKS() []byte {
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa-key",
+				"use": "sig",
+				"n":   encode(testRSAKey.N),
+				"e":   encode(big.NewInt(int64(testRSAKey.E))),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-key",
+				"crv": "P-256",
+				"x":   encode(testECKey.X),
+				"y":   encode(testECKey.Y),
+			},
+			{
+				"kty": "RSA",
+				"kid": "enc-key",
+				"use": "enc",
+				"n":   encode(testOtherRSAKey.N),
+				"e":   encode(big.NewInt(int64(testOtherRSAKey.E))),
+			},
+		},
+	}
+	data, _ := json.Marshal(jwks)
+	return data
+}
+
+// testPEM PEM encoded public test RSA key
+func testPEM() []byte {
+	der, _ := x509.MarshalPKIXPublicKey(&testRSAKey.PublicKey)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// TestKeysAddJWKS tests AddJWKS function
+func TestKeysAddJWKS(t *testing.T) {
+	keySet := NewKeySet()
+	assert.Nil(t, keySet.AddJWKS(bytes.NewReader(testJWKS())))
+	// the encryption key is skipped
+	assert.Equal(t, 2, keySet.Len())
+
+	for _, token := range []string{
+		signTestToken("RS256", "rsa-key", testRSAKey, testClaims()),
+		signTestToken("ES256", "ec-key", testECKey, testClaims()),
+	} {
+		_, err := ParseToken(token, keySet, testNow)
+		assert.Nil(t, err)
+	}
+	_, err := ParseToken(signTestToken("RS256", "enc-key", testOtherRSAKey, testClaims()), keySet, testNow)
+	assert.NotNil(t, err)
+}
+
+// keysAddJWKSErrorTC test cases for invalid JWKS documents
+var keysAddJWKSErrorTC = []string{
+	"not json",
+	`{"keys":[{"kty":"oct","kid":"a","k":"c2VjcmV0"}]}`,
+	`{"keys":[{"kty":"RSA","kid":"a","n":"","e":"AQAB"}]}`,
+	`{"keys":[{"kty":"EC","kid":"a","crv":"P-192","x":"AQ","y":"AQ"}]}`,
+	`{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+}
+
+// TestKeysAddJWKSError tests AddJWKS rejects invalid documents
+func TestKeysAddJWKSError(t *testing.T) {
+	for _, tc := range keysAddJWKSErrorTC {
+		assert.NotNil(t, NewKeySet().AddJWKS(strings.NewReader(tc)))
+	}
+}
+
+// TestKeysAddPEM tests AddPEM function
+func TestKeysAddPEM(t *testing.T) {
+	keySet := NewKeySet()
+	assert.Nil(t, keySet.AddPEM(bytes.NewReader(testPEM())))
+	_, err := ParseToken(signTestToken("RS256", "", testRSAKey, testClaims()), keySet, testNow)
+	assert.Nil(t, err)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&testRSAKey.PublicKey)})
+	assert.Nil(t, NewKeySet().AddPEM(bytes.NewReader(pkcs1)))
+
+	assert.NotNil(t, NewKeySet().AddPEM(strings.NewReader("not pem")))
+	garbage := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")})
+	assert.NotNil(t, NewKeySet().AddPEM(bytes.NewReader(garbage)))
+}
+
+// TestKeysLoadKeySet tests LoadKeySet function
+func TestKeysLoadKeySet(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsauth")
+	defer os.RemoveAll(dir)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	pemFile := filepath.Join(dir, "issuer.pem")
+	ioutil.WriteFile(jwksFile, testJWKS(), 0644)
+	ioutil.WriteFile(pemFile, testPEM(), 0644)
+
+	keySet, err := LoadKeySet(jwksFile, pemFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, keySet.Len())
+
+	keySet, err = LoadKeySet("", pemFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, keySet.Len())
+
+	_, err = LoadKeySet("", "")
+	assert.NotNil(t, err)
+	_, err = LoadKeySet(filepath.Join(dir, "missing.json"), "")
+	assert.NotNil(t, err)
+	_, err = LoadKeySet(pemFile, "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,108 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module middleware contains the http middleware rejecting unauthenticated
+// or unauthorized requests with htsget errors
+package htsauth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
+)
+
+// Options configures the auth middleware
+//
+// Attributes
+//	KeySet (*KeySet): keys trusted to sign tokens
+//	Issuer (string): if not empty, tokens must have been issued by this issuer
+//	Audience (string): if not empty, tokens must have been issued for this audience
+//	Authorize (func): if not nil, decides whether an authenticated request
+//		may proceed. otherwise, all authenticated requests proceed
+type Options struct {
+	KeySet    *KeySet
+	Issuer    string
+	Audience  string
+	Authorize func(request *http.Request, claims *Claims) bool
+}
+
+// claimsContextKey key of the verified claims in the request context
+type claimsContextKey struct{}
+
+// Handler creates middleware requiring a valid bearer token on each request.
+// requests without a valid token fail with InvalidAuthentication (401), and
+// requests rejected by the Authorize func fail with PermissionDenied (403)
+//
+// Arguments
+//	options (Options): middleware configuration
+// Returns
+//	(func(next http.Handler) http.Handler): the middleware
+func Handler(options Options) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			claims, msg := options.authenticate(request)
+			if claims == nil {
+				writer.Header().Set("WWW-Authenticate", "Bearer")
+				htserror.InvalidAuthentication(writer, &msg)
+				return
+			}
+			if options.Authorize != nil && !options.Authorize(request, claims) {
+				msg := "The provided token does not grant access to the requested resource"
+				htserror.PermissionDenied(writer, &msg)
+				return
+			}
+			ctx := context.WithValue(request.Context(), claimsContextKey{}, claims)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// authenticate verifies the request's bearer token. if the token is not
+// valid, the reason is returned instead of the claims
+func (options *Options) authenticate(request *http.Request) (*Claims, string) {
+	token := BearerToken(request)
+	if token == "" {
+		return nil, "A bearer token is required to access the requested resource"
+	}
+	claims, err := ParseToken(token, options.KeySet, time.Now())
+	if err != nil {
+		return nil, "Invalid bearer token: " + err.Error()
+	}
+	if options.Issuer != "" && claims.Issuer != options.Issuer {
+		return nil, "Invalid bearer token: unexpected issuer"
+	}
+	if options.Audience != "" && !claims.HasAudience(options.Audience) {
+		return nil, "Invalid bearer token: unexpected audience"
+	}
+	return claims, ""
+}
+
+// BearerToken gets the token from the request's Authorization header
+//
+// Arguments
+//	request (*http.Request): the http request
+// Returns
+//	(string): bearer token, empty if the request has none
+func BearerToken(request *http.Request) string {
+	authorization := request.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authorization[7:])
+}
+
+// ClaimsFromContext gets the verified claims of the request's token, as
+// stored by the middleware
+//
+// Arguments
+//	ctx (context.Context): request context
+// Returns
+//	(*Claims): verified token claims, nil if the request was not authenticated
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims
+}
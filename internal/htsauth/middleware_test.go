@@ -0,0 +1,102 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module middleware_test tests module middleware
+package htsauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// middlewareTestClaims claims valid now, rather than at testNow
+func middlewareTestClaims() map[string]interface{} {
+	claims := testClaims()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["nbf"] = time.Now().Add(-time.Hour).Unix()
+	return claims
+}
+
+// middlewareHandlerTC test cases for Handler
+var middlewareHandlerTC = []struct {
+	name          string
+	authorization func() string
+	expCode       int
+	expError      string
+}{
+	{"valid", func() string {
+		return "Bearer " + signTestToken("RS256", "rsa-key", testRSAKey, middlewareTestClaims())
+	}, 200, ""},
+	{"lowercase scheme", func() string {
+		return "bearer " + signTestToken("RS256", "rsa-key", testRSAKey, middlewareTestClaims())
+	}, 200, ""},
+	{"missing", func() string { return "" }, 401, "InvalidAuthentication"},
+	{"basic auth", func() string { return "Basic dXNlcjpwYXNz" }, 401, "InvalidAuthentication"},
+	{"untrusted", func() string {
+		return "Bearer " + signTestToken("RS256", "", testOtherRSAKey, middlewareTestClaims())
+	}, 401, "InvalidAuthentication"},
+	{"wrong issuer", func() string {
+		claims := middlewareTestClaims()
+		claims["iss"] = "https://other.example.org"
+		return "Bearer " + signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, 401, "InvalidAuthentication"},
+	{"wrong audience", func() string {
+		claims := middlewareTestClaims()
+		claims["aud"] = "other"
+		return "Bearer " + signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, 401, "InvalidAuthentication"},
+	{"forbidden", func() string {
+		claims := middlewareTestClaims()
+		claims["sub"] = "user0002"
+		return "Bearer " + signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, 403, "PermissionDenied"},
+}
+
+// TestMiddlewareHandler tests Handler function
+func TestMiddlewareHandler(t *testing.T) {
+	var subject string
+	next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		subject = ClaimsFromContext(request.Context()).Subject
+	})
+	handler := Handler(Options{
+		KeySet:   testKeySet(),
+		Issuer:   "https://issuer.example.org",
+		Audience: "htsget",
+		Authorize: func(request *http.Request, claims *Claims) bool {
+			return claims.Subject == "user0001"
+		},
+	})(next)
+
+	for _, tc := range middlewareHandlerTC {
+		subject = ""
+		request := httptest.NewRequest("GET", "/reads/object0001", nil)
+		if authorization := tc.authorization(); authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, request)
+
+		assert.Equal(t, tc.expCode, writer.Code, tc.name)
+		if tc.expCode == 200 {
+			assert.Equal(t, "user0001", subject, tc.name)
+		} else {
+			assert.Equal(t, "", subject, tc.name)
+			assert.Contains(t, writer.Body.String(), "\"error\":\""+tc.expError+"\"", tc.name)
+		}
+		if tc.expCode == 401 {
+			assert.Equal(t, "Bearer", writer.Header().Get("WWW-Authenticate"), tc.name)
+		}
+	}
+}
+
+// TestMiddlewareClaimsFromContext tests ClaimsFromContext on an
+// unauthenticated request
+func TestMiddlewareClaimsFromContext(t *testing.T) {
+	request := httptest.NewRequest("GET", "/reads/object0001", nil)
+	assert.Nil(t, ClaimsFromContext(request.Context()))
+}
@@ -0,0 +1,62 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module passport extracts dataset access grants from GA4GH Passport visas
+package htsauth
+
+import (
+	"time"
+)
+
+// VisaTypeControlledAccessGrants the visa type granting access to a dataset
+const VisaTypeControlledAccessGrants = "ControlledAccessGrants"
+
+// Visa the 'ga4gh_visa_v1' claim of a GA4GH Passport visa
+//
+// Attributes
+//	Type (string): visa type, e.g. ControlledAccessGrants
+//	Value (string): visa value, e.g. the url of the dataset access is granted to
+//	Source (string): the organization that made the assertion
+//	By (string): the entity that made the assertion, e.g. dac
+//	Asserted (float64): time of the assertion, seconds since epoch
+type Visa struct {
+	Type     string  `json:"type"`
+	Value    string  `json:"value"`
+	Source   string  `json:"source"`
+	By       string  `json:"by"`
+	Asserted float64 `json:"asserted"`
+}
+
+// ControlledAccessGrants gets the values of all ControlledAccessGrants visas
+// held by the token: the visa carried by the token itself, if any, and the
+// visas of its passport. passport visas are individually signed tokens,
+// which are only trusted if signed by a key in the key set
+//
+//	Type: Claims
+// Arguments
+//	keySet (*KeySet): keys trusted to sign visas
+//	now (time.Time): time visa validity is checked against
+// Returns
+//	([]string): values of the valid ControlledAccessGrants visas
+func (claims *Claims) ControlledAccessGrants(keySet *KeySet, now time.Time) []string {
+	visas := []*Visa{}
+	if claims.Visa != nil {
+		visas = append(visas, claims.Visa)
+	}
+	for _, visaToken := range claims.Passport {
+		visaClaims, err := ParseToken(visaToken, keySet, now)
+		if err != nil || visaClaims.Visa == nil {
+			continue
+		}
+		visas = append(visas, visaClaims.Visa)
+	}
+
+	grants := []string{}
+	for _, visa := range visas {
+		if visa.Type == VisaTypeControlledAccessGrants && visa.Value != "" {
+			grants = append(grants, visa.Value)
+		}
+	}
+	return grants
+}
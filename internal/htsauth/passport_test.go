@@ -0,0 +1,56 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module passport_test tests module passport
+package htsauth
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testVisaToken creates a signed visa token
+func testVisaToken(key crypto.Signer, visaType string, value string, exp time.Time) string {
+	return signTestToken("RS256", "", key, map[string]interface{}{
+		"iss": "https://visas.example.org",
+		"sub": "user0001",
+		"exp": exp.Unix(),
+		"ga4gh_visa_v1": map[string]interface{}{
+			"type":     visaType,
+			"value":    value,
+			"source":   "https://dac.example.org",
+			"by":       "dac",
+			"asserted": testNow.Add(-24 * time.Hour).Unix(),
+		},
+	})
+}
+
+// TestPassportControlledAccessGrants tests ControlledAccessGrants function
+func TestPassportControlledAccessGrants(t *testing.T) {
+	keySet := testKeySet()
+	valid := testNow.Add(time.Hour)
+	claims := testClaims()
+	claims["ga4gh_passport_v1"] = []string{
+		testVisaToken(testRSAKey, VisaTypeControlledAccessGrants, "https://dac.example.org/datasets/1", valid),
+		testVisaToken(testRSAKey, "AffiliationAndRole", "faculty@example.org", valid),
+		testVisaToken(testRSAKey, VisaTypeControlledAccessGrants, "https://dac.example.org/datasets/2", testNow.Add(-time.Hour)),
+		testVisaToken(testOtherRSAKey, VisaTypeControlledAccessGrants, "https://dac.example.org/datasets/3", valid),
+		"not a visa",
+	}
+	parsed, err := ParseToken(signTestToken("RS256", "rsa-key", testRSAKey, claims), keySet, testNow)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://dac.example.org/datasets/1"}, parsed.ControlledAccessGrants(keySet, testNow))
+
+	// a visa used directly as the bearer token
+	visa, err := ParseToken(testVisaToken(testRSAKey, VisaTypeControlledAccessGrants, "https://dac.example.org/datasets/4", valid), keySet, testNow)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://dac.example.org/datasets/4"}, visa.ControlledAccessGrants(keySet, testNow))
+
+	// no visas
+	parsed, _ = ParseToken(signTestToken("RS256", "rsa-key", testRSAKey, testClaims()), keySet, testNow)
+	assert.Empty(t, parsed.ControlledAccessGrants(keySet, testNow))
+}
@@ -0,0 +1,162 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module signedurl signs the urls returned in tickets with an expiring HMAC,
+// so that data can be downloaded without further authentication, but only
+// for the object, range and time granted when the ticket was issued
+package htsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
+)
+
+// query parameters holding the expiry time and signature of signed urls
+const (
+	ParamExpires   = "expires"
+	ParamSignature = "signature"
+)
+
+// signedHeaders ticket url headers covered by the signature. the range and
+// block headers both affect the data served
+var signedHeaders = []string{
+	"Range",
+	"HtsgetBlockClass",
+	"HtsgetCurrentBlock",
+	"HtsgetTotalBlocks",
+}
+
+// URLSigner signs and verifies urls with a secret key
+//
+// Attributes
+//	key ([]byte): HMAC secret key
+//	expiry (time.Duration): time signed urls remain valid for
+type URLSigner struct {
+	key    []byte
+	expiry time.Duration
+}
+
+// NewURLSigner instantiates a URLSigner
+//
+// Arguments
+//	key ([]byte): HMAC secret key, shared by all server instances urls may be
+//		followed to
+//	expiry (time.Duration): time signed urls remain valid for
+// Returns
+//	(*URLSigner): the url signer
+//	(error): if not nil, the key is empty
+func NewURLSigner(key []byte, expiry time.Duration) (*URLSigner, error) {
+	if len(key) == 0 {
+		return nil, errors.New("url signing key is empty")
+	}
+	signer := new(URLSigner)
+	signer.key = key
+	signer.expiry = expiry
+	return signer, nil
+}
+
+// signature computes the HMAC of the path, query, expiry and headers
+func (signer *URLSigner) signature(path string, query url.Values, expires string, headers http.Header) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != ParamExpires && key != ParamSignature {
+			unsigned[key] = values
+		}
+	}
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(path + "\n" + unsigned.Encode() + "\n" + expires + "\n"))
+	for _, name := range signedHeaders {
+		mac.Write([]byte(name + ":" + headers.Get(name) + "\n"))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign gets the expiry and signature query string for a url, to be appended
+// to its existing query string
+//
+//	Type: URLSigner
+// Arguments
+//	path (string): escaped url path, as seen by the server
+//	query (url.Values): url query parameters
+//	headers (http.Header): headers the client will send with the request
+//	now (time.Time): time the expiry is counted from
+// Returns
+//	(string): encoded expiry and signature query parameters
+func (signer *URLSigner) Sign(path string, query url.Values, headers http.Header, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(signer.expiry).Unix(), 10)
+	return ParamExpires + "=" + expires + "&" + ParamSignature + "=" + signer.signature(path, query, expires, headers)
+}
+
+// Verify checks a request's url was signed, has not expired, and that
+// neither the url nor the signed headers were modified
+//
+//	Type: URLSigner
+// Arguments
+//	request (*http.Request): request for a signed url
+//	now (time.Time): time the expiry is checked against
+// Returns
+//	(error): if not nil, the url is unsigned, expired, or was modified
+func (signer *URLSigner) Verify(request *http.Request, now time.Time) error {
+	query := request.URL.Query()
+	signature := query.Get(ParamSignature)
+	expires := query.Get(ParamExpires)
+	if signature == "" || expires == "" {
+		return errors.New("url is not signed")
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("invalid url expiry")
+	}
+	expected := signer.signature(request.URL.EscapedPath(), query, expires, request.Header)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("invalid url signature")
+	}
+	if now.Unix() > expiresAt {
+		return errors.New("url has expired")
+	}
+	return nil
+}
+
+// SignedURLHandler creates middleware only letting through requests for
+// signed urls, failing others with InvalidAuthentication (401)
+//
+// Arguments
+//	signer (*URLSigner): signer the urls were signed with
+// Returns
+//	(func(next http.Handler) http.Handler): the middleware
+func SignedURLHandler(signer *URLSigner) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if err := signer.Verify(request, time.Now()); err != nil {
+				msg := "Invalid data url: " + err.Error() + ". Request a new ticket"
+				htserror.InvalidAuthentication(writer, &msg)
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// AppendQuery appends encoded query parameters to a url string
+//
+// Arguments
+//	rawURL (string): url, with or without a query string
+//	query (string): encoded query parameters
+// Returns
+//	(string): url with the parameters appended
+func AppendQuery(rawURL string, query string) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + query
+	}
+	return rawURL + "?" + query
+}
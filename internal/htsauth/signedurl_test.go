@@ -0,0 +1,114 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module signedurl_test tests module signedurl
+package htsauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signedURLTestHeaders headers sent with the signed test url
+func signedURLTestHeaders() http.Header {
+	headers := http.Header{}
+	headers.Set("Range", "bytes=0-99")
+	headers.Set("HtsgetBlockClass", "header")
+	return headers
+}
+
+// signedURLTestRequest signs the test url, then lets the test case modify the
+// request before verification
+func signedURLTestRequest(signer *URLSigner, modify func(*http.Request)) *http.Request {
+	rawURL := "http://localhost:3000/reads/data/object0001?class=header"
+	parsed, _ := url.Parse(rawURL)
+	signed := AppendQuery(rawURL, signer.Sign(parsed.EscapedPath(), parsed.Query(), signedURLTestHeaders(), testNow))
+	request := httptest.NewRequest("GET", signed, nil)
+	request.Header = signedURLTestHeaders()
+	if modify != nil {
+		modify(request)
+	}
+	return request
+}
+
+// signedURLVerifyTC test cases for Verify
+var signedURLVerifyTC = []struct {
+	name   string
+	modify func(*http.Request)
+	now    time.Time
+	expErr bool
+}{
+	{"valid", nil, testNow, false},
+	{"before expiry", nil, testNow.Add(time.Hour), false},
+	{"expired", nil, testNow.Add(time.Hour + time.Second), true},
+	{"different range", func(request *http.Request) {
+		request.Header.Set("Range", "bytes=0-9999")
+	}, testNow, true},
+	{"different class", func(request *http.Request) {
+		request.Header.Set("HtsgetBlockClass", "body")
+	}, testNow, true},
+	{"different id", func(request *http.Request) {
+		request.URL.Path = "/reads/data/object0002"
+	}, testNow, true},
+	{"different query", func(request *http.Request) {
+		request.URL.RawQuery = strings.Replace(request.URL.RawQuery, "class=header", "class=body", 1)
+	}, testNow, true},
+	{"extended expiry", func(request *http.Request) {
+		query := request.URL.Query()
+		query.Set(ParamExpires, "99999999999")
+		request.URL.RawQuery = query.Encode()
+	}, testNow, true},
+	{"unsigned", func(request *http.Request) {
+		request.URL.RawQuery = "class=header"
+	}, testNow, true},
+}
+
+// TestSignedURLVerify tests Sign and Verify functions
+func TestSignedURLVerify(t *testing.T) {
+	signer, err := NewURLSigner([]byte("secret"), time.Hour)
+	assert.Nil(t, err)
+	for _, tc := range signedURLVerifyTC {
+		err := signer.Verify(signedURLTestRequest(signer, tc.modify), tc.now)
+		assert.Equal(t, tc.expErr, err != nil, tc.name)
+	}
+
+	// urls signed with another key are rejected
+	other, _ := NewURLSigner([]byte("other"), time.Hour)
+	assert.NotNil(t, signer.Verify(signedURLTestRequest(other, nil), testNow))
+
+	// a key is required
+	_, err = NewURLSigner(nil, time.Hour)
+	assert.NotNil(t, err)
+}
+
+// TestSignedURLHandler tests SignedURLHandler function
+func TestSignedURLHandler(t *testing.T) {
+	signer, _ := NewURLSigner([]byte("secret"), time.Hour)
+	handler := SignedURLHandler(signer)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+
+	// signed at testNow, so expired
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, signedURLTestRequest(signer, nil))
+	assert.Equal(t, 401, writer.Code)
+	assert.Contains(t, writer.Body.String(), "\"error\":\"InvalidAuthentication\"")
+
+	rawURL := "http://localhost:3000/reads/file-bytes/object0001"
+	request := httptest.NewRequest("GET", AppendQuery(rawURL, signer.Sign("/reads/file-bytes/object0001", url.Values{}, signedURLTestHeaders(), time.Now())), nil)
+	request.Header = signedURLTestHeaders()
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, request)
+	assert.Equal(t, 200, writer.Code)
+}
+
+// TestSignedURLAppendQuery tests AppendQuery function
+func TestSignedURLAppendQuery(t *testing.T) {
+	assert.Equal(t, "http://a/b?c=d", AppendQuery("http://a/b", "c=d"))
+	assert.Equal(t, "http://a/b?e=f&c=d", AppendQuery("http://a/b?e=f", "c=d"))
+}
@@ -0,0 +1,201 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module token verifies JWT signatures and registered claims
+package htsauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	// hash implementations used by the supported signature algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// clockSkew tolerance when checking token expiry / not before times
+const clockSkew = 60 * time.Second
+
+// signing algorithms accepted in token headers. symmetric algorithms and
+// unsigned ('none') tokens are never accepted
+var algorithmHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// curve sizes of the ES algorithms
+var ecdsaCurveBits = map[string]int{
+	"ES256": 256,
+	"ES384": 384,
+	"ES512": 521,
+}
+
+// audience the 'aud' claim, which may be a single string or a list
+type audience []string
+
+// UnmarshalJSON decodes the 'aud' claim from either representation
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*aud = list
+	return nil
+}
+
+// Claims the claims of a verified token relevant to htsget
+//
+// Attributes
+//	Issuer (string): 'iss', the token issuer
+//	Subject (string): 'sub', the user the token was issued to
+//	Audience ([]string): 'aud', the intended recipients of the token
+//	ExpiresAt (float64): 'exp', expiry time, seconds since epoch
+//	NotBefore (float64): 'nbf', start of validity, seconds since epoch
+//	Passport ([]string): 'ga4gh_passport_v1', encoded visa tokens
+//	Visa (*Visa): 'ga4gh_visa_v1', set if the token is itself a visa
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt float64  `json:"exp"`
+	NotBefore float64  `json:"nbf"`
+	Passport  []string `json:"ga4gh_passport_v1"`
+	Visa      *Visa    `json:"ga4gh_visa_v1"`
+}
+
+// tokenHeader the JOSE header of a token
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// ParseToken verifies the signature and validity period of a compact
+// serialized JWT, and decodes its claims
+//
+// Arguments
+//	token (string): encoded JWT
+//	keySet (*KeySet): keys trusted to sign the token
+//	now (time.Time): time the validity period is checked against
+// Returns
+//	(*Claims): the token's claims
+//	(error): if not nil, the token is malformed, unsigned, has no expiry, has expired, or was not signed by a trusted key
+func ParseToken(token string, keySet *KeySet, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	header := new(tokenHeader)
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	if err := verifySignature(header, parts[0]+"."+parts[1], signature, keySet); err != nil {
+		return nil, err
+	}
+
+	claims := new(Claims)
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	// tokens without an expiry would be valid forever
+	if claims.ExpiresAt == 0 {
+		return nil, errors.New("token has no expiry")
+	}
+	if now.After(unixTime(claims.ExpiresAt).Add(clockSkew)) {
+		return nil, errors.New("token has expired")
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(unixTime(claims.NotBefore)) {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+// HasAudience checks if the token was issued for the given audience
+//
+//	Type: Claims
+// Arguments
+//	aud (string): expected audience
+// Returns
+//	(bool): true if the audience is listed in the 'aud' claim
+func (claims *Claims) HasAudience(aud string) bool {
+	for _, tokenAud := range claims.Audience {
+		if tokenAud == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature checks the signature against all candidate keys of the
+// token's algorithm
+func verifySignature(header *tokenHeader, signed string, signature []byte, keySet *KeySet) error {
+	hash, ok := algorithmHashes[header.Alg]
+	if !ok {
+		return errors.New("unsupported token signing algorithm: " + header.Alg)
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	for _, key := range keySet.candidates(header.Kid) {
+		switch publicKey := key.(type) {
+		case *rsa.PublicKey:
+			if strings.HasPrefix(header.Alg, "RS") && rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) == nil {
+				return nil
+			}
+			if strings.HasPrefix(header.Alg, "PS") && rsa.VerifyPSS(publicKey, hash, digest, signature, nil) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			// ES signatures are the fixed size concatenation of r and s, the
+			// curve being determined by the algorithm
+			bitSize := publicKey.Curve.Params().BitSize
+			size := (bitSize + 7) / 8
+			if ecdsaCurveBits[header.Alg] == bitSize && len(signature) == 2*size {
+				r := new(big.Int).SetBytes(signature[:size])
+				s := new(big.Int).SetBytes(signature[size:])
+				if ecdsa.Verify(publicKey, digest, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("token signature could not be verified")
+}
+
+// decodeSegment decodes a base64url encoded JSON token segment
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate claim to a time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
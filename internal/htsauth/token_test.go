@@ -0,0 +1,172 @@
+// Package htsauth authenticates requests bearing JWT access tokens, and
+// exposes the token claims (including GA4GH Passport visas) used to
+// authorize access to individual datasets
+//
+// Module token_test tests module token
+package htsauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test signing keys, generated once per test run
+var testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+var testECKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+var testOtherRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+// testNow fixed time tokens are checked against
+var testNow = time.Unix(1600000000, 0)
+
+// testKeySet key set trusting the test RSA key (as "rsa-key") and EC key
+// (as "ec-key")
+func testKeySet() *KeySet {
+	keySet := NewKeySet()
+	keySet.keys["rsa-key"] = &testRSAKey.PublicKey
+	keySet.keys["ec-key"] = &testECKey.PublicKey
+	return keySet
+}
+
+// signTestToken creates a token with the given header fields and claims
+func signTestToken(alg string, kid string, key crypto.Signer, claims interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := algorithmHashes[alg]
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "PS") {
+			signature, _ = rsa.SignPSS(rand.Reader, k, hash, digest, nil)
+		} else {
+			signature, _ = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, digest)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = append(padBigInt(r, size), padBigInt(s, size)...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// padBigInt encodes an integer as fixed size big-endian bytes
+func padBigInt(i *big.Int, size int) []byte {
+	b := i.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// testClaims claims valid at testNow
+func testClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss": "https://issuer.example.org",
+		"sub": "user0001",
+		"aud": "htsget",
+		"exp": testNow.Add(time.Hour).Unix(),
+		"nbf": testNow.Add(-time.Hour).Unix(),
+	}
+}
+
+// tokenParseTC test cases for ParseToken
+var tokenParseTC = []struct {
+	name  string
+	token func() string
+	valid bool
+}{
+	{"RS256", func() string { return signTestToken("RS256", "rsa-key", testRSAKey, testClaims()) }, true},
+	{"RS512", func() string { return signTestToken("RS512", "rsa-key", testRSAKey, testClaims()) }, true},
+	{"PS256", func() string { return signTestToken("PS256", "rsa-key", testRSAKey, testClaims()) }, true},
+	{"ES256", func() string { return signTestToken("ES256", "ec-key", testECKey, testClaims()) }, true},
+	{"no kid", func() string { return signTestToken("RS256", "", testRSAKey, testClaims()) }, true},
+	{"ES384 on P-256 key", func() string { return signTestToken("ES384", "ec-key", testECKey, testClaims()) }, false},
+	{"untrusted key", func() string { return signTestToken("RS256", "", testOtherRSAKey, testClaims()) }, false},
+	{"unknown kid", func() string { return signTestToken("RS256", "other", testRSAKey, testClaims()) }, false},
+	{"wrong kid", func() string { return signTestToken("RS256", "ec-key", testRSAKey, testClaims()) }, false},
+	{"HS256", func() string { return signTestToken("HS256", "rsa-key", testRSAKey, testClaims()) }, false},
+	{"none", func() string {
+		parts := strings.Split(signTestToken("RS256", "rsa-key", testRSAKey, testClaims()), ".")
+		header, _ := json.Marshal(map[string]string{"alg": "none"})
+		return base64.RawURLEncoding.EncodeToString(header) + "." + parts[1] + "."
+	}, false},
+	{"expired", func() string {
+		claims := testClaims()
+		claims["exp"] = testNow.Add(-time.Hour).Unix()
+		return signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, false},
+	{"no expiry", func() string {
+		claims := testClaims()
+		delete(claims, "exp")
+		return signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, false},
+	{"not yet valid", func() string {
+		claims := testClaims()
+		claims["nbf"] = testNow.Add(time.Hour).Unix()
+		return signTestToken("RS256", "rsa-key", testRSAKey, claims)
+	}, false},
+	{"tampered", func() string {
+		parts := strings.Split(signTestToken("RS256", "rsa-key", testRSAKey, testClaims()), ".")
+		claims := testClaims()
+		claims["sub"] = "admin"
+		payload, _ := json.Marshal(claims)
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		return strings.Join(parts, ".")
+	}, false},
+	{"malformed", func() string { return "not.a.token" }, false},
+	{"two segments", func() string { return "abc.def" }, false},
+}
+
+// TestTokenParseToken tests ParseToken function
+func TestTokenParseToken(t *testing.T) {
+	keySet := testKeySet()
+	for _, tc := range tokenParseTC {
+		claims, err := ParseToken(tc.token(), keySet, testNow)
+		if tc.valid {
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, "user0001", claims.Subject, tc.name)
+		} else {
+			assert.NotNil(t, err, tc.name)
+			assert.Nil(t, claims, tc.name)
+		}
+	}
+}
+
+// tokenAudienceTC test cases for HasAudience
+var tokenAudienceTC = []struct {
+	aud    interface{}
+	expect bool
+}{
+	{"htsget", true},
+	{[]string{"other", "htsget"}, true},
+	{"other", false},
+	{nil, false},
+}
+
+// TestTokenHasAudience tests HasAudience function, for single and list
+// 'aud' claims
+func TestTokenHasAudience(t *testing.T) {
+	keySet := testKeySet()
+	for _, tc := range tokenAudienceTC {
+		claims := testClaims()
+		claims["aud"] = tc.aud
+		parsed, err := ParseToken(signTestToken("RS256", "rsa-key", testRSAKey, claims), keySet, testNow)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expect, parsed.HasAudience("htsget"))
+	}
+}
@@ -0,0 +1,175 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module bgzf contains reading and writing of single BGZF blocks
+package htsbgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+// MaxBlockSize maximum size (number of bytes) of a single BGZF block, both
+// compressed and uncompressed
+const MaxBlockSize = 65536
+
+// maxBlockDataSize the maximum number of uncompressed bytes written to a
+// single block, leaving room for incompressible data to fit in MaxBlockSize
+const maxBlockDataSize = 0xff00
+
+// fixed BGZF block header: gzip magic, deflate method, FEXTRA flag, zeroed
+// mtime, xfl, unknown os, 6 byte extra field holding the 'BC' subfield
+var blockHeaderPrefix = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00,
+	0x42, 0x43, 0x02, 0x00,
+}
+
+// gzip header and footer lengths, excluding the extra field
+const gzipHeaderLen = 12
+const gzipFooterLen = 8
+
+// Block a single BGZF block, and its decompressed contents
+//
+// Attributes
+//	Offset (int64): byte position of the block in the compressed file
+//	Size (int): size of the compressed block, including header and footer
+//	Data ([]byte): decompressed block contents
+type Block struct {
+	Offset int64
+	Size   int
+	Data   []byte
+}
+
+// NextOffset gets the compressed file position of the block following this one
+//
+//	Type: Block
+// Returns
+//	(int64): byte position of the next block
+func (block *Block) NextOffset() int64 {
+	return block.Offset + int64(block.Size)
+}
+
+// ReadBlock reads and decompresses a single BGZF block
+//
+// Arguments
+//	r (io.Reader): reader positioned at the start of a BGZF block
+//	offset (int64): byte position of the block in the compressed file
+// Returns
+//	(*Block): the decompressed block
+//	(error): io.EOF if there are no more blocks, otherwise if not nil, the
+//		data is not valid BGZF
+func ReadBlock(r io.Reader, offset int64) (*Block, error) {
+	header := make([]byte, gzipHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated BGZF block header")
+		}
+		return nil, err
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[2] != 0x08 || header[3]&0x04 == 0 {
+		return nil, errors.New("invalid BGZF block header")
+	}
+
+	// scan the extra subfields for 'BC', holding the total block size - 1
+	xlen := int(binary.LittleEndian.Uint16(header[10:12]))
+	extra := make([]byte, xlen)
+	if _, err := io.ReadFull(r, extra); err != nil {
+		return nil, errors.New("truncated BGZF block header")
+	}
+	blockSize := -1
+	for i := 0; i+4 <= xlen; {
+		slen := int(binary.LittleEndian.Uint16(extra[i+2 : i+4]))
+		if extra[i] == 'B' && extra[i+1] == 'C' && slen == 2 && i+6 <= xlen {
+			blockSize = int(binary.LittleEndian.Uint16(extra[i+4:i+6])) + 1
+		}
+		i += 4 + slen
+	}
+	if blockSize < gzipHeaderLen+xlen+gzipFooterLen {
+		return nil, errors.New("BGZF block size subfield missing or invalid")
+	}
+
+	// compressed data, followed by the crc32 and uncompressed size
+	rest := make([]byte, blockSize-gzipHeaderLen-xlen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, errors.New("truncated BGZF block")
+	}
+	cdata := rest[:len(rest)-gzipFooterLen]
+	checksum := binary.LittleEndian.Uint32(rest[len(rest)-gzipFooterLen:])
+	isize := binary.LittleEndian.Uint32(rest[len(rest)-4:])
+
+	data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(cdata)))
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(data)) != isize || crc32.ChecksumIEEE(data) != checksum {
+		return nil, errors.New("BGZF block failed integrity check")
+	}
+
+	block := new(Block)
+	block.Offset = offset
+	block.Size = blockSize
+	block.Data = data
+	return block, nil
+}
+
+// compressBlock compresses data into a single BGZF block
+func compressBlock(data []byte) ([]byte, error) {
+	var cdata bytes.Buffer
+	writer, err := flate.NewWriter(&cdata, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	writer.Write(data)
+	writer.Close()
+
+	blockSize := len(blockHeaderPrefix) + 2 + cdata.Len() + gzipFooterLen
+	if blockSize > MaxBlockSize {
+		return nil, errors.New("data does not fit in a single BGZF block")
+	}
+
+	block := make([]byte, 0, blockSize)
+	block = append(block, blockHeaderPrefix...)
+	block = appendUint16(block, uint16(blockSize-1))
+	block = append(block, cdata.Bytes()...)
+	block = appendUint32(block, crc32.ChecksumIEEE(data))
+	block = appendUint32(block, uint32(len(data)))
+	return block, nil
+}
+
+// Compress compresses data as a sequence of BGZF blocks. no end of file
+// marker is appended
+//
+// Arguments
+//	data ([]byte): uncompressed data
+// Returns
+//	([]byte): BGZF compressed data
+//	(error): if not nil, the data could not be compressed
+func Compress(data []byte) ([]byte, error) {
+	var compressed []byte
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxBlockDataSize {
+			n = maxBlockDataSize
+		}
+		block, err := compressBlock(data[:n])
+		if err != nil {
+			return nil, err
+		}
+		compressed = append(compressed, block...)
+		data = data[n:]
+	}
+	return compressed, nil
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
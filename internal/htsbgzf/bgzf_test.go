@@ -0,0 +1,87 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module bgzf_test tests module bgzf
+package htsbgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// bgzfCompressTC test cases for Compress
+var bgzfCompressTC = []struct {
+	data      []byte
+	expBlocks int
+}{
+	{[]byte("@HD\tVN:1.6\tSO:coordinate\n"), 1},
+	{bytes.Repeat([]byte("ACGT"), maxBlockDataSize/4), 1},
+	{bytes.Repeat([]byte("ACGT"), maxBlockDataSize/2), 2},
+	{[]byte{}, 0},
+}
+
+// TestBgzfCompress tests that Compress output can be read back, both block
+// by block and as a standard (multi-member) gzip stream
+func TestBgzfCompress(t *testing.T) {
+	for _, tc := range bgzfCompressTC {
+		compressed, err := Compress(tc.data)
+		assert.Nil(t, err)
+
+		nBlocks := 0
+		decompressed := []byte{}
+		r := bytes.NewReader(compressed)
+		offset := int64(0)
+		for r.Len() > 0 {
+			block, err := ReadBlock(r, offset)
+			assert.Nil(t, err)
+			assert.Equal(t, offset, block.Offset)
+			decompressed = append(decompressed, block.Data...)
+			offset = block.NextOffset()
+			nBlocks++
+		}
+		assert.Equal(t, tc.expBlocks, nBlocks)
+		assert.Equal(t, len(tc.data), len(decompressed))
+		assert.True(t, bytes.Equal(tc.data, decompressed))
+
+		if len(compressed) > 0 {
+			gz, err := gzip.NewReader(bytes.NewReader(compressed))
+			assert.Nil(t, err)
+			gzData, err := ioutil.ReadAll(gz)
+			assert.Nil(t, err)
+			assert.True(t, bytes.Equal(tc.data, gzData))
+		}
+	}
+}
+
+// TestBgzfReadBlockEOF tests ReadBlock on the standard BGZF end of file block
+func TestBgzfReadBlockEOF(t *testing.T) {
+	block, err := ReadBlock(bytes.NewReader(htsconstants.BamEOF), 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), block.Offset)
+	assert.Equal(t, htsconstants.BamEOFLen, block.Size)
+	assert.Equal(t, int64(128), block.NextOffset())
+	assert.Equal(t, 0, len(block.Data))
+}
+
+// bgzfReadBlockErrorTC test cases for invalid ReadBlock input
+var bgzfReadBlockErrorTC = []struct {
+	data []byte
+}{
+	{[]byte{}},
+	{[]byte("not a bgzf block at all")},
+	{htsconstants.BamEOF[:20]},
+	{append([]byte{0x1f, 0x8b, 0x08, 0x00}, htsconstants.BamEOF[4:]...)},
+}
+
+// TestBgzfReadBlockError tests ReadBlock on invalid data
+func TestBgzfReadBlockError(t *testing.T) {
+	for _, tc := range bgzfReadBlockErrorTC {
+		_, err := ReadBlock(bytes.NewReader(tc.data), 0)
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module reader streams the decompressed contents of consecutive BGZF
+// blocks, keeping track of the virtual offset of the read position
+package htsbgzf
+
+import (
+	"bufio"
+	"io"
+)
+
+// Reader reads decompressed data from a stream of BGZF blocks
+type Reader struct {
+	source io.Reader
+	block  *Block
+	pos    int
+	offset int64
+}
+
+// NewReader instantiates a Reader
+//
+// Arguments
+//	r (io.Reader): BGZF compressed data, positioned at the start of a block
+//	offset (int64): byte position of r in the compressed file
+// Returns
+//	(*Reader): reader of the decompressed data
+func NewReader(r io.Reader, offset int64) *Reader {
+	reader := new(Reader)
+	reader.source = bufio.NewReader(r)
+	reader.offset = offset
+	return reader
+}
+
+// nextBlock loads the next non-empty block from the source
+func (reader *Reader) nextBlock() error {
+	for reader.block == nil || reader.pos == len(reader.block.Data) {
+		block, err := ReadBlock(reader.source, reader.offset)
+		if err != nil {
+			return err
+		}
+		reader.block = block
+		reader.pos = 0
+		reader.offset = block.NextOffset()
+	}
+	return nil
+}
+
+// Read reads decompressed data, implementing io.Reader
+//
+//	Type: Reader
+// Arguments
+//	p ([]byte): buffer to read into
+// Returns
+//	(int): number of bytes read
+//	(error): io.EOF at the end of the compressed stream
+func (reader *Reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := reader.nextBlock(); err != nil {
+		return 0, err
+	}
+	n := copy(p, reader.block.Data[reader.pos:])
+	reader.pos += n
+	return n, nil
+}
+
+// VirtualOffset gets the virtual offset of the next byte to be read. when a
+// block has been read to its end, the start of the following block is given
+//
+//	Type: Reader
+// Returns
+//	(VirtualOffset): virtual offset of the read position
+func (reader *Reader) VirtualOffset() VirtualOffset {
+	if reader.block == nil || reader.pos == len(reader.block.Data) {
+		return NewVirtualOffset(reader.offset, 0)
+	}
+	return NewVirtualOffset(reader.block.Offset, reader.pos)
+}
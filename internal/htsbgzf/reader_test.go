@@ -0,0 +1,55 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module reader_test tests module reader
+package htsbgzf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReaderRead tests that Reader decompresses consecutive blocks
+func TestReaderRead(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	compressed, _ := Compress(data)
+	compressed = append(compressed, htsconstants.BamEOF...)
+
+	reader := NewReader(bytes.NewReader(compressed), 0)
+	decompressed, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(data, decompressed))
+	assert.Equal(t, NewVirtualOffset(int64(len(compressed)), 0), reader.VirtualOffset())
+}
+
+// TestReaderVirtualOffset tests VirtualOffset function while reading
+func TestReaderVirtualOffset(t *testing.T) {
+	first, _ := Compress([]byte("first block"))
+	second, _ := Compress([]byte("second block"))
+	compressed := append(append([]byte{}, first...), second...)
+
+	// the reader may start part way through a file
+	startOffset := int64(1000)
+	reader := NewReader(bytes.NewReader(compressed), startOffset)
+	assert.Equal(t, NewVirtualOffset(startOffset, 0), reader.VirtualOffset())
+
+	buf := make([]byte, 5)
+	io.ReadFull(reader, buf)
+	assert.Equal(t, "first", string(buf))
+	assert.Equal(t, NewVirtualOffset(startOffset, 5), reader.VirtualOffset())
+
+	// reading the remainder of a block moves to the start of the next block
+	buf = make([]byte, 6)
+	io.ReadFull(reader, buf)
+	assert.Equal(t, " block", string(buf))
+	assert.Equal(t, NewVirtualOffset(startOffset+int64(len(first)), 0), reader.VirtualOffset())
+
+	io.ReadFull(reader, buf)
+	assert.Equal(t, "second", string(buf))
+	assert.Equal(t, NewVirtualOffset(startOffset+int64(len(first)), 6), reader.VirtualOffset())
+}
@@ -0,0 +1,54 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module virtualoffset contains BGZF virtual file offsets, as used by
+// BAI, CSI and tabix indices
+package htsbgzf
+
+import (
+	"strconv"
+)
+
+// VirtualOffset a position in BGZF compressed data. the upper 48 bits hold
+// the byte position of a block in the compressed file, the lower 16 bits
+// hold the byte position within the decompressed block
+type VirtualOffset uint64
+
+// NewVirtualOffset creates a virtual offset from its compressed and
+// uncompressed components
+//
+// Arguments
+//	compressed (int64): byte position of the block in the compressed file
+//	uncompressed (int): byte position within the decompressed block
+// Returns
+//	(VirtualOffset): virtual offset
+func NewVirtualOffset(compressed int64, uncompressed int) VirtualOffset {
+	return VirtualOffset(uint64(compressed)<<16 | uint64(uncompressed&0xffff))
+}
+
+// Compressed gets the byte position of the block in the compressed file
+//
+//	Type: VirtualOffset
+// Returns
+//	(int64): compressed offset
+func (v VirtualOffset) Compressed() int64 {
+	return int64(v >> 16)
+}
+
+// Uncompressed gets the byte position within the decompressed block
+//
+//	Type: VirtualOffset
+// Returns
+//	(int): uncompressed offset
+func (v VirtualOffset) Uncompressed() int {
+	return int(v & 0xffff)
+}
+
+// String gets the virtual offset representation as a string
+//
+//	Type: VirtualOffset
+// Returns
+//	(string): virtual offset as "compressed:uncompressed"
+func (v VirtualOffset) String() string {
+	return strconv.FormatInt(v.Compressed(), 10) + ":" + strconv.Itoa(v.Uncompressed())
+}
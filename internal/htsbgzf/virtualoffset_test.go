@@ -0,0 +1,36 @@
+// Package htsbgzf reads and writes BGZF (blocked gzip format) data, the
+// compression format underlying BAM, BCF and bgzipped VCF files
+//
+// Module virtualoffset_test tests module virtualoffset
+package htsbgzf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// virtualOffsetTC test cases for VirtualOffset
+var virtualOffsetTC = []struct {
+	compressed   int64
+	uncompressed int
+	exp          VirtualOffset
+	expString    string
+}{
+	{0, 0, 0, "0:0"},
+	{0, 2580, 2580, "0:2580"},
+	{2580, 0, 169082880, "2580:0"},
+	{41129, 65535, 2695495679, "41129:65535"},
+}
+
+// TestVirtualOffset tests NewVirtualOffset, Compressed, Uncompressed and
+// String functions
+func TestVirtualOffset(t *testing.T) {
+	for _, tc := range virtualOffsetTC {
+		v := NewVirtualOffset(tc.compressed, tc.uncompressed)
+		assert.Equal(t, tc.exp, v)
+		assert.Equal(t, tc.compressed, v.Compressed())
+		assert.Equal(t, tc.uncompressed, v.Uncompressed())
+		assert.Equal(t, tc.expString, v.String())
+	}
+}
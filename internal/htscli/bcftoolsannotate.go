@@ -0,0 +1,88 @@
+// Package htscli deals with the construction and submission of command-line
+// jobs
+//
+// Module bcftoolsannotate defines the job submission for the 'bcftools
+// annotate' command, which removes INFO/FORMAT keys from a piped BCF stream
+package htscli
+
+import (
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
+)
+
+// BcftoolsAnnotateCommand represents a single 'bcftools annotate' command,
+// reading uncompressed BCF from stdin, and the INFO/FORMAT keys to keep or
+// remove
+type BcftoolsAnnotateCommand struct {
+	tags   []string
+	notags []string
+}
+
+// BcftoolsAnnotate instantiates a new BcftoolsAnnotate command
+func BcftoolsAnnotate() *BcftoolsAnnotateCommand {
+	bcftoolsAnnotateCommand := new(BcftoolsAnnotateCommand)
+	bcftoolsAnnotateCommand.tags = nil
+	bcftoolsAnnotateCommand.notags = []string{}
+	return bcftoolsAnnotateCommand
+}
+
+// SetTags sets the INFO/FORMAT keys to keep, all others (except the GT
+// FORMAT key) being removed. nil keeps all keys
+func (bcftoolsAnnotateCommand *BcftoolsAnnotateCommand) SetTags(tags []string) {
+	bcftoolsAnnotateCommand.tags = tags
+}
+
+// SetNoTags sets the INFO/FORMAT keys to remove, only used if the keys to keep
+// are not set
+func (bcftoolsAnnotateCommand *BcftoolsAnnotateCommand) SetNoTags(notags []string) {
+	bcftoolsAnnotateCommand.notags = notags
+}
+
+// annotationKeys gets the INFO and FORMAT annotations named by a list of keys,
+// each key applying to both
+func annotationKeys(keys []string) []string {
+	annotations := []string{}
+	for _, key := range keys {
+		if key != "" {
+			annotations = append(annotations, "INFO/"+key, "FORMAT/"+key)
+		}
+	}
+	return annotations
+}
+
+// removeList gets the value of the '-x' option, an empty string if no
+// annotations are removed
+func (bcftoolsAnnotateCommand *BcftoolsAnnotateCommand) removeList() string {
+	if bcftoolsAnnotateCommand.tags != nil {
+		keep := annotationKeys(bcftoolsAnnotateCommand.tags)
+		// removes all INFO keys, and all FORMAT keys except GT
+		if len(keep) == 0 {
+			return "INFO,FORMAT"
+		}
+		// as the genotypes of the samples, GT is always kept
+		if !htsutils.IsItemInArray("FORMAT/GT", keep) {
+			keep = append(keep, "FORMAT/GT")
+		}
+		return "^" + strings.Join(keep, ",")
+	}
+	return strings.Join(annotationKeys(bcftoolsAnnotateCommand.notags), ",")
+}
+
+// GetCommand exports the BcftoolsAnnotateCommand as a generic Command,
+// streaming uncompressed BCF to be piped into the next command
+func (bcftoolsAnnotateCommand *BcftoolsAnnotateCommand) GetCommand() *Command {
+	command := NewCommand()
+	command.SetBaseCommand("bcftools")
+	command.AddArg("annotate")
+	command.AddArg("-")
+	command.AddArg("--no-version")
+	command.AddArg("-O")
+	command.AddArg("u")
+
+	if removeList := bcftoolsAnnotateCommand.removeList(); removeList != "" {
+		command.AddArg("-x")
+		command.AddArg(removeList)
+	}
+	return command
+}
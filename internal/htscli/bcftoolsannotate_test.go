@@ -0,0 +1,78 @@
+// Package htscli deals with the construction and submission of command-line
+// jobs
+//
+// Module bcftoolsannotate_test tests module bcftoolsannotate
+package htscli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bcftoolsAnnotateGetCommandTC test cases for GetCommand
+var bcftoolsAnnotateGetCommandTC = []struct {
+	tags, notags, expArgs []string
+}{
+	{
+		[]string{"DP", "AD"},
+		nil,
+		[]string{"annotate", "-", "--no-version", "-O", "u", "-x", "^INFO/DP,FORMAT/DP,INFO/AD,FORMAT/AD,FORMAT/GT"},
+	},
+	{
+		[]string{"GT", "DP"},
+		nil,
+		[]string{"annotate", "-", "--no-version", "-O", "u", "-x", "^INFO/GT,FORMAT/GT,INFO/DP,FORMAT/DP"},
+	},
+	{
+		[]string{""},
+		nil,
+		[]string{"annotate", "-", "--no-version", "-O", "u", "-x", "INFO,FORMAT"},
+	},
+	{
+		nil,
+		[]string{"AC", "AN"},
+		[]string{"annotate", "-", "--no-version", "-O", "u", "-x", "INFO/AC,FORMAT/AC,INFO/AN,FORMAT/AN"},
+	},
+	{
+		nil,
+		[]string{""},
+		[]string{"annotate", "-", "--no-version", "-O", "u"},
+	},
+	{
+		[]string{"DP"},
+		[]string{"AC"},
+		[]string{"annotate", "-", "--no-version", "-O", "u", "-x", "^INFO/DP,FORMAT/DP,FORMAT/GT"},
+	},
+}
+
+// TestBcftoolsAnnotateSetTags tests SetTags function
+func TestBcftoolsAnnotateSetTags(t *testing.T) {
+	bcftoolsAnnotate := BcftoolsAnnotate()
+	assert.Nil(t, bcftoolsAnnotate.tags)
+	bcftoolsAnnotate.SetTags([]string{"DP", "AD"})
+	assert.Equal(t, []string{"DP", "AD"}, bcftoolsAnnotate.tags)
+}
+
+// TestBcftoolsAnnotateSetNoTags tests SetNoTags function
+func TestBcftoolsAnnotateSetNoTags(t *testing.T) {
+	bcftoolsAnnotate := BcftoolsAnnotate()
+	bcftoolsAnnotate.SetNoTags([]string{"AC"})
+	assert.Equal(t, []string{"AC"}, bcftoolsAnnotate.notags)
+}
+
+// TestBcftoolsAnnotateGetCommand tests GetCommand function
+func TestBcftoolsAnnotateGetCommand(t *testing.T) {
+	for _, tc := range bcftoolsAnnotateGetCommandTC {
+		bcftoolsAnnotate := BcftoolsAnnotate()
+		if tc.tags != nil {
+			bcftoolsAnnotate.SetTags(tc.tags)
+		}
+		if tc.notags != nil {
+			bcftoolsAnnotate.SetNoTags(tc.notags)
+		}
+		command := bcftoolsAnnotate.GetCommand()
+		assert.Equal(t, "bcftools", command.GetBaseCommand())
+		assert.Equal(t, tc.expArgs, command.GetArgs())
+	}
+}
@@ -2,10 +2,14 @@
 // jobs
 //
 // Module bcftoolsview defines the job submission for the 'bcftools view'
-// command, which streams VCF or BCF to stdout
+// command, which streams BGZF-compressed VCF or BCF to stdout
 package htscli
 
 import (
+	"io"
+	"strconv"
+	"strings"
+
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 )
 
@@ -14,12 +18,20 @@ import (
 type BcftoolsViewCommand struct {
 	filePath   string
 	headerOnly bool
+	outputBCF  bool
+	outputVCF  bool
+	piped      bool
 	region     *htsrequest.Region
+	minStart   int
+	samples    []string
+	stdin      io.Reader
 }
 
 // BcftoolsView instantiates a new BcftoolsView Command
 func BcftoolsView() *BcftoolsViewCommand {
-	return new(BcftoolsViewCommand)
+	bcftoolsViewCommand := new(BcftoolsViewCommand)
+	bcftoolsViewCommand.minStart = -1
+	return bcftoolsViewCommand
 }
 
 // SetFilePath sets the path to the requested variant file
@@ -33,37 +45,105 @@ func (bcftoolsViewCommand *BcftoolsViewCommand) SetHeaderOnly(headerOnly bool) {
 	bcftoolsViewCommand.headerOnly = headerOnly
 }
 
+// SetOutputBCF sets boolean parameter that, if true, will stream BGZF-compressed
+// BCF instead of BGZF-compressed VCF. as BCF cannot be written without a
+// header, the header is not excluded from BCF body streams
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetOutputBCF(outputBCF bool) {
+	bcftoolsViewCommand.outputBCF = outputBCF
+}
+
+// SetOutputVCF sets boolean parameter that, if true, will stream uncompressed
+// VCF, e.g. to read the header as text
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetOutputVCF(outputVCF bool) {
+	bcftoolsViewCommand.outputVCF = outputVCF
+}
+
+// SetPiped sets boolean parameter that, if true, will stream uncompressed BCF
+// including the header, to be piped into another bcftools command. the
+// output format and header exclusion are then left to the last command
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetPiped(piped bool) {
+	bcftoolsViewCommand.piped = piped
+}
+
 // SetRegion sets the requested genomic region for variant streaming
 func (bcftoolsViewCommand *BcftoolsViewCommand) SetRegion(region *htsrequest.Region) {
 	bcftoolsViewCommand.region = region
 }
 
+// SetMinStart sets the 0-based position variants must start at or after to be
+// streamed, -1 streaming all variants overlapping the region
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetMinStart(minStart int) {
+	bcftoolsViewCommand.minStart = minStart
+}
+
+// SetSamples sets the samples to subset the variant file to, all samples
+// being streamed if empty
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetSamples(samples []string) {
+	bcftoolsViewCommand.samples = samples
+}
+
+// SetStdin sets a reader the variant file is streamed from, instead of the
+// file path. as a stream can't be indexed, the region is then filtered as
+// targets, by reading through the stream
+func (bcftoolsViewCommand *BcftoolsViewCommand) SetStdin(stdin io.Reader) {
+	bcftoolsViewCommand.stdin = stdin
+}
+
 // GetCommand exports the BcftoolsViewCommand as a generic Command
 func (bcftoolsViewCommand *BcftoolsViewCommand) GetCommand() *Command {
 	// consistent base command and initial args
 	command := NewCommand()
 	command.SetBaseCommand("bcftools")
 	command.AddArg("view")
-	command.AddArg(bcftoolsViewCommand.filePath)
+	if bcftoolsViewCommand.stdin != nil {
+		command.AddArg("-")
+		command.SetStdin(bcftoolsViewCommand.stdin)
+	} else {
+		command.AddArg(bcftoolsViewCommand.filePath)
+	}
 	command.AddArg("--no-version")
 
 	// add header flag
 	if bcftoolsViewCommand.headerOnly {
 		command.AddArg("-h")
-	} else {
+	} else if !bcftoolsViewCommand.outputBCF && !bcftoolsViewCommand.piped {
 		command.AddArg("-H")
 	}
 
-	// always output as uncompressed VCF
-	// TODO make 'format' parameter effective
+	// output as uncompressed BCF to the next command, compressed BCF,
+	// uncompressed VCF or compressed VCF
 	command.AddArg("-O")
-	command.AddArg("v")
+	if bcftoolsViewCommand.piped {
+		command.AddArg("u")
+	} else if bcftoolsViewCommand.outputVCF {
+		command.AddArg("v")
+	} else if bcftoolsViewCommand.outputBCF {
+		command.AddArg("b")
+	} else {
+		command.AddArg("z")
+	}
+
+	// add sample subset flag
+	if len(bcftoolsViewCommand.samples) > 0 {
+		command.AddArg("-s")
+		command.AddArg(strings.Join(bcftoolsViewCommand.samples, ","))
+	}
 
 	// add region interval flag
 	if bcftoolsViewCommand.region != nil {
-		command.AddArg("-r")
+		if bcftoolsViewCommand.stdin != nil {
+			command.AddArg("-t")
+		} else {
+			command.AddArg("-r")
+		}
 		command.AddArg(bcftoolsViewCommand.region.ExportBcftools())
 	}
 
+	// add minimum start filter, bcftools positions being 1-based
+	if bcftoolsViewCommand.minStart != -1 {
+		command.AddArg("-i")
+		command.AddArg("POS>=" + strconv.Itoa(bcftoolsViewCommand.minStart+1))
+	}
+
 	return command
 }
@@ -5,6 +5,8 @@
 package htscli
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
@@ -29,6 +31,14 @@ var bcftoolsViewSetHeaderOnlyTC = []struct {
 	{false},
 }
 
+// bcftoolsViewSetOutputBCFTC test cases for SetOutputBCF
+var bcftoolsViewSetOutputBCFTC = []struct {
+	outputBCF bool
+}{
+	{true},
+	{false},
+}
+
 // bcftoolsViewSetRegionTC test cases for SetRegion
 var bcftoolsViewSetRegionTC = []struct {
 	region *htsrequest.Region
@@ -53,25 +63,47 @@ var bcftoolsViewSetRegionTC = []struct {
 var bcftoolsViewGetCommandTC = []struct {
 	filepath   string
 	headerOnly bool
+	outputBCF  bool
 	region     *htsrequest.Region
 	expArgs    []string
 }{
 	{
 		"/path/to/the/file",
 		true,
+		false,
 		nil,
-		[]string{"view", "/path/to/the/file", "--no-version", "-h", "-O", "v"},
+		[]string{"view", "/path/to/the/file", "--no-version", "-h", "-O", "z"},
 	},
 	{
 		"https://genomics.com/datasets/object0001",
 		false,
+		false,
 		&htsrequest.Region{
 			ReferenceName: "chr1",
 			Start:         intPtr(2000000),
 			End:           intPtr(3000000),
 		},
 		[]string{"view", "https://genomics.com/datasets/object0001", "--no-version",
-			"-H", "-O", "v", "-r", "chr1:2000000-3000000"},
+			"-H", "-O", "z", "-r", "chr1:2000001-3000000"},
+	},
+	{
+		"/path/to/the/file",
+		true,
+		true,
+		nil,
+		[]string{"view", "/path/to/the/file", "--no-version", "-h", "-O", "b"},
+	},
+	{
+		"https://genomics.com/datasets/object0001",
+		false,
+		true,
+		&htsrequest.Region{
+			ReferenceName: "chr22",
+			Start:         intPtr(600000),
+			End:           intPtr(999999),
+		},
+		[]string{"view", "https://genomics.com/datasets/object0001", "--no-version",
+			"-O", "b", "-r", "chr22:600001-999999"},
 	},
 }
 
@@ -93,6 +125,15 @@ func TestBcftoolsViewSetHeaderOnly(t *testing.T) {
 	}
 }
 
+// TestBcftoolsViewSetOutputBCF tests SetOutputBCF function
+func TestBcftoolsViewSetOutputBCF(t *testing.T) {
+	for _, tc := range bcftoolsViewSetOutputBCFTC {
+		bcftoolsView := BcftoolsView()
+		bcftoolsView.SetOutputBCF(tc.outputBCF)
+		assert.Equal(t, bcftoolsView.outputBCF, tc.outputBCF)
+	}
+}
+
 // TestBcftoolsViewSetRegion tests SetRegion function
 func TestBcftoolsViewSetRegion(t *testing.T) {
 	for _, tc := range bcftoolsViewSetRegionTC {
@@ -102,15 +143,109 @@ func TestBcftoolsViewSetRegion(t *testing.T) {
 	}
 }
 
+// TestBcftoolsViewSetStdin tests SetStdin function, streaming the file from
+// stdin and filtering the region as targets
+func TestBcftoolsViewSetStdin(t *testing.T) {
+	stdin := strings.NewReader("##fileformat=VCFv4.2\n")
+	bcftoolsView := BcftoolsView()
+	bcftoolsView.SetFilePath("s3://bucket/object0001.vcf.gz")
+	bcftoolsView.SetStdin(stdin)
+	bcftoolsView.SetRegion(&htsrequest.Region{
+		ReferenceName: "chr1",
+		Start:         intPtr(2000000),
+		End:           intPtr(3000000),
+	})
+	command := bcftoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "-", "--no-version", "-H", "-O", "z", "-t", "chr1:2000001-3000000"}, command.GetArgs())
+	assert.Equal(t, stdin, command.GetStdin())
+}
+
+// TestBcftoolsViewSetMinStart tests SetMinStart function, excluding variants
+// starting before the 0-based position
+func TestBcftoolsViewSetMinStart(t *testing.T) {
+	bcftoolsView := BcftoolsView()
+	bcftoolsView.SetFilePath("/path/to/the/file.vcf.gz")
+	bcftoolsView.SetRegion(&htsrequest.Region{
+		ReferenceName: "chr1",
+		Start:         intPtr(2000000),
+		End:           intPtr(3000000),
+	})
+	bcftoolsView.SetMinStart(1500000)
+	command := bcftoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "/path/to/the/file.vcf.gz", "--no-version", "-H", "-O", "z", "-r", "chr1:2000001-3000000", "-i", "POS>=1500001"}, command.GetArgs())
+}
+
+// TestBcftoolsViewSetSamples tests SetSamples function
+func TestBcftoolsViewSetSamples(t *testing.T) {
+	bcftoolsView := BcftoolsView()
+	bcftoolsView.SetFilePath("/path/to/the/file.vcf.gz")
+	bcftoolsView.SetSamples([]string{"HG002", "HG003"})
+	command := bcftoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "/path/to/the/file.vcf.gz", "--no-version", "-H", "-O", "z", "-s", "HG002,HG003"}, command.GetArgs())
+}
+
+// TestBcftoolsViewSetPiped tests SetPiped function, streaming uncompressed
+// BCF with the header to the next command
+func TestBcftoolsViewSetPiped(t *testing.T) {
+	for _, outputBCF := range []bool{true, false} {
+		bcftoolsView := BcftoolsView()
+		bcftoolsView.SetFilePath("/path/to/the/file.vcf.gz")
+		bcftoolsView.SetOutputBCF(outputBCF)
+		bcftoolsView.SetPiped(true)
+		command := bcftoolsView.GetCommand()
+		assert.Equal(t, []string{"view", "/path/to/the/file.vcf.gz", "--no-version", "-O", "u"}, command.GetArgs())
+	}
+}
+
+// TestBcftoolsViewSetOutputVCF tests SetOutputVCF function, streaming the
+// header as uncompressed VCF
+func TestBcftoolsViewSetOutputVCF(t *testing.T) {
+	bcftoolsView := BcftoolsView()
+	bcftoolsView.SetFilePath("/path/to/the/file.vcf.gz")
+	bcftoolsView.SetHeaderOnly(true)
+	bcftoolsView.SetOutputVCF(true)
+	command := bcftoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "/path/to/the/file.vcf.gz", "--no-version", "-h", "-O", "v"}, command.GetArgs())
+}
+
 // TestBcftoolsViewGetCommand tests GetCommand function
 func TestBcftoolsViewGetCommand(t *testing.T) {
 	for _, tc := range bcftoolsViewGetCommandTC {
 		bcftoolsView := BcftoolsView()
 		bcftoolsView.SetFilePath(tc.filepath)
 		bcftoolsView.SetHeaderOnly(tc.headerOnly)
+		bcftoolsView.SetOutputBCF(tc.outputBCF)
 		bcftoolsView.SetRegion(tc.region)
 		command := bcftoolsView.GetCommand()
 		assert.Equal(t, "bcftools", command.baseCommand)
 		assert.Equal(t, tc.expArgs, command.GetArgs())
 	}
 }
+
+// bcftoolsViewRegionBoundariesTC test cases for regions bordering SNPs of the
+// GIAB fixture, at 1-based positions 1781345 and 1875858 of chromosome 1
+var bcftoolsViewRegionBoundariesTC = []struct {
+	region       *htsrequest.Region
+	minStart     int
+	expPositions []string
+}{
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781344), End: intPtr(1781345)}, -1, []string{"1781345"}},
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781343), End: intPtr(1781344)}, -1, []string{}},
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781345), End: intPtr(1875858)}, -1, []string{"1875858"}},
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781345), End: intPtr(1875857)}, -1, []string{}},
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781344), End: intPtr(1875858)}, 1781345, []string{"1875858"}},
+	{&htsrequest.Region{ReferenceName: "1", Start: intPtr(1781344), End: intPtr(1875858)}, 1781344, []string{"1781345", "1875858"}},
+}
+
+// TestBcftoolsViewRegionBoundaries tests htsget regions select the variants
+// overlapping them, the 0-based coordinates being converted for bcftools
+func TestBcftoolsViewRegionBoundaries(t *testing.T) {
+	vcf := filepath.Join(fixturesDir, "giab", "HG002_GIAB.filtered.vcf.gz")
+	for _, tc := range bcftoolsViewRegionBoundariesTC {
+		bcftoolsView := BcftoolsView()
+		bcftoolsView.SetFilePath(vcf)
+		bcftoolsView.SetRegion(tc.region)
+		bcftoolsView.SetMinStart(tc.minStart)
+		assert.Equal(t, tc.expPositions, commandOutputColumn(t, bcftoolsView.GetCommand(), 1), tc.region.String())
+	}
+}
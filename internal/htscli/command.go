@@ -5,14 +5,21 @@
 package htscli
 
 import (
+	"io"
+	"os"
 	"os/exec"
+	"time"
 )
 
 // Command job/command to be submitted on the command-line
 type Command struct {
 	baseCommand string
 	args        []string
+	stdin       io.Reader
+	env         []string
 	cmd         *exec.Cmd
+	started     time.Time
+	duration    time.Duration
 }
 
 // NewCommand instantiates a new Command
@@ -28,6 +35,11 @@ func (command *Command) SetBaseCommand(baseCommand string) {
 	command.baseCommand = baseCommand
 }
 
+// GetBaseCommand gets the command's base command
+func (command *Command) GetBaseCommand() string {
+	return command.baseCommand
+}
+
 // SetArgs sets the command's arguments, ie. the space-delimited strings
 // appearing after the base command to modify program behaviour
 func (command *Command) SetArgs(args []string) {
@@ -49,14 +61,80 @@ func (command *Command) GetLastArg() string {
 	return command.args[len(command.args)-1]
 }
 
+// SetStdin sets a reader the command's stdin is fed from, used when the
+// command is first in a chain and streams its input from stdin
+func (command *Command) SetStdin(stdin io.Reader) {
+	command.stdin = stdin
+}
+
+// GetStdin gets the reader the command's stdin is fed from, nil if unset
+func (command *Command) GetStdin() io.Reader {
+	return command.stdin
+}
+
+// SetEnv sets environment variables the command runs with, in addition to
+// the server's environment
+func (command *Command) SetEnv(env []string) {
+	command.env = env
+}
+
+// GetEnv gets the environment variables the command runs with, nil if only
+// the server's environment is used
+func (command *Command) GetEnv() []string {
+	if command.env == nil {
+		return nil
+	}
+	return append(os.Environ(), command.env...)
+}
+
 // SetupCmd wraps the command's base command and arguments as an exec.Cmd
 // object, setting it to the command's cmd property
 func (command *Command) SetupCmd() {
 	cmd := exec.Command(command.baseCommand, command.args...)
+	if command.stdin != nil {
+		cmd.Stdin = command.stdin
+	}
+	cmd.Env = command.GetEnv()
 	command.cmd = cmd
 }
 
 // ExecuteCmd starts the command that has been set up
 func (command *Command) ExecuteCmd() {
+	command.started = time.Now()
 	command.cmd.Start()
 }
+
+// WaitCmd waits for the started command to exit, after its output has been
+// read, and gets its exit code. -1 if the command could not be started or
+// was killed by a signal
+func (command *Command) WaitCmd() int {
+	command.cmd.Wait()
+	return command.exited()
+}
+
+// RunCmd runs the command that has been set up to completion, writing its
+// output to stdout, and gets its exit code. -1 if the command could not be
+// started or was killed by a signal
+func (command *Command) RunCmd(stdout io.Writer) int {
+	command.cmd.Stdout = stdout
+	command.started = time.Now()
+	command.cmd.Run()
+	return command.exited()
+}
+
+// GetDuration gets the time taken by the command from starting to exiting,
+// zero if it has not exited
+func (command *Command) GetDuration() time.Duration {
+	return command.duration
+}
+
+// exited gets the exit code of the finished command, -1 if it never started,
+// and records its duration
+func (command *Command) exited() int {
+	command.duration = time.Since(command.started)
+	exitCode := -1
+	if command.cmd.ProcessState != nil {
+		exitCode = command.cmd.ProcessState.ExitCode()
+	}
+	return exitCode
+}
@@ -5,7 +5,11 @@
 package htscli
 
 import (
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -43,12 +47,26 @@ var commandSetupCmdTC = []struct {
 	{"echo", []string{"Hello", "World"}},
 }
 
+// commandExitCodeTC test cases for WaitCmd and RunCmd
+var commandExitCodeTC = []struct {
+	baseCommand string
+	args        []string
+	expStdout   string
+	expExitCode int
+}{
+	{"sh", []string{"-c", "echo Hello"}, "Hello\n", 0},
+	{"sh", []string{"-c", "echo World; exit 3"}, "World\n", 3},
+	{"sh", []string{"-c", "kill -9 $$"}, "", -1},
+	{"htsget-refserver-missing-command", []string{}, "", -1},
+}
+
 // TestCommandSetBaseCommand tests SetBaseCommand function
 func TestCommandSetBaseCommand(t *testing.T) {
 	for _, tc := range commandSetBaseCommandTC {
 		command := NewCommand()
 		command.SetBaseCommand(tc.baseCommand)
 		assert.Equal(t, tc.baseCommand, command.baseCommand)
+		assert.Equal(t, tc.baseCommand, command.GetBaseCommand())
 	}
 }
 
@@ -78,6 +96,32 @@ func TestCommandAddArg(t *testing.T) {
 	}
 }
 
+// TestCommandSetStdin tests SetStdin function
+func TestCommandSetStdin(t *testing.T) {
+	command := NewCommand()
+	assert.Nil(t, command.GetStdin())
+	command.SetBaseCommand("cat")
+	command.SetStdin(strings.NewReader("Hello World"))
+	command.SetupCmd()
+	output, err := command.cmd.Output()
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello World", string(output))
+}
+
+// TestCommandSetEnv tests SetEnv function
+func TestCommandSetEnv(t *testing.T) {
+	command := NewCommand()
+	assert.Nil(t, command.GetEnv())
+	command.SetBaseCommand("sh")
+	command.AddArg("-c")
+	command.AddArg("echo $HTSGET_TEST_ENV")
+	command.SetEnv([]string{"HTSGET_TEST_ENV=Hello World"})
+	command.SetupCmd()
+	output, err := command.cmd.Output()
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello World\n", string(output))
+}
+
 // TestCommandSetupCmd tests SetupCmd function
 func TestCommandSetupCmd(t *testing.T) {
 	for _, tc := range commandSetupCmdTC {
@@ -96,3 +140,43 @@ func TestCommandSetupCmd(t *testing.T) {
 		command.ExecuteCmd()
 	}
 }
+
+// TestCommandWaitCmd tests WaitCmd function
+func TestCommandWaitCmd(t *testing.T) {
+	for _, tc := range commandExitCodeTC {
+		command := NewCommand()
+		command.SetBaseCommand(tc.baseCommand)
+		command.SetArgs(tc.args)
+		command.SetupCmd()
+		pipe, _ := command.cmd.StdoutPipe()
+		command.ExecuteCmd()
+		stdout := new(strings.Builder)
+		io.Copy(stdout, pipe)
+		assert.Equal(t, tc.expStdout, stdout.String())
+		assert.Equal(t, tc.expExitCode, command.WaitCmd())
+	}
+}
+
+// TestCommandRunCmd tests RunCmd function
+func TestCommandRunCmd(t *testing.T) {
+	for _, tc := range commandExitCodeTC {
+		command := NewCommand()
+		command.SetBaseCommand(tc.baseCommand)
+		command.SetArgs(tc.args)
+		command.SetupCmd()
+		stdout := new(strings.Builder)
+		assert.Equal(t, tc.expExitCode, command.RunCmd(stdout))
+		assert.Equal(t, tc.expStdout, stdout.String())
+	}
+}
+
+// TestCommandGetDuration tests GetDuration function
+func TestCommandGetDuration(t *testing.T) {
+	command := NewCommand()
+	command.SetBaseCommand("sh")
+	command.SetArgs([]string{"-c", "sleep 0.1"})
+	assert.Equal(t, time.Duration(0), command.GetDuration())
+	command.SetupCmd()
+	command.RunCmd(ioutil.Discard)
+	assert.True(t, command.GetDuration() >= 100*time.Millisecond)
+}
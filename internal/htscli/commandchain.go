@@ -33,6 +33,13 @@ func (commandChain *CommandChain) AddCommand(command *Command) {
 	commandChain.commands = append(commandChain.commands, command)
 }
 
+// SetEnv sets environment variables all commands in the chain run with
+func (commandChain *CommandChain) SetEnv(env []string) {
+	for _, command := range commandChain.commands {
+		command.SetEnv(env)
+	}
+}
+
 // SetupCommandChain stages all commands in the array chain as an exec.Cmd
 func (commandChain *CommandChain) SetupCommandChain() {
 	for _, command := range commandChain.commands {
@@ -52,20 +59,36 @@ func (commandChain *CommandChain) ExecuteCommandChain() io.ReadCloser {
 		// get the current command, and the command that appears after it
 		// set the next command's stdin to the stdout pipe of the current command
 		// start the current command
-		current := commandChain.commands[i].cmd
-		next := commandChain.commands[i+1].cmd
-		pipe, _ := current.StdoutPipe()
-		next.Stdin = pipe
-		current.Start()
+		current := commandChain.commands[i]
+		next := commandChain.commands[i+1]
+		pipe, _ := current.cmd.StdoutPipe()
+		next.cmd.Stdin = pipe
+		current.ExecuteCmd()
 	}
 
 	// for the last command, return its stdout pipe
-	last := commandChain.GetLastCommand().cmd
-	pipe, _ := last.StdoutPipe()
-	last.Start()
+	last := commandChain.GetLastCommand()
+	pipe, _ := last.cmd.StdoutPipe()
+	last.ExecuteCmd()
 	return pipe
 }
 
+// WaitCommandChain waits for all commands in the chain to exit, once the
+// stdout pipe of the final command has been read to the end, and gets their
+// exit codes in chain order
+func (commandChain *CommandChain) WaitCommandChain() []int {
+	exitCodes := make([]int, len(commandChain.commands))
+	for i, command := range commandChain.commands {
+		exitCodes[i] = command.WaitCmd()
+	}
+	return exitCodes
+}
+
+// GetCommands returns the array chain of commands
+func (commandChain *CommandChain) GetCommands() []*Command {
+	return commandChain.commands
+}
+
 // GetLastCommand returns the final command in the array chain
 func (commandChain *CommandChain) GetLastCommand() *Command {
 	return commandChain.commands[len(commandChain.commands)-1]
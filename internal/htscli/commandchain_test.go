@@ -70,6 +70,42 @@ var commandChainExecuteCommandChainTC = []struct {
 	},
 }
 
+// commandChainWaitCommandChainTC test cases for WaitCommandChain
+var commandChainWaitCommandChainTC = []struct {
+	commands     []*Command
+	expStdout    string
+	expExitCodes []int
+}{
+	{
+		[]*Command{
+			&Command{
+				baseCommand: "sh",
+				args:        []string{"-c", "echo Hello World; exit 2"},
+			},
+			&Command{
+				baseCommand: "wc",
+				args:        []string{"-w"},
+			},
+		},
+		"2\n",
+		[]int{2, 0},
+	},
+	{
+		[]*Command{
+			&Command{
+				baseCommand: "echo",
+				args:        []string{"Hello", "World"},
+			},
+			&Command{
+				baseCommand: "sh",
+				args:        []string{"-c", "cat; exit 1"},
+			},
+		},
+		"Hello World\n",
+		[]int{0, 1},
+	},
+}
+
 // commandChainAddCommandTC test cases for AddCommand
 var commandChainAddCommandTC = commandChainSetCommandsTC
 
@@ -115,6 +151,16 @@ func TestCommandChainAddCommand(t *testing.T) {
 }
 
 // TestCommandChainExecuteCommandChain tests ExecuteCommandChain function
+func TestCommandChainSetEnv(t *testing.T) {
+	commandChain := NewCommandChain()
+	commandChain.AddCommand(NewCommand())
+	commandChain.AddCommand(NewCommand())
+	commandChain.SetEnv([]string{"HTSGET_TEST_ENV=1"})
+	for _, command := range commandChain.commands {
+		assert.Equal(t, []string{"HTSGET_TEST_ENV=1"}, command.env)
+	}
+}
+
 func TestCommandChainExecuteCommandChain(t *testing.T) {
 	for _, tc := range commandChainExecuteCommandChainTC {
 		commandChain := NewCommandChain()
@@ -129,3 +175,18 @@ func TestCommandChainExecuteCommandChain(t *testing.T) {
 	}
 
 }
+
+// TestCommandChainWaitCommandChain tests WaitCommandChain function
+func TestCommandChainWaitCommandChain(t *testing.T) {
+	for _, tc := range commandChainWaitCommandChainTC {
+		commandChain := NewCommandChain()
+		commandChain.SetCommands(tc.commands)
+		commandChain.SetupCommandChain()
+		pipe := commandChain.ExecuteCommandChain()
+		bytes, err := ioutil.ReadAll(pipe)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expStdout, string(bytes))
+		assert.Equal(t, tc.expExitCodes, commandChain.WaitCommandChain())
+		assert.Equal(t, tc.commands, commandChain.GetCommands())
+	}
+}
@@ -6,13 +6,17 @@
 package htscli
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 )
 
 // SamtoolsViewCommand represents a single 'samtools view' command and associated
 // arguments
 type SamtoolsViewCommand struct {
-	command *Command
+	command     *Command
+	expressions []string
 }
 
 // SamtoolsView instantiates a new SamtoolsView Command
@@ -52,12 +56,102 @@ func (samtoolsViewCommand *SamtoolsViewCommand) OutputBAM() *SamtoolsViewCommand
 	return samtoolsViewCommand
 }
 
+// OutputCRAM adds an option to the cli, which will lead to the output being
+// printed in CRAM format instead of SAM
+func (samtoolsViewCommand *SamtoolsViewCommand) OutputCRAM() *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-C")
+	return samtoolsViewCommand
+}
+
+// AddReference adds the reference FASTA to the cli, used when reading from
+// or writing to CRAM
+func (samtoolsViewCommand *SamtoolsViewCommand) AddReference(referencePath string) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-T")
+	samtoolsViewCommand.command.AddArg(referencePath)
+	return samtoolsViewCommand
+}
+
 // AddRegion adds a specific region request to the command line
 func (samtoolsViewCommand *SamtoolsViewCommand) AddRegion(region *htsrequest.Region) *SamtoolsViewCommand {
 	samtoolsViewCommand.command.AddArg(region.ExportSamtools())
 	return samtoolsViewCommand
 }
 
+// AddRegionsFile adds a BED file to the command line, only alignments
+// overlapping its intervals are output. unlike AddRegion, the input is not
+// required to be indexed, so regions can be filtered from stdin
+func (samtoolsViewCommand *SamtoolsViewCommand) AddRegionsFile(regionsFile string) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-L")
+	samtoolsViewCommand.command.AddArg(regionsFile)
+	return samtoolsViewCommand
+}
+
+// addExpression adds a filter expression, only alignments matching all added
+// expressions are output. requires samtools 1.12+
+func (samtoolsViewCommand *SamtoolsViewCommand) addExpression(expression string) *SamtoolsViewCommand {
+	samtoolsViewCommand.expressions = append(samtoolsViewCommand.expressions, expression)
+	return samtoolsViewCommand
+}
+
+// getExpression combines the added filter expressions into one, as samtools
+// only applies the last '-e' option
+func (samtoolsViewCommand *SamtoolsViewCommand) getExpression() string {
+	if len(samtoolsViewCommand.expressions) == 1 {
+		return samtoolsViewCommand.expressions[0]
+	}
+	return "(" + strings.Join(samtoolsViewCommand.expressions, ") && (") + ")"
+}
+
+// AddMinStart adds a filter expression to the cli, only alignments starting
+// at or after the 0-based position are output
+func (samtoolsViewCommand *SamtoolsViewCommand) AddMinStart(minStart int) *SamtoolsViewCommand {
+	// samtools positions are 1-based
+	return samtoolsViewCommand.addExpression("pos >= " + strconv.Itoa(minStart+1))
+}
+
+// AddMinMappingQuality adds an option to the cli, only alignments with a
+// mapping quality of at least minMappingQuality are output
+func (samtoolsViewCommand *SamtoolsViewCommand) AddMinMappingQuality(minMappingQuality int) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-q")
+	samtoolsViewCommand.command.AddArg(strconv.Itoa(minMappingQuality))
+	return samtoolsViewCommand
+}
+
+// AddRequiredFlags adds an option to the cli, only alignments with all of the
+// FLAG bits set are output
+func (samtoolsViewCommand *SamtoolsViewCommand) AddRequiredFlags(requiredFlags int) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-f")
+	samtoolsViewCommand.command.AddArg(strconv.Itoa(requiredFlags))
+	return samtoolsViewCommand
+}
+
+// AddExcludedFlags adds an option to the cli, alignments with any of the FLAG
+// bits set are not output
+func (samtoolsViewCommand *SamtoolsViewCommand) AddExcludedFlags(excludedFlags int) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-F")
+	samtoolsViewCommand.command.AddArg(strconv.Itoa(excludedFlags))
+	return samtoolsViewCommand
+}
+
+// AddReadGroups adds a filter expression to the cli, only alignments tagged
+// with one of the read groups are output. read group ids are quoted as is, so
+// must not contain '"' or '\'
+func (samtoolsViewCommand *SamtoolsViewCommand) AddReadGroups(readGroups []string) *SamtoolsViewCommand {
+	conditions := []string{}
+	for _, readGroup := range readGroups {
+		conditions = append(conditions, "[RG] == \""+readGroup+"\"")
+	}
+	return samtoolsViewCommand.addExpression(strings.Join(conditions, " || "))
+}
+
+// AddLibrary adds an option to the cli, only alignments in read groups of the
+// library are output
+func (samtoolsViewCommand *SamtoolsViewCommand) AddLibrary(library string) *SamtoolsViewCommand {
+	samtoolsViewCommand.command.AddArg("-l")
+	samtoolsViewCommand.command.AddArg(library)
+	return samtoolsViewCommand
+}
+
 // StreamFromStdin adds a cli option, indicating that the input will come from
 // stdin and not an input file
 func (samtoolsViewCommand *SamtoolsViewCommand) StreamFromStdin() *SamtoolsViewCommand {
@@ -65,7 +159,15 @@ func (samtoolsViewCommand *SamtoolsViewCommand) StreamFromStdin() *SamtoolsViewC
 	return samtoolsViewCommand
 }
 
-// GetCommand exports the SamtoolsViewCommand as a generic Command
+// GetCommand exports the SamtoolsViewCommand as a generic Command, with the
+// filter expressions as a single '-e' option
 func (samtoolsViewCommand *SamtoolsViewCommand) GetCommand() *Command {
-	return samtoolsViewCommand.command
+	command := NewCommand()
+	command.SetBaseCommand(samtoolsViewCommand.command.GetBaseCommand())
+	command.SetArgs(append([]string{}, samtoolsViewCommand.command.GetArgs()...))
+	if len(samtoolsViewCommand.expressions) > 0 {
+		command.AddArg("-e")
+		command.AddArg(samtoolsViewCommand.getExpression())
+	}
+	return command
 }
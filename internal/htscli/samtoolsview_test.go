@@ -5,6 +5,10 @@
 package htscli
 
 import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
@@ -31,7 +35,7 @@ var samtoolsViewAddRegionTC = []struct {
 			Start:         intPtr(1000000),
 			End:           intPtr(2000000),
 		},
-		"chr1:1000000-2000000",
+		"chr1:1000001-2000000",
 	},
 	{
 		&htsrequest.Region{
@@ -39,7 +43,7 @@ var samtoolsViewAddRegionTC = []struct {
 			Start:         intPtr(550000),
 			End:           intPtr(990000),
 		},
-		"chr21:550000-990000",
+		"chr21:550001-990000",
 	},
 	{
 		&htsrequest.Region{
@@ -47,7 +51,7 @@ var samtoolsViewAddRegionTC = []struct {
 			Start:         intPtr(240000000),
 			End:           intPtr(485000000),
 		},
-		"chrX:240000000-485000000",
+		"chrX:240000001-485000000",
 	},
 }
 
@@ -81,7 +85,7 @@ var samtoolsViewGetCommandTC = []struct {
 			Start:         intPtr(1000000),
 			End:           intPtr(2000000),
 		},
-		[]string{"view", "-h", "-b", "-", "chr1:1000000-2000000"},
+		[]string{"view", "-h", "-b", "-", "chr1:1000001-2000000"},
 	},
 	{
 		false,
@@ -94,7 +98,7 @@ var samtoolsViewGetCommandTC = []struct {
 			Start:         intPtr(240000000),
 			End:           intPtr(485000000),
 		},
-		[]string{"view", "-H", "-b", "-", "chrX:240000000-485000000"},
+		[]string{"view", "-H", "-b", "-", "chrX:240000001-485000000"},
 	},
 }
 
@@ -103,6 +107,50 @@ func intPtr(i int) *int {
 	return &i
 }
 
+// fixturesDir directory of the test data sources
+var fixturesDir = filepath.Join("..", "..", "data", "test", "sources")
+
+// samtoolsViewRegionBoundariesTC test cases for regions bordering the first
+// chr1 read pair of the tabula muris fixture. the reads cover 0-based
+// positions [4861645, 4861745) and [4861803, 4861903), ie. 1-based positions
+// 4861646-4861745 and 4861804-4861903
+var samtoolsViewRegionBoundariesTC = []struct {
+	region       *htsrequest.Region
+	minStart     int
+	expPositions []string
+}{
+	// first base of the first read
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(4861645), End: intPtr(4861646)}, -1, []string{"4861646"}},
+	// last base of the first read
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(4861744), End: intPtr(4861745)}, -1, []string{"4861646"}},
+	// starting just after the first read
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(4861745), End: intPtr(4861804)}, -1, []string{"4861804"}},
+	// ending just before the first read, and at its first base
+	{&htsrequest.Region{ReferenceName: "chr1", End: intPtr(4861645)}, -1, []string{}},
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(0), End: intPtr(4861646)}, -1, []string{"4861646"}},
+	// alignments starting before the minimum start are excluded
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(4861645), End: intPtr(4861904)}, 4861646, []string{"4861804"}},
+	{&htsrequest.Region{ReferenceName: "chr1", Start: intPtr(4861645), End: intPtr(4861904)}, 4861645, []string{"4861646", "4861804"}},
+}
+
+// commandOutputColumn runs the command, getting a column of its tab-separated
+// output lines
+func commandOutputColumn(t *testing.T, command *Command, column int) []string {
+	if _, err := exec.LookPath(command.GetBaseCommand()); err != nil {
+		t.Skip(command.GetBaseCommand() + " is not installed")
+	}
+	output := new(bytes.Buffer)
+	command.SetupCmd()
+	assert.Equal(t, 0, command.RunCmd(output))
+	values := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) > column {
+			values = append(values, fields[column])
+		}
+	}
+	return values
+}
+
 // TestSamtoolsViewAddFilePath tests AddFilePath function
 func TestSamtoolsViewAddFilePath(t *testing.T) {
 	for _, tc := range samtoolsViewAddFilePathTC {
@@ -133,6 +181,21 @@ func TestSamtoolsViewOutputBAM(t *testing.T) {
 	assert.Equal(t, samtoolsView.command.GetLastArg(), "-b")
 }
 
+// TestSamtoolsViewOutputCRAM tests OutputCRAM function
+func TestSamtoolsViewOutputCRAM(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.OutputCRAM()
+	assert.Equal(t, samtoolsView.command.GetLastArg(), "-C")
+}
+
+// TestSamtoolsViewAddReference tests AddReference function
+func TestSamtoolsViewAddReference(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.AddReference("/path/to/reference.fa")
+	command := samtoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "-T", "/path/to/reference.fa"}, command.args)
+}
+
 // TestSamtoolsViewAddRegion tests AddRegion function
 func TestSamtoolsViewAddRegion(t *testing.T) {
 	for _, tc := range samtoolsViewAddRegionTC {
@@ -142,6 +205,54 @@ func TestSamtoolsViewAddRegion(t *testing.T) {
 	}
 }
 
+// TestSamtoolsViewAddRegionsFile tests AddRegionsFile function
+func TestSamtoolsViewAddRegionsFile(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.StreamFromStdin().AddRegionsFile("/tmp/regions.bed")
+	command := samtoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "-", "-L", "/tmp/regions.bed"}, command.args)
+}
+
+// TestSamtoolsViewAddMinStart tests AddMinStart function
+func TestSamtoolsViewAddMinStart(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.AddFilePath("/path/to/the/file.bam").AddMinStart(200)
+	command := samtoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "/path/to/the/file.bam", "-e", "pos >= 201"}, command.args)
+}
+
+// TestSamtoolsViewReadFilters tests AddMinMappingQuality, AddRequiredFlags,
+// AddExcludedFlags and AddLibrary functions
+func TestSamtoolsViewReadFilters(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.AddFilePath("/path/to/the/file.bam").
+		AddMinMappingQuality(30).
+		AddRequiredFlags(1).
+		AddExcludedFlags(3852).
+		AddLibrary("lib1")
+	command := samtoolsView.GetCommand()
+	assert.Equal(t, []string{"view", "/path/to/the/file.bam", "-q", "30", "-f", "1", "-F", "3852", "-l", "lib1"}, command.args)
+}
+
+// TestSamtoolsViewAddReadGroups tests AddReadGroups function, including its
+// combination with another filter expression
+func TestSamtoolsViewAddReadGroups(t *testing.T) {
+	samtoolsView := SamtoolsView()
+	samtoolsView.AddFilePath("/path/to/the/file.bam").AddReadGroups([]string{"rg1"})
+	assert.Equal(t, []string{"view", "/path/to/the/file.bam", "-e", "[RG] == \"rg1\""}, samtoolsView.GetCommand().args)
+
+	samtoolsView = SamtoolsView()
+	samtoolsView.AddFilePath("/path/to/the/file.bam").
+		AddMinStart(200).
+		AddMinMappingQuality(30).
+		AddReadGroups([]string{"rg1", "rg2"})
+	assert.Equal(t, []string{"view", "/path/to/the/file.bam", "-q", "30", "-e", "(pos >= 201) && ([RG] == \"rg1\" || [RG] == \"rg2\")"}, samtoolsView.GetCommand().args)
+
+	// the expression is added once, however many times the command is exported
+	samtoolsView.AddRequiredFlags(1)
+	assert.Equal(t, []string{"view", "/path/to/the/file.bam", "-q", "30", "-f", "1", "-e", "(pos >= 201) && ([RG] == \"rg1\" || [RG] == \"rg2\")"}, samtoolsView.GetCommand().args)
+}
+
 // TestSamtoolsViewStreamFromStdin tests StreamFromStdin function
 func TestSamtoolsViewStreamFromStdin(t *testing.T) {
 	samtoolsView := SamtoolsView()
@@ -179,3 +290,17 @@ func TestSamtoolsViewGetCommand(t *testing.T) {
 		}
 	}
 }
+
+// TestSamtoolsViewRegionBoundaries tests htsget regions select the reads
+// overlapping them, the 0-based coordinates being converted for samtools
+func TestSamtoolsViewRegionBoundaries(t *testing.T) {
+	bam := filepath.Join(fixturesDir, "tabulamuris", "A1-B000168-3_57_F-1-1_R2.mus.Aligned.out.sorted.bam")
+	for _, tc := range samtoolsViewRegionBoundariesTC {
+		samtoolsView := SamtoolsView().AddFilePath(bam).AddRegion(tc.region)
+		if tc.minStart != -1 {
+			samtoolsView.AddMinStart(tc.minStart)
+		}
+		command := samtoolsView.GetCommand()
+		assert.Equal(t, tc.expPositions, commandOutputColumn(t, command, 3), tc.region.String())
+	}
+}
@@ -0,0 +1,43 @@
+// Package htscli deals with the construction and submission of command-line
+// jobs
+//
+// Module version checks the command-line tools jobs are submitted to are
+// installed, and gets their versions
+package htscli
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// RequiredCommands base commands of all jobs the server submits, which must
+// be on the PATH
+var RequiredCommands = []string{"samtools", "bcftools", "htsget-refserver-utils"}
+
+// versionTimeout time the command may take to print its version
+var versionTimeout = 5 * time.Second
+
+// GetVersion finds the base command on the PATH, and gets its version as
+// the first line it prints with --version
+//
+// Arguments
+//	baseCommand (string): command name, or path to the command
+// Returns
+//	(string): first line of the version output, empty if none was printed
+//	(error): if not nil, the command could not be found, or failed or timed
+//	out printing its version
+func GetVersion(baseCommand string) (string, error) {
+	path, err := exec.LookPath(baseCommand)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0]), nil
+}
@@ -0,0 +1,50 @@
+// Package htscli deals with the construction and submission of command-line
+// jobs
+//
+// Module version_test tests module version
+package htscli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionGetVersionTC test cases for GetVersion, scripts standing in for the
+// command-line tools
+var versionGetVersionTC = []struct {
+	script     string
+	expVersion string
+	expError   bool
+}{
+	{"#!/bin/sh\necho 'samtools 1.10'\necho 'Using htslib 1.10'\n", "samtools 1.10", false},
+	{"#!/bin/sh\necho 'unknown option --version' >&2\nexit 1\n", "", true},
+	{"#!/bin/sh\nexec sleep 5\n", "", true},
+	{"#!/bin/sh\n", "", false},
+}
+
+// TestGetVersion tests GetVersion function
+func TestGetVersion(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsget-version")
+	defer os.RemoveAll(dir)
+	previousTimeout := versionTimeout
+	defer func() { versionTimeout = previousTimeout }()
+	versionTimeout = 500 * time.Millisecond
+	for _, tc := range versionGetVersionTC {
+		path := filepath.Join(dir, "tool")
+		ioutil.WriteFile(path, []byte(tc.script), 0755)
+		version, err := GetVersion(path)
+		assert.Equal(t, tc.expError, err != nil, tc.script)
+		assert.Equal(t, tc.expVersion, version)
+		os.Remove(path)
+	}
+
+	_, err := GetVersion("htsget-refserver-missing-command")
+	assert.NotNil(t, err)
+	_, err = GetVersion(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
@@ -12,7 +12,35 @@ import (
 
 // cliArgs contains all properties that can be specified on the command line
 type cliArgs struct {
-	configFile string
+	configFile    string
+	configuration *Configuration
+	setProperties map[string]bool
+}
+
+// propertyFlag a command line flag setting a property in the cli
+// configuration when parsed
+type propertyFlag struct {
+	property overridableProperty
+	config   *Configuration
+	value    string
+}
+
+func (f *propertyFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *propertyFlag) Set(value string) error {
+	f.value = value
+	return f.property.set(f.config, value)
+}
+
+// IsBoolFlag allows boolean properties to be set without a value, e.g.
+// -awsAssumeRole
+func (f *propertyFlag) IsBoolFlag() bool {
+	return f.property.isBoolProperty()
 }
 
 // cliargs (*cliArgs): singleton of settings loaded from command line
@@ -24,13 +52,34 @@ var cliargsLoaded sync.Once
 // parseCliArgs parse all cli options/flags and returns it as a new cliArgs
 // instance
 func parseCliArgs() *cliArgs {
-	configFilePtr := flag.String("config", "", "path to json config file")
+	configFilePtr := flag.String("config", "", "path to json, yaml or toml config file")
+	cliConfiguration := newOverrideConfiguration()
+	for _, property := range overridableProperties() {
+		if property.envOnly {
+			continue
+		}
+		flag.Var(
+			&propertyFlag{property: property, config: cliConfiguration},
+			property.name,
+			"sets the '"+property.name+"' property, overriding the config file and "+property.envVar,
+		)
+	}
 	flag.Parse()
 	newCliargs := new(cliArgs)
 	newCliargs.configFile = *configFilePtr
+	newCliargs.configuration = cliConfiguration
+	newCliargs.setProperties = map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		newCliargs.setProperties[f.Name] = true
+	})
 	return newCliargs
 }
 
+// isSet checks if the property is set by its command line flag
+func (args *cliArgs) isSet(property overridableProperty) bool {
+	return args.setProperties[property.name]
+}
+
 // loadCliArgs instantiates the cliargs config singleton, loading allowed options
 // into the object
 func loadCliArgs() {
@@ -5,6 +5,8 @@
 package htsconfig
 
 import (
+	"flag"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -29,3 +31,39 @@ func TestLoadCliArgs(t *testing.T) {
 		assert.Equal(t, tc.expCliArgs.configFile, actualCliArgs.configFile)
 	}
 }
+
+// propertyFlagTC test cases for propertyFlag
+var propertyFlagTC = []struct {
+	args           []string
+	expCorsMaxAge  int
+	expAssumeRole  *bool
+	expReadsEnable *bool
+	expErr         bool
+}{
+	{[]string{}, 0, nil, nil, false},
+	{[]string{"-corsMaxAge", "60", "-awsAssumeRole", "-readsEnabled=false"}, 60, boolPtr(true), boolPtr(false), false},
+	{[]string{"-corsMaxAge", "sixty"}, 0, nil, nil, true},
+	{[]string{"-readsEnabled=maybe"}, 0, nil, nil, true},
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestPropertyFlag(t *testing.T) {
+	for _, tc := range propertyFlagTC {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+		cliConfiguration := newOverrideConfiguration()
+		for _, property := range overridableProperties() {
+			flags.Var(&propertyFlag{property: property, config: cliConfiguration}, property.name, "")
+		}
+		err := flags.Parse(tc.args)
+		assert.Equal(t, tc.expErr, err != nil, tc.args)
+		if err == nil {
+			assert.Equal(t, tc.expCorsMaxAge, cliConfiguration.Container.ServerProps.CorsMaxAge)
+			assert.Equal(t, tc.expAssumeRole, cliConfiguration.Container.ServerProps.AwsAssumeRole)
+			assert.Equal(t, tc.expReadsEnable, cliConfiguration.Container.ReadsConfig.Enabled)
+		}
+	}
+}
@@ -2,14 +2,28 @@
 // properties, affecting runtime properties. also contains program constants
 //
 // Module configfile contains operations for setting properties from the
-// JSON config file
+// JSON, YAML or TOML config file
 package htsconfig
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// config file formats, detected from the config file extension
+const (
+	configFormatJSON = "json"
+	configFormatYAML = "yaml"
+	configFormatTOML = "toml"
 )
 
 var configFileSingleton *Configuration
@@ -18,36 +32,101 @@ var configFileSingletonLoaded = false
 
 var configFileSingletonLoadedError error
 
-// LoadConfigFile instanties config file singleton with correct runtime properties
+// LoadConfigFile instanties config file singleton with correct runtime
+// properties. without a -config flag, no properties are set
 func LoadConfigFile() {
 	// get config file path from cli
 	filePath := getCliArgs().configFile
-	_, err := os.Stat(filePath)
-	// check if the file doesn't exist, and if file is not valid JSON
-	if os.IsNotExist(err) {
-		configFileSingletonLoadedError = errors.New("The specified config file doesn't exist: " + filePath)
+	if filePath == "" {
+		configFileSingletonLoaded = true
 		return
 	}
+	configFile, err := readConfigFile(filePath)
 	if err != nil {
-		configFileSingletonLoadedError = errors.New(err.Error())
+		configFileSingletonLoadedError = err
 		return
 	}
-	jsonFile, err := os.Open(filePath)
+	configFileSingleton = configFile
+	configFileSingletonLoaded = true
+}
+
+// configFileFormat gets the format of the config file from its extension,
+// .yaml/.yml files are YAML, .toml files are TOML, and all others JSON
+//
+// Arguments
+//	filePath (string): path to the config file
+// Returns
+//	(string): config file format
+func configFileFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return configFormatYAML
+	case ".toml":
+		return configFormatTOML
+	}
+	return configFormatJSON
+}
+
+// decodeConfigFile decodes config file content of the given format. errors
+// include the line number of the problem
+//
+// Arguments
+//	format (string): config file format
+//	content ([]byte): config file content
+//	v (interface{}): value to decode into, as with json.Unmarshal
+// Returns
+//	(error): if not nil, the content is not valid, or can't be decoded into v
+func decodeConfigFile(format string, content []byte, v interface{}) error {
+	switch format {
+	case configFormatYAML:
+		return yaml.Unmarshal(content, v)
+	case configFormatTOML:
+		_, err := toml.Decode(string(content), v)
+		return err
+	}
+
+	// JSON errors only report the byte offset of the problem
+	err := json.Unmarshal(content, v)
+	var offset int64
+	switch jsonErr := err.(type) {
+	case *json.SyntaxError:
+		offset = jsonErr.Offset
+	case *json.UnmarshalTypeError:
+		offset = jsonErr.Offset
+	default:
+		return err
+	}
+	line := bytes.Count(content[:offset], []byte("\n")) + 1
+	return fmt.Errorf("json: line %d: %s", line, err.Error())
+}
+
+// readConfigFile reads and parses the config file, in the format indicated
+// by its extension
+//
+// Arguments
+//	filePath (string): path to the JSON, YAML or TOML config file
+// Returns
+//	(*Configuration): properties set in the config file
+//	(error): if not nil, the file doesn't exist or is not valid
+func readConfigFile(filePath string) (*Configuration, error) {
+	_, err := os.Stat(filePath)
+	// check if the file doesn't exist, and if file is not valid
+	if os.IsNotExist(err) {
+		return nil, errors.New("The specified config file doesn't exist: " + filePath)
+	}
 	if err != nil {
-		configFileSingletonLoadedError = errors.New(err.Error())
-		return
+		return nil, err
 	}
-	jsonContent, err := ioutil.ReadAll(jsonFile)
+	content, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		configFileSingletonLoadedError = errors.New(err.Error())
-		return
+		return nil, err
 	}
-
-	err = json.Unmarshal(jsonContent, &configFileSingleton)
+	configFile := new(Configuration)
+	err = decodeConfigFile(configFileFormat(filePath), content, configFile)
 	if err != nil {
-		configFileSingletonLoadedError = errors.New(err.Error())
+		return nil, err
 	}
-	configFileSingletonLoaded = true
+	return configFile, nil
 }
 
 func SetConfigFile(configFile *Configuration) {
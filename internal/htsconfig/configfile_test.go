@@ -0,0 +1,182 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module configfile_test tests module configfile
+package htsconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// configfileEquivalentTC the same configuration, as JSON, YAML and TOML
+var configfileEquivalentTC = map[string]string{
+	"config.json": `{
+  "htsgetConfig": {
+    "props": {
+      "port": "4000",
+      "tempDir": "/tmp/htsget",
+      "corsMaxAge": 60,
+      "awsAssumeRole": true
+    },
+    "reads": {
+      "enabled": true,
+      "dataSourceRegistry": {
+        "sources": [
+          {
+            "pattern": "^cohort\\.(?P<accession>.*)$",
+            "path": "s3://bucket/{accession}.cram",
+            "referencePath": "/refs/GRCh38.fa",
+            "visas": ["https://dac.example.org/datasets/cohort"]
+          },
+          {
+            "pattern": "^(?P<accession>.*)$",
+            "path": "/data/{accession}.bam"
+          }
+        ]
+      },
+      "serviceInfo": {
+        "id": "org.example.htsget.reads",
+        "htsget": {"formats": ["BAM", "CRAM"], "fieldsParameterEffective": false}
+      }
+    },
+    "variants": {
+      "enabled": false
+    }
+  }
+}`,
+	"config.yaml": `htsgetConfig:
+  props:
+    port: "4000"
+    tempDir: /tmp/htsget
+    corsMaxAge: 60
+    awsAssumeRole: true
+  reads:
+    enabled: true
+    dataSourceRegistry:
+      sources:
+        - pattern: ^cohort\.(?P<accession>.*)$
+          path: s3://bucket/{accession}.cram
+          referencePath: /refs/GRCh38.fa
+          visas:
+            - https://dac.example.org/datasets/cohort
+        - pattern: ^(?P<accession>.*)$
+          path: /data/{accession}.bam
+    serviceInfo:
+      id: org.example.htsget.reads
+      htsget:
+        formats: [BAM, CRAM]
+        fieldsParameterEffective: false
+  variants:
+    enabled: false
+`,
+	"config.toml": `[htsgetConfig.props]
+port = "4000"
+tempDir = "/tmp/htsget"
+corsMaxAge = 60
+awsAssumeRole = true
+
+[htsgetConfig.reads]
+enabled = true
+
+[[htsgetConfig.reads.dataSourceRegistry.sources]]
+pattern = '^cohort\.(?P<accession>.*)$'
+path = "s3://bucket/{accession}.cram"
+referencePath = "/refs/GRCh38.fa"
+visas = ["https://dac.example.org/datasets/cohort"]
+
+[[htsgetConfig.reads.dataSourceRegistry.sources]]
+pattern = '^(?P<accession>.*)$'
+path = "/data/{accession}.bam"
+
+[htsgetConfig.reads.serviceInfo]
+id = "org.example.htsget.reads"
+
+[htsgetConfig.reads.serviceInfo.htsget]
+formats = ["BAM", "CRAM"]
+fieldsParameterEffective = false
+
+[htsgetConfig.variants]
+enabled = false
+`,
+}
+
+// configfileErrorLineTC invalid config files, and the expected start of
+// the error, locating the problem
+var configfileErrorLineTC = []struct {
+	fileName  string
+	content   string
+	expPrefix string
+}{
+	{"config.json", "{\n  \"htsgetConfig\": {\n    \"props\": {\"port\": 80}\n  }\n}", "json: line 3: "},
+	{"config.json", "{\n  \"htsgetConfig\": {\n    \"props\": {\"port\": \"80\",}\n  }\n}", "json: line 3: "},
+	{"config.yml", "htsgetConfig:\n  props:\n    corsMaxAge: [60]\n", "yaml: unmarshal errors:\n  line 3: "},
+	{"config.yaml", "htsgetConfig:\n  props:\n   port: \"80\"\n  host: x: y\n", "yaml: line 4: "},
+	{"config.toml", "[htsgetConfig.props]\nport = \"80\"\ncorsMaxAge = \"60\"\n", "toml: line 3 "},
+	{"config.toml", "[htsgetConfig.props]\nport = \"80\"\nhost = http://localhost/\n", "toml: line 3 "},
+}
+
+func TestConfigFileFormat(t *testing.T) {
+	assert.Equal(t, configFormatJSON, configFileFormat("/config/config.json"))
+	assert.Equal(t, configFormatJSON, configFileFormat("/config/config"))
+	assert.Equal(t, configFormatYAML, configFileFormat("/config/config.yaml"))
+	assert.Equal(t, configFormatYAML, configFileFormat("/config/config.YML"))
+	assert.Equal(t, configFormatTOML, configFileFormat("/config/config.toml"))
+}
+
+// TestReadConfigFileEquivalent tests equivalent JSON, YAML and TOML config
+// files produce identical configurations
+func TestReadConfigFileEquivalent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsget-configfile")
+	defer os.RemoveAll(dir)
+
+	configs := map[string]*Configuration{}
+	for fileName, content := range configfileEquivalentTC {
+		filePath := filepath.Join(dir, fileName)
+		ioutil.WriteFile(filePath, []byte(content), 0644)
+		configFile, err := readConfigFile(filePath)
+		assert.Nil(t, err, fileName)
+		configs[fileName] = buildConfiguration(configFile)
+		assert.Empty(t, ValidateConfigFile(filePath), fileName)
+	}
+	assert.Equal(t, configs["config.json"], configs["config.yaml"])
+	assert.Equal(t, configs["config.json"], configs["config.toml"])
+
+	props := configs["config.yaml"].Container.ServerProps
+	assert.Equal(t, "4000", props.Port)
+	assert.Equal(t, "/tmp/htsget", props.TempDir)
+	assert.Equal(t, 60, props.CorsMaxAge)
+	assert.Equal(t, true, *props.AwsAssumeRole)
+	assert.Equal(t, false, *configs["config.toml"].Container.VariantsConfig.Enabled)
+	path, _ := configs["config.toml"].Container.ReadsConfig.DataSourceRegistry.GetMatchingPath("cohort.00001")
+	assert.Equal(t, "s3://bucket/00001.cram", path)
+
+	// the example config files are equivalent too
+	var examples []*Configuration
+	for _, example := range []string{"example-0.config.json", "example-0.config.yaml", "example-0.config.toml"} {
+		configFile, err := readConfigFile(filepath.Join("../../data/config", example))
+		assert.Nil(t, err, example)
+		examples = append(examples, buildConfiguration(configFile))
+	}
+	assert.Equal(t, examples[0], examples[1])
+	assert.Equal(t, examples[0], examples[2])
+}
+
+func TestReadConfigFileErrorLine(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsget-configfile")
+	defer os.RemoveAll(dir)
+
+	for _, tc := range configfileErrorLineTC {
+		filePath := filepath.Join(dir, tc.fileName)
+		ioutil.WriteFile(filePath, []byte(tc.content), 0644)
+		_, err := readConfigFile(filePath)
+		if assert.NotNil(t, err, tc.content) {
+			assert.True(t, strings.HasPrefix(err.Error(), tc.expPrefix), err.Error())
+		}
+	}
+}
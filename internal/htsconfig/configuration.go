@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 
@@ -23,36 +24,55 @@ import (
 // Attributes
 //	ReadsDataSourceRegistry (*DataSourceRegistry): data sources for reads endpoint
 type Configuration struct {
-	Container *configurationContainer `json:"htsgetConfig"`
+	Container *configurationContainer `json:"htsgetConfig" yaml:"htsgetConfig" toml:"htsgetConfig"`
 }
 
 type configurationContainer struct {
-	ServerProps    *configurationServerProps `json:"props"`
-	ReadsConfig    *configurationEndpoint    `json:"reads"`
-	VariantsConfig *configurationEndpoint    `json:"variants"`
+	ServerProps    *configurationServerProps `json:"props" yaml:"props" toml:"props"`
+	ReadsConfig    *configurationEndpoint    `json:"reads" yaml:"reads" toml:"reads"`
+	VariantsConfig *configurationEndpoint    `json:"variants" yaml:"variants" toml:"variants"`
+	AuthConfig     *configurationAuth        `json:"auth" yaml:"auth" toml:"auth"`
 }
 
 type configurationServerProps struct {
-	Port                 string `json:"port"`
-	Host                 string `json:"host"`
-	DocsDir              string `json:"docsDir"`
-	TempDir              string `json:"tempdir"`
-	LogFile              string `json:"logFile"`
-	CorsAllowedOrigins   string `json:"corsAllowedOrigins"`
-	CorsAllowedMethods   string `json:"corsAllowedMethods"`
-	CorsAllowedHeaders   string `json:"corsAllowedHeaders"`
-	CorsAllowCredentials *bool  `json:"corsAllowCredentials"`
-	CorsMaxAge           int    `json:"corsMaxAge"`
-	AwsAssumeRole        *bool  `json:"awsAssumeRole"`
+	Port                 string `json:"port" yaml:"port" toml:"port"`
+	Host                 string `json:"host" yaml:"host" toml:"host"`
+	DocsDir              string `json:"docsDir" yaml:"docsDir" toml:"docsDir"`
+	TempDir              string `json:"tempdir" yaml:"tempDir" toml:"tempDir"`
+	LogFile              string `json:"logFile" yaml:"logFile" toml:"logFile"`
+	LogLevel             string `json:"logLevel" yaml:"logLevel" toml:"logLevel"`
+	CorsAllowedOrigins   string `json:"corsAllowedOrigins" yaml:"corsAllowedOrigins" toml:"corsAllowedOrigins"`
+	CorsAllowedMethods   string `json:"corsAllowedMethods" yaml:"corsAllowedMethods" toml:"corsAllowedMethods"`
+	CorsAllowedHeaders   string `json:"corsAllowedHeaders" yaml:"corsAllowedHeaders" toml:"corsAllowedHeaders"`
+	CorsAllowCredentials *bool  `json:"corsAllowCredentials" yaml:"corsAllowCredentials" toml:"corsAllowCredentials"`
+	CorsMaxAge           int    `json:"corsMaxAge" yaml:"corsMaxAge" toml:"corsMaxAge"`
+	AwsAssumeRole        *bool  `json:"awsAssumeRole" yaml:"awsAssumeRole" toml:"awsAssumeRole"`
+	AwsPresignExpiry     int    `json:"awsPresignExpiry" yaml:"awsPresignExpiry" toml:"awsPresignExpiry"`
+	GcsSignedURLExpiry   int    `json:"gcsSignedUrlExpiry" yaml:"gcsSignedUrlExpiry" toml:"gcsSignedUrlExpiry"`
+	AzureSasExpiry       int    `json:"azureSasExpiry" yaml:"azureSasExpiry" toml:"azureSasExpiry"`
+	DrsCacheTTL          int    `json:"drsCacheTtl" yaml:"drsCacheTtl" toml:"drsCacheTtl"`
+	URLSigningKey        string `json:"urlSigningKey" yaml:"urlSigningKey" toml:"urlSigningKey"`
+	URLExpiry            int    `json:"urlExpiry" yaml:"urlExpiry" toml:"urlExpiry"`
+	MetricsEnabled       *bool  `json:"metricsEnabled" yaml:"metricsEnabled" toml:"metricsEnabled"`
 }
 
 type configurationEndpoint struct {
-	Enabled            *bool               `json:"enabled,true" default:"true"`
-	DataSourceRegistry *DataSourceRegistry `json:"dataSourceRegistry"`
-	ServiceInfo        *ServiceInfo        `json:"serviceInfo"`
+	Enabled            *bool               `json:"enabled,true" yaml:"enabled" toml:"enabled" default:"true"`
+	DataSourceRegistry *DataSourceRegistry `json:"dataSourceRegistry" yaml:"dataSourceRegistry" toml:"dataSourceRegistry"`
+	ServiceInfo        *ServiceInfo        `json:"serviceInfo" yaml:"serviceInfo" toml:"serviceInfo"`
 }
 
-var configurationSingleton *Configuration
+type configurationAuth struct {
+	Enabled       *bool  `json:"enabled" yaml:"enabled" toml:"enabled"`
+	JwksFile      string `json:"jwksFile" yaml:"jwksFile" toml:"jwksFile"`
+	IssuerKeyFile string `json:"issuerKeyFile" yaml:"issuerKeyFile" toml:"issuerKeyFile"`
+	Issuer        string `json:"issuer" yaml:"issuer" toml:"issuer"`
+	Audience      string `json:"audience" yaml:"audience" toml:"audience"`
+}
+
+// configurationSingleton (atomic.Value): holds the current *Configuration,
+// swapped as a whole when the configuration is reloaded
+var configurationSingleton atomic.Value
 
 var configurationSingletonLoaded = false
 
@@ -70,6 +90,7 @@ func patchConfiguration(defR reflect.Value, patchR reflect.Value) {
 			"string",
 			"int",
 			"*bool",
+			"[]string",
 			"*htsconfig.DataSourceRegistry",
 		}
 
@@ -89,11 +110,17 @@ func patchConfiguration(defR reflect.Value, patchR reflect.Value) {
 					defR.Field(i).Set(patchR.Field(i))
 				}
 			} else if defRType == "int" {
-				defR.Field(i).Set(patchR.Field(i))
+				if patchR.Field(i).Int() != 0 {
+					defR.Field(i).Set(patchR.Field(i))
+				}
 			} else if defRType == "*bool" {
 				if !patchR.Field(i).IsNil() {
 					defR.Field(i).Set(patchR.Field(i))
 				}
+			} else if defRType == "[]string" {
+				if !patchR.Field(i).IsNil() {
+					defR.Field(i).Set(patchR.Field(i))
+				}
 			} else if defRType == "*htsconfig.DataSourceRegistry" {
 				if !patchR.Field(i).IsNil() {
 					defR.Field(i).Set(patchR.Field(i))
@@ -103,35 +130,63 @@ func patchConfiguration(defR reflect.Value, patchR reflect.Value) {
 	}
 }
 
-func LoadConfig() {
+// buildConfiguration patches the default configuration with the properties
+// set in the config file, then with those set by environment variables, and
+// finally with those set by command line flags
+//
+// Arguments
+//	configFile (*Configuration): properties set in the config file, may be nil
+// Returns
+//	(*Configuration): complete configuration
+func buildConfiguration(configFile *Configuration) *Configuration {
 	newConfiguration := new(Configuration)
 	deepcopy.Copy(newConfiguration, DefaultConfiguration)
+	if configFile != nil {
+		patchConfiguration(
+			reflect.ValueOf(newConfiguration).Elem(),
+			reflect.ValueOf(configFile).Elem(),
+		)
+	}
+	if envConfiguration, err := getEnvConfiguration(); err == nil {
+		overrideConfiguration(newConfiguration, envConfiguration, isEnvSet)
+	}
+	overrideConfiguration(newConfiguration, getCliArgs().configuration, getCliArgs().isSet)
+	return newConfiguration
+}
 
+// LoadConfig builds the configuration from the config file, environment and
+// command line. problems loading or validating it are available from
+// GetConfigLoadError, the server should not be started if there are any
+func LoadConfig() {
+	configurationSingletonLoadedError = nil
+	configFile := getConfigFile()
 	configFileLoadError := getConfigFileLoadError()
 	if configFileLoadError != nil {
 		configurationSingletonLoadedError = errors.New(configFileLoadError.Error())
 	}
+	if _, err := getEnvConfiguration(); err != nil {
+		configurationSingletonLoadedError = err
+	}
 
-	configFileConfiguration := getConfigFile()
-	if configFileConfiguration != nil {
-		patchConfiguration(
-			reflect.ValueOf(newConfiguration).Elem(),
-			reflect.ValueOf(configFileConfiguration).Elem(),
-		)
+	newConfiguration := buildConfiguration(configFile)
+	if problems := validateConfiguration(newConfiguration); len(problems) > 0 && configurationSingletonLoadedError == nil {
+		configurationSingletonLoadedError = problemsError(problems)
 	}
 	SetConfig(newConfiguration)
 	configurationSingletonLoaded = true
 }
 
+// SetConfig swaps in the configuration. callers of GetConfig holding the
+// previous configuration keep a consistent view of it
 func SetConfig(config *Configuration) {
-	configurationSingleton = config
+	configurationSingleton.Store(config)
 }
 
 func GetConfig() *Configuration {
 	if !configurationSingletonLoaded {
 		LoadConfig()
 	}
-	return configurationSingleton
+	return configurationSingleton.Load().(*Configuration)
 }
 
 func getContainer() *configurationContainer {
@@ -181,6 +236,12 @@ func GetLogFile() string {
 	return getServerProps().LogFile
 }
 
+// GetLogLevel gets the minimum level of log entries written, one of debug,
+// info, warn or error
+func GetLogLevel() string {
+	return getServerProps().LogLevel
+}
+
 func GetCorsAllowedOrigins() string {
 	return getServerProps().CorsAllowedOrigins
 }
@@ -201,6 +262,20 @@ func GetCorsMaxAge() int {
 	return getServerProps().CorsMaxAge
 }
 
+// GetURLSigningKey gets the secret key ticket urls are signed with. if empty,
+// ticket urls are unsigned
+func GetURLSigningKey() string {
+	return getServerProps().URLSigningKey
+}
+
+// GetURLExpiry gets the number of seconds signed ticket urls remain valid for
+func GetURLExpiry() int {
+	if getServerProps().URLExpiry <= 0 {
+		return htsconstants.DfltURLExpiry
+	}
+	return getServerProps().URLExpiry
+}
+
 func getEndpointConfig(ep htsconstants.APIEndpoint) *configurationEndpoint {
 	reads := getContainer().ReadsConfig
 	variants := getContainer().VariantsConfig
@@ -208,9 +283,11 @@ func getEndpointConfig(ep htsconstants.APIEndpoint) *configurationEndpoint {
 		htsconstants.APIEndpointReadsTicket:         reads,
 		htsconstants.APIEndpointReadsData:           reads,
 		htsconstants.APIEndpointReadsServiceInfo:    reads,
+		htsconstants.APIEndpointReadsFileBytes:      reads,
 		htsconstants.APIEndpointVariantsTicket:      variants,
 		htsconstants.APIEndpointVariantsData:        variants,
 		htsconstants.APIEndpointVariantsServiceInfo: variants,
+		htsconstants.APIEndpointVariantsFileBytes:   variants,
 	}
 	return configs[ep]
 }
@@ -227,6 +304,12 @@ func GetObjectPath(ep htsconstants.APIEndpoint, id string) (string, error) {
 	return GetDataSourceRegistry(ep).GetMatchingPath(id)
 }
 
+// GetObjectReferencePath gets the reference FASTA path configured for the
+// object's data source, empty if the data source doesn't specify one
+func GetObjectReferencePath(ep htsconstants.APIEndpoint, id string) (string, error) {
+	return GetDataSourceRegistry(ep).GetMatchingReferencePath(id)
+}
+
 func GetServiceInfo(ep htsconstants.APIEndpoint) *ServiceInfo {
 	return getEndpointConfig(ep).ServiceInfo
 }
@@ -239,3 +322,82 @@ func GetConfigLoadError() error {
 func IsAwsAssumeRole() bool {
 	return *getServerProps().AwsAssumeRole
 }
+
+// IsMetricsEnabled checks if metrics are served at /metrics
+func IsMetricsEnabled() bool {
+	return *getServerProps().MetricsEnabled
+}
+
+// GetAwsPresignExpiry gets the number of seconds presigned S3 urls in tickets
+// remain valid for
+func GetAwsPresignExpiry() int {
+	if getServerProps().AwsPresignExpiry <= 0 {
+		return htsconstants.DfltAwsPresignExpiry
+	}
+	return getServerProps().AwsPresignExpiry
+}
+
+// GetGcsSignedURLExpiry gets the number of seconds signed GCS urls in tickets
+// remain valid for
+func GetGcsSignedURLExpiry() int {
+	if getServerProps().GcsSignedURLExpiry <= 0 {
+		return htsconstants.DfltGcsSignedURLExpiry
+	}
+	return getServerProps().GcsSignedURLExpiry
+}
+
+// GetAzureSasExpiry gets the number of seconds SAS-signed Azure urls in
+// tickets remain valid for
+func GetAzureSasExpiry() int {
+	if getServerProps().AzureSasExpiry <= 0 {
+		return htsconstants.DfltAzureSasExpiry
+	}
+	return getServerProps().AzureSasExpiry
+}
+
+// GetDrsCacheTTL gets the number of seconds drs:// paths stay resolved to
+// the same access url before the DRS API is asked again
+func GetDrsCacheTTL() int {
+	if getServerProps().DrsCacheTTL <= 0 {
+		return htsconstants.DfltDrsCacheTTL
+	}
+	return getServerProps().DrsCacheTTL
+}
+
+func getAuthConfig() *configurationAuth {
+	return getContainer().AuthConfig
+}
+
+// IsAuthEnabled checks if requests for data must be authenticated by bearer
+// token
+func IsAuthEnabled() bool {
+	return *getAuthConfig().Enabled
+}
+
+// GetAuthJwksFile gets the path to the JWKS file of keys trusted to sign tokens
+func GetAuthJwksFile() string {
+	return getAuthConfig().JwksFile
+}
+
+// GetAuthIssuerKeyFile gets the path to the PEM encoded public key of the
+// token issuer
+func GetAuthIssuerKeyFile() string {
+	return getAuthConfig().IssuerKeyFile
+}
+
+// GetAuthIssuer gets the required token issuer, empty if any issuer is accepted
+func GetAuthIssuer() string {
+	return getAuthConfig().Issuer
+}
+
+// GetAuthAudience gets the required token audience, empty if any audience is
+// accepted
+func GetAuthAudience() string {
+	return getAuthConfig().Audience
+}
+
+// GetObjectVisas gets the visas granting access to the object's data source,
+// empty if the data source is open to all authenticated users
+func GetObjectVisas(ep htsconstants.APIEndpoint, id string) ([]string, error) {
+	return GetDataSourceRegistry(ep).GetMatchingVisas(id)
+}
@@ -9,18 +9,25 @@ package htsconfig
 
 import (
 	"errors"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/ga4gh/htsget-refserver/internal/drsutils"
 	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
+// drsResolver resolves drs:// paths to access urls, shared by all registries
+// so resolutions are cached across requests
+var drsResolver = drsutils.NewResolver()
+
 // DataSourceRegistry holds all data sources for a particular endpoint
 //
 // Attributes
 //	Sources ([]*DataSource): list of data sources to scan
 type DataSourceRegistry struct {
-	Sources []*DataSource `json:"sources"`
+	Sources []*DataSource `json:"sources" yaml:"sources" toml:"sources"`
 }
 
 // DataSource references a single source of htsget-related data the service
@@ -30,9 +37,13 @@ type DataSourceRegistry struct {
 // Attributes
 //	Pattern (string): regex pattern indicating criteria for an ID to match the data source
 //	Path (string): path template, indicating how matching ids can be resolved to an exact location (path or url)
+//	ReferencePath (string): optional path template to the reference FASTA used to decode/encode CRAM
+//	Visas ([]string): optional ControlledAccessGrants visa values, one of which callers must hold when auth is enabled
 type DataSource struct {
-	Pattern string `json:"pattern"`
-	Path    string `json:"path"`
+	Pattern       string   `json:"pattern" yaml:"pattern" toml:"pattern"`
+	Path          string   `json:"path" yaml:"path" toml:"path"`
+	ReferencePath string   `json:"referencePath" yaml:"referencePath" toml:"referencePath"`
+	Visas         []string `json:"visas" yaml:"visas" toml:"visas"`
 }
 
 // newDataSourceRegistry instantiates a data source registry
@@ -64,6 +75,82 @@ func (dataSource *DataSource) evaluatePatternMatch(id string) (bool, error) {
 //	(string): populated resource location based on path template and id
 //	(error): if not nil, an error was encountered in the evaluation process
 func (dataSource *DataSource) evaluatePath(id string) (string, error) {
+	return dataSource.evaluateTemplate(dataSource.Path, id)
+}
+
+// templateRoot gets the directory local files populated from a path template
+// are confined to, the static part of the template preceding any {name}
+// placeholder
+//
+// Arguments
+//	template (string): path template
+// Returns
+//	(string): cleaned root directory of the template
+func templateRoot(template string) string {
+	static := template
+	if i := strings.Index(static, "{"); i >= 0 {
+		static = static[:i]
+	}
+	if strings.HasSuffix(static, "/") {
+		return filepath.Clean(static)
+	}
+	return filepath.Dir(static)
+}
+
+// confineToRoot checks a path populated from a template, refusing local paths
+// outside of the template root (e.g. through ".." in the id). urls are not
+// confined
+//
+// Arguments
+//	template (string): path template the path was populated from
+//	path (string): populated path
+//	id (string): requested object id
+// Returns
+//	(error): if not nil, the path is a local path outside of the template root
+func confineToRoot(template string, path string, id string) error {
+	if htsutils.IsValidURL(path) {
+		return nil
+	}
+	rel, err := filepath.Rel(templateRoot(template), filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.New("id: " + id + " resolves outside of the data source root")
+	}
+	return nil
+}
+
+// evaluateReferencePath completes the reference FASTA path based on the
+// reference path template and the passed id. reference paths are optional,
+// an empty string is returned if the data source does not specify one
+//
+//	Type: DataSource
+// Arguments
+//	id (string): requested object id
+// Returns
+//	(string): populated reference location based on reference path template and id
+//	(error): if not nil, an error was encountered in the evaluation process
+func (dataSource *DataSource) evaluateReferencePath(id string) (string, error) {
+	if dataSource.ReferencePath == "" {
+		return "", nil
+	}
+	// a single reference is commonly shared by all objects in the source,
+	// such reference paths have no placeholders to populate
+	if !strings.Contains(dataSource.ReferencePath, "{") {
+		return dataSource.ReferencePath, nil
+	}
+	return dataSource.evaluateTemplate(dataSource.ReferencePath, id)
+}
+
+// evaluateTemplate populates a path template with the named capture groups
+// obtained by evaluating the data source pattern against the passed id
+//
+//	Type: DataSource
+// Arguments
+//	template (string): path template, containing {name} placeholders
+//	id (string): requested object id
+// Returns
+//	(string): populated path
+//	(error): if not nil, an error was encountered in the evaluation process
+func (dataSource *DataSource) evaluateTemplate(template string, id string) (string, error) {
 
 	// create match map, map of named control groups parsed from the regex
 	// evaluation of pattern on id
@@ -71,12 +158,12 @@ func (dataSource *DataSource) evaluatePath(id string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	parameterNamesMap, err := htsutils.CreateRegexNamedParameterMap("\\{(?P<paramName>.+?)\\}", dataSource.Path)
+	parameterNamesMap, err := htsutils.CreateRegexNamedParameterMap("\\{(?P<paramName>.+?)\\}", template)
 	if err != nil {
 		return "", err
 	}
 
-	finalPath := dataSource.Path
+	finalPath := template
 	for i := 0; i < len(parameterNamesMap["paramName"]); i++ {
 		paramName := parameterNamesMap["paramName"][i]
 		finalPath = strings.Replace(finalPath, "{"+paramName+"}", idParameterMap[paramName][0], -1)
@@ -130,7 +217,9 @@ func (registry *DataSourceRegistry) findFirstMatch(id string) (*DataSource, erro
 
 // GetMatchingPath gets the correct path to the object from the requested id
 // the registry is scanned for the first source matching the pattern. once found,
-// the path template is populated with the id
+// the path template is populated with the id. local paths outside of the data
+// source root are refused. drs:// paths are resolved to the object's access
+// url through the DRS API
 //
 //	Type: DataSourceRegistry
 // Arguments
@@ -147,9 +236,87 @@ func (registry *DataSourceRegistry) GetMatchingPath(id string) (string, error) {
 	if path == "" || err != nil {
 		return "", err
 	}
+	if err := confineToRoot(matchingDataSource.Path, path, id); err != nil {
+		return "", err
+	}
+	if drsutils.IsDRSURI(path) {
+		return drsResolver.Resolve(path, time.Duration(GetDrsCacheTTL())*time.Second)
+	}
 	return path, err
 }
 
+// GetMatchingFilePath gets the local file path to the object from the
+// requested id, like GetMatchingPath. ids resolving to urls, or to files
+// outside the data source's root directory (e.g. through ".." in the id) are
+// refused
+//
+//	Type: DataSourceRegistry
+// Arguments
+//	id (string): requested object id
+// Returns
+//	(string): local path to the requested file
+//	(error): if not nil, the id does not resolve to a local file within a data source root
+func (registry *DataSourceRegistry) GetMatchingFilePath(id string) (string, error) {
+	matchingDataSource, err := registry.findFirstMatch(id)
+	if matchingDataSource == nil || err != nil {
+		return "", err
+	}
+	path, err := matchingDataSource.evaluatePath(id)
+	if err != nil {
+		return "", err
+	}
+	if path == "" || htsutils.IsValidURL(path) {
+		return "", errors.New("id: " + id + " does not resolve to a local file")
+	}
+	if err := confineToRoot(matchingDataSource.Path, path, id); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// GetMatchingReferencePath gets the path to the reference FASTA associated
+// with the requested id. the first data source matching the id is used, and
+// its reference path template is populated with the id. local paths outside
+// of the template root are refused
+//
+//	Type: DataSourceRegistry
+// Arguments
+//	id (string): requested object id
+// Returns
+//	(string): location of the reference FASTA, empty if none is configured
+//	(error): if not nil, the reference location could not be constructed for the id
+func (registry *DataSourceRegistry) GetMatchingReferencePath(id string) (string, error) {
+	matchingDataSource, err := registry.findFirstMatch(id)
+	if matchingDataSource == nil || err != nil {
+		return "", err
+	}
+	referencePath, err := matchingDataSource.evaluateReferencePath(id)
+	if referencePath == "" || err != nil {
+		return "", err
+	}
+	if err := confineToRoot(matchingDataSource.ReferencePath, referencePath, id); err != nil {
+		return "", err
+	}
+	return referencePath, nil
+}
+
+// GetMatchingVisas gets the visas granting access to the requested id, from
+// the first data source matching the id
+//
+//	Type: DataSourceRegistry
+// Arguments
+//	id (string): requested object id
+// Returns
+//	([]string): ControlledAccessGrants visa values, empty if the data source does not restrict access
+//	(error): if not nil, no data source matches the id
+func (registry *DataSourceRegistry) GetMatchingVisas(id string) ([]string, error) {
+	matchingDataSource, err := registry.findFirstMatch(id)
+	if matchingDataSource == nil || err != nil {
+		return nil, err
+	}
+	return matchingDataSource.Visas, nil
+}
+
 // String gets the registry representation as a string
 //
 //	Type: DataSourceRegistry
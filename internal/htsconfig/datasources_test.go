@@ -0,0 +1,180 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module datasources_test tests module datasources
+package htsconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/drsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// datasourcesGetMatchingPathTC test cases for GetMatchingPath
+var datasourcesGetMatchingPathTC = []struct {
+	id     string
+	exp    string
+	expErr bool
+}{
+	{"tabulamuris.00001", "./data/gcp/tabula-muris/00001.bam", false},
+	{"object0001", "https://example.org/reads/object0001.cram", false},
+	{"unregistered", "", true},
+	// traversal out of the data source root
+	{"tabulamuris.subdir/../00001", "./data/gcp/tabula-muris/subdir/../00001.bam", false},
+	{"tabulamuris.../../../../etc/passwd", "", true},
+	{"cohort.subdir/../../../etc/passwd", "", true},
+}
+
+// datasourcesGetMatchingReferencePathTC test cases for GetMatchingReferencePath
+var datasourcesGetMatchingReferencePathTC = []struct {
+	id     string
+	exp    string
+	expErr bool
+}{
+	{"tabulamuris.00001", "", false},
+	{"object0001", "https://example.org/refs/object0001.fa", false},
+	{"cohort.00002", "/refs/GRCh38.fa", false},
+	{"unregistered", "", true},
+	// traversal out of the reference root
+	{"local.00003", "/refs/local/00003.fa", false},
+	{"local.../../etc/passwd", "", true},
+}
+
+// datasourcesGetMatchingVisasTC test cases for GetMatchingVisas
+var datasourcesGetMatchingVisasTC = []struct {
+	id     string
+	exp    []string
+	expErr bool
+}{
+	{"tabulamuris.00001", nil, false},
+	{"cohort.00002", []string{"https://dac.example.org/datasets/cohort"}, false},
+	{"unregistered", nil, true},
+}
+
+// datasourcesGetMatchingFilePathTC test cases for GetMatchingFilePath
+var datasourcesGetMatchingFilePathTC = []struct {
+	id     string
+	exp    string
+	expErr bool
+}{
+	{"tabulamuris.00001", "./data/gcp/tabula-muris/00001.bam", false},
+	{"tabulamuris.subdir/../00001", "./data/gcp/tabula-muris/subdir/../00001.bam", false},
+	{"cohort.00002", "/data/cohort/00002.cram", false},
+	// urls are not local files
+	{"object0001", "", true},
+	{"unregistered", "", true},
+	// traversal out of the data source root
+	{"tabulamuris./../00001", "", true},
+	{"tabulamuris.../../../../etc/passwd", "", true},
+	{"cohort.../../etc/passwd", "", true},
+	{"cohort.subdir/../../../etc/passwd", "", true},
+}
+
+// datasourcesTestRegistry creates a registry with and without references
+func datasourcesTestRegistry() *DataSourceRegistry {
+	registry := newDataSourceRegistry()
+	registry.addDataSource(newDataSource(
+		"^tabulamuris\\.(?P<accession>.*)$",
+		"./data/gcp/tabula-muris/{accession}.bam",
+	))
+	withTemplate := newDataSource("^(?P<id>object.*)$", "https://example.org/reads/{id}.cram")
+	withTemplate.ReferencePath = "https://example.org/refs/{id}.fa"
+	registry.addDataSource(withTemplate)
+	withStatic := newDataSource("^cohort\\.(?P<accession>.*)$", "/data/cohort/{accession}.cram")
+	withStatic.ReferencePath = "/refs/GRCh38.fa"
+	withStatic.Visas = []string{"https://dac.example.org/datasets/cohort"}
+	registry.addDataSource(withStatic)
+	withLocalTemplate := newDataSource("^local\\.(?P<accession>.*)$", "/data/local/{accession}.cram")
+	withLocalTemplate.ReferencePath = "/refs/local/{accession}.fa"
+	registry.addDataSource(withLocalTemplate)
+	return registry
+}
+
+// TestDataSourcesGetMatchingPath tests GetMatchingPath function
+func TestDataSourcesGetMatchingPath(t *testing.T) {
+	registry := datasourcesTestRegistry()
+	for _, tc := range datasourcesGetMatchingPathTC {
+		path, err := registry.GetMatchingPath(tc.id)
+		if tc.expErr {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, tc.exp, path)
+	}
+}
+
+// TestDataSourcesGetMatchingPathDRS tests drs:// paths are resolved by
+// GetMatchingPath through a stub DRS server
+func TestDataSourcesGetMatchingPathDRS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != drsutils.DRSAPIPath+"/objects/00001" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"00001","access_methods":[{"type":"s3","access_url":{"url":"s3://bucket/00001.bam"}}]}`))
+	}))
+	defer server.Close()
+	defaultResolver := drsResolver
+	drsResolver = drsutils.NewResolver()
+	drsResolver.Scheme = "http"
+	defer func() { drsResolver = defaultResolver }()
+
+	registry := newDataSourceRegistry()
+	registry.addDataSource(newDataSource(
+		"^drs\\.(?P<objectId>.*)$",
+		"drs://"+strings.TrimPrefix(server.URL, "http://")+"/{objectId}",
+	))
+	path, err := registry.GetMatchingPath("drs.00001")
+	assert.Nil(t, err)
+	assert.Equal(t, "s3://bucket/00001.bam", path)
+	path, err = registry.GetMatchingPath("drs.00002")
+	assert.NotNil(t, err)
+	assert.Equal(t, "", path)
+}
+
+// TestDataSourcesGetMatchingFilePath tests GetMatchingFilePath function
+func TestDataSourcesGetMatchingFilePath(t *testing.T) {
+	registry := datasourcesTestRegistry()
+	for _, tc := range datasourcesGetMatchingFilePathTC {
+		path, err := registry.GetMatchingFilePath(tc.id)
+		if tc.expErr {
+			assert.NotNil(t, err, tc.id)
+		} else {
+			assert.Nil(t, err, tc.id)
+		}
+		assert.Equal(t, tc.exp, path, tc.id)
+	}
+}
+
+// TestDataSourcesGetMatchingReferencePath tests GetMatchingReferencePath function
+func TestDataSourcesGetMatchingReferencePath(t *testing.T) {
+	registry := datasourcesTestRegistry()
+	for _, tc := range datasourcesGetMatchingReferencePathTC {
+		path, err := registry.GetMatchingReferencePath(tc.id)
+		if tc.expErr {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, tc.exp, path)
+	}
+}
+
+// TestDataSourcesGetMatchingVisas tests GetMatchingVisas function
+func TestDataSourcesGetMatchingVisas(t *testing.T) {
+	registry := datasourcesTestRegistry()
+	for _, tc := range datasourcesGetMatchingVisasTC {
+		visas, err := registry.GetMatchingVisas(tc.id)
+		if tc.expErr {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, tc.exp, visas)
+	}
+}
@@ -21,7 +21,7 @@ var defaultTagsParametersEffectiveReads = true
 
 var defaultEnabledVariants = true
 var defaultFieldsParameterEffectiveVariants = false
-var defaultTagsParametersEffectiveVariants = false
+var defaultTagsParametersEffectiveVariants = true
 
 var DefaultConfiguration = &Configuration{
 	Container: &configurationContainer{
@@ -31,12 +31,20 @@ var DefaultConfiguration = &Configuration{
 			DocsDir:              htsconstants.DfltServerPropsDocsDir,
 			TempDir:              htsconstants.DfltServerPropsTempDir,
 			LogFile:              htsconstants.DfltServerPropsLogFile,
+			LogLevel:             htsconstants.DfltServerPropsLogLevel,
 			CorsAllowedOrigins:   htsconstants.DfltCorsAllowedOrigins,
 			CorsAllowedMethods:   htsconstants.DfltCorsAllowedMethods,
 			CorsAllowedHeaders:   htsconstants.DfltCorsAllowedHeaders,
 			CorsAllowCredentials: &htsconstants.DfltCorsAllowCredentials,
 			CorsMaxAge:           htsconstants.DfltCorsMaxAge,
 			AwsAssumeRole:        &htsconstants.DfltAwsAssumeRole,
+			AwsPresignExpiry:     htsconstants.DfltAwsPresignExpiry,
+			GcsSignedURLExpiry:   htsconstants.DfltGcsSignedURLExpiry,
+			AzureSasExpiry:       htsconstants.DfltAzureSasExpiry,
+			DrsCacheTTL:          htsconstants.DfltDrsCacheTTL,
+			URLSigningKey:        htsconstants.DfltURLSigningKey,
+			URLExpiry:            htsconstants.DfltURLExpiry,
+			MetricsEnabled:       &htsconstants.DfltMetricsEnabled,
 		},
 		ReadsConfig: &configurationEndpoint{
 			Enabled: &defaultEnabledReads,
@@ -72,6 +80,7 @@ var DefaultConfiguration = &Configuration{
 					Formats:                  htsconstants.APIEndpointReadsTicket.AllowedFormats(),
 					FieldsParameterEffective: &defaultFieldsParameterEffectiveReads,
 					TagsParametersEffective:  &defaultTagsParametersEffectiveReads,
+					ReadFilterParameters:     htsconstants.HtsgetExtensionReadFilterParameters,
 				},
 			},
 		},
@@ -112,5 +121,12 @@ var DefaultConfiguration = &Configuration{
 				},
 			},
 		},
+		AuthConfig: &configurationAuth{
+			Enabled:       &htsconstants.DfltAuthEnabled,
+			JwksFile:      htsconstants.DfltAuthJwksFile,
+			IssuerKeyFile: htsconstants.DfltAuthIssuerKeyFile,
+			Issuer:        htsconstants.DfltAuthIssuer,
+			Audience:      htsconstants.DfltAuthAudience,
+		},
 	},
 }
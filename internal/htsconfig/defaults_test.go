@@ -17,20 +17,34 @@ func TestDefaults(t *testing.T) {
 	props := d.Container.ServerProps
 	reads := d.Container.ReadsConfig
 	variants := d.Container.VariantsConfig
+	auth := d.Container.AuthConfig
 
 	// SERVER PROPS
 	assert.Equal(t, props.Host, htsconstants.DfltServerPropsHost)
 	assert.Equal(t, props.Port, htsconstants.DfltServerPropsPort)
+	assert.Equal(t, props.LogLevel, htsconstants.DfltServerPropsLogLevel)
 
 	assert.Equal(t, props.CorsAllowedOrigins, htsconstants.DfltCorsAllowedOrigins)
 	assert.Equal(t, props.CorsAllowedMethods, htsconstants.DfltCorsAllowedMethods)
 	assert.Equal(t, props.CorsAllowedHeaders, htsconstants.DfltCorsAllowedHeaders)
 	assert.Equal(t, props.CorsAllowCredentials, &htsconstants.DfltCorsAllowCredentials)
 	assert.Equal(t, props.CorsMaxAge, htsconstants.DfltCorsMaxAge)
+	assert.Equal(t, props.URLSigningKey, htsconstants.DfltURLSigningKey)
+	assert.Equal(t, props.URLExpiry, htsconstants.DfltURLExpiry)
+	assert.Equal(t, props.MetricsEnabled, &htsconstants.DfltMetricsEnabled)
+	assert.Equal(t, props.AwsPresignExpiry, htsconstants.DfltAwsPresignExpiry)
+	assert.Equal(t, props.GcsSignedURLExpiry, htsconstants.DfltGcsSignedURLExpiry)
+	assert.Equal(t, props.AzureSasExpiry, htsconstants.DfltAzureSasExpiry)
+	assert.Equal(t, props.DrsCacheTTL, htsconstants.DfltDrsCacheTTL)
 
 	// READS DATA SOURCE REGISTRY
 	assert.Equal(t, *reads.Enabled, true)
 
 	// VARIANTS DATA SOURCE REGISTRY
 	assert.Equal(t, *variants.Enabled, true)
+
+	// AUTH
+	assert.Equal(t, *auth.Enabled, false)
+	assert.Equal(t, auth.JwksFile, htsconstants.DfltAuthJwksFile)
+	assert.Equal(t, auth.IssuerKeyFile, htsconstants.DfltAuthIssuerKeyFile)
 }
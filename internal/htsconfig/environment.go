@@ -0,0 +1,184 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module environment.go contains operations for overriding properties with
+// HTSGET_* environment variables and the matching command line flags
+package htsconfig
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
+)
+
+// envPrefix prefix of all environment variables overriding properties
+const envPrefix = "HTSGET_"
+
+// overridableProperty a property that can be set through an environment
+// variable or command line flag
+//
+// Attributes
+//	name (string): command line flag name, e.g. corsMaxAge
+//	envVar (string): environment variable name, e.g. HTSGET_CORS_MAX_AGE
+//	field (func(*Configuration) reflect.Value): gets the property's field in a configuration
+//	envOnly (bool): if true, the property has no command line flag
+type overridableProperty struct {
+	name    string
+	envVar  string
+	field   func(config *Configuration) reflect.Value
+	envOnly bool
+}
+
+// envOnlyProperties secret properties, which can't be set by command line
+// flags as the command line of the process is visible to other users
+var envOnlyProperties = []string{"urlSigningKey"}
+
+// splitFieldName splits a struct field name into its words, keeping
+// acronyms together, e.g. GcsSignedURLExpiry into Gcs, Signed, URL, Expiry
+func splitFieldName(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// newOverridableProperty names the property after its words
+func newOverridableProperty(words []string, field func(config *Configuration) reflect.Value) overridableProperty {
+	name := strings.ToLower(words[0])
+	for _, word := range words[1:] {
+		name += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+	return overridableProperty{
+		name:    name,
+		envVar:  envPrefix + strings.ToUpper(strings.Join(words, "_")),
+		field:   field,
+		envOnly: htsutils.IsItemInArray(name, envOnlyProperties),
+	}
+}
+
+// overridableProperties lists every server property, and the enabled
+// property of each endpoint
+func overridableProperties() []overridableProperty {
+	var properties []overridableProperty
+	propsType := reflect.TypeOf(configurationServerProps{})
+	for i := 0; i < propsType.NumField(); i++ {
+		index := i
+		properties = append(properties, newOverridableProperty(
+			splitFieldName(propsType.Field(i).Name),
+			func(config *Configuration) reflect.Value {
+				return reflect.ValueOf(config.Container.ServerProps).Elem().Field(index)
+			},
+		))
+	}
+	properties = append(properties,
+		newOverridableProperty([]string{"reads", "enabled"}, func(config *Configuration) reflect.Value {
+			return reflect.ValueOf(config.Container.ReadsConfig).Elem().FieldByName("Enabled")
+		}),
+		newOverridableProperty([]string{"variants", "enabled"}, func(config *Configuration) reflect.Value {
+			return reflect.ValueOf(config.Container.VariantsConfig).Elem().FieldByName("Enabled")
+		}),
+	)
+	return properties
+}
+
+// newOverrideConfiguration creates an empty configuration, to be populated
+// with overridden properties and patched onto the configuration
+func newOverrideConfiguration() *Configuration {
+	return &Configuration{
+		Container: &configurationContainer{
+			ServerProps:    new(configurationServerProps),
+			ReadsConfig:    new(configurationEndpoint),
+			VariantsConfig: new(configurationEndpoint),
+		},
+	}
+}
+
+// isBoolProperty checks if the property is a *bool field
+func (property overridableProperty) isBoolProperty() bool {
+	return property.field(newOverrideConfiguration()).Type().String() == "*bool"
+}
+
+// set parses the value into the property's field of the configuration
+//
+//	Type: overridableProperty
+// Arguments
+//	config (*Configuration): configuration to set the property in
+//	value (string): property value, as given in the environment or command line
+// Returns
+//	(error): if not nil, the value cannot be parsed for the property's type
+func (property overridableProperty) set(config *Configuration, value string) error {
+	field := property.field(config)
+	switch field.Type().String() {
+	case "string":
+		field.SetString(value)
+	case "int":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("invalid value '" + value + "' for " + property.name + ", expected an integer")
+		}
+		field.SetInt(int64(parsed))
+	case "*bool":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid value '" + value + "' for " + property.name + ", expected true or false")
+		}
+		field.Set(reflect.ValueOf(&parsed))
+	}
+	return nil
+}
+
+// getEnvConfiguration gets the properties set by HTSGET_* environment
+// variables
+//
+// Returns
+//	(*Configuration): configuration holding only the properties set in the environment
+//	(error): if not nil, an environment variable could not be parsed
+func getEnvConfiguration() (*Configuration, error) {
+	envConfiguration := newOverrideConfiguration()
+	for _, property := range overridableProperties() {
+		value, ok := os.LookupEnv(property.envVar)
+		if !ok {
+			continue
+		}
+		if err := property.set(envConfiguration, value); err != nil {
+			return nil, errors.New(property.envVar + ": " + err.Error())
+		}
+	}
+	return envConfiguration, nil
+}
+
+// isEnvSet checks if the property is set by its environment variable
+func isEnvSet(property overridableProperty) bool {
+	_, ok := os.LookupEnv(property.envVar)
+	return ok
+}
+
+// overrideConfiguration sets the properties set through the environment or
+// command line in the configuration. unlike patchConfiguration, zero values
+// (e.g. HTSGET_CORS_MAX_AGE=0) override the configuration, as it is known
+// which properties were set
+//
+// Arguments
+//	config (*Configuration): configuration to override properties of
+//	override (*Configuration): configuration holding the overriding properties
+//	isSet (func(overridableProperty) bool): checks if a property was set
+func overrideConfiguration(config *Configuration, override *Configuration, isSet func(property overridableProperty) bool) {
+	for _, property := range overridableProperties() {
+		if isSet(property) {
+			property.field(config).Set(property.field(override))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module environment_test tests module environment
+package htsconfig
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// environmentSplitFieldNameTC test cases for splitFieldName
+var environmentSplitFieldNameTC = []struct {
+	name string
+	exp  []string
+}{
+	{"Port", []string{"Port"}},
+	{"TempDir", []string{"Temp", "Dir"}},
+	{"URLSigningKey", []string{"URL", "Signing", "Key"}},
+	{"GcsSignedURLExpiry", []string{"Gcs", "Signed", "URL", "Expiry"}},
+	{"DrsCacheTTL", []string{"Drs", "Cache", "TTL"}},
+}
+
+// environmentOverridablePropertiesTC expected flag and environment variable
+// names of properties
+var environmentOverridablePropertiesTC = map[string]string{
+	"port":               "HTSGET_PORT",
+	"tempDir":            "HTSGET_TEMP_DIR",
+	"corsAllowedOrigins": "HTSGET_CORS_ALLOWED_ORIGINS",
+	"awsAssumeRole":      "HTSGET_AWS_ASSUME_ROLE",
+	"gcsSignedUrlExpiry": "HTSGET_GCS_SIGNED_URL_EXPIRY",
+	"urlSigningKey":      "HTSGET_URL_SIGNING_KEY",
+	"drsCacheTtl":        "HTSGET_DRS_CACHE_TTL",
+	"readsEnabled":       "HTSGET_READS_ENABLED",
+	"variantsEnabled":    "HTSGET_VARIANTS_ENABLED",
+}
+
+// setEnvironmentTestEnv sets environment variables for a test, returning a
+// function restoring them
+func setEnvironmentTestEnv(env map[string]string) func() {
+	for key, value := range env {
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key := range env {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestSplitFieldName(t *testing.T) {
+	for _, tc := range environmentSplitFieldNameTC {
+		assert.Equal(t, tc.exp, splitFieldName(tc.name))
+	}
+}
+
+func TestOverridableProperties(t *testing.T) {
+	properties := map[string]string{}
+	for _, property := range overridableProperties() {
+		properties[property.name] = property.envVar
+	}
+	for name, envVar := range environmentOverridablePropertiesTC {
+		assert.Equal(t, envVar, properties[name], name)
+	}
+	// every server property, and the enabled property of both endpoints
+	assert.Equal(t, len(properties), len(overridableProperties()))
+	assert.Equal(t, 21, len(properties))
+}
+
+func TestGetEnvConfiguration(t *testing.T) {
+	defer setEnvironmentTestEnv(map[string]string{
+		"HTSGET_PORT":             "4000",
+		"HTSGET_CORS_MAX_AGE":     "60",
+		"HTSGET_AWS_ASSUME_ROLE":  "true",
+		"HTSGET_VARIANTS_ENABLED": "false",
+	})()
+	envConfiguration, err := getEnvConfiguration()
+	assert.Nil(t, err)
+	props := envConfiguration.Container.ServerProps
+	assert.Equal(t, "4000", props.Port)
+	assert.Equal(t, 60, props.CorsMaxAge)
+	assert.Equal(t, true, *props.AwsAssumeRole)
+	assert.Equal(t, "", props.Host)
+	assert.Nil(t, props.CorsAllowCredentials)
+	assert.Nil(t, envConfiguration.Container.ReadsConfig.Enabled)
+	assert.Equal(t, false, *envConfiguration.Container.VariantsConfig.Enabled)
+
+	defer setEnvironmentTestEnv(map[string]string{"HTSGET_URL_EXPIRY": "1h"})()
+	_, err = getEnvConfiguration()
+	assert.EqualError(t, err, "HTSGET_URL_EXPIRY: invalid value '1h' for urlExpiry, expected an integer")
+}
+
+// TestBuildConfigurationPrecedence tests command line flags take precedence
+// over environment variables, which take precedence over the config file
+func TestBuildConfigurationPrecedence(t *testing.T) {
+	cliConfiguration, cliSetProperties := getCliArgs().configuration, getCliArgs().setProperties
+	defer func() {
+		getCliArgs().configuration, getCliArgs().setProperties = cliConfiguration, cliSetProperties
+	}()
+	getCliArgs().configuration = newOverrideConfiguration()
+	getCliArgs().configuration.Container.ServerProps.Port = "5000"
+	getCliArgs().setProperties = map[string]bool{"port": true}
+
+	defer setEnvironmentTestEnv(map[string]string{
+		"HTSGET_PORT":         "4000",
+		"HTSGET_CORS_MAX_AGE": "60",
+		"HTSGET_LOG_FILE":     "env.log",
+	})()
+	configFile := newOverrideConfiguration()
+	configFile.Container.ServerProps.Port = "3001"
+	configFile.Container.ServerProps.CorsMaxAge = 120
+	configFile.Container.ServerProps.TempDir = "/tmp/file"
+
+	props := buildConfiguration(configFile).Container.ServerProps
+	assert.Equal(t, "5000", props.Port)
+	assert.Equal(t, 60, props.CorsMaxAge)
+	assert.Equal(t, "env.log", props.LogFile)
+	assert.Equal(t, "/tmp/file", props.TempDir)
+	assert.Equal(t, DefaultConfiguration.Container.ServerProps.Host, props.Host)
+
+	// zero values set explicitly override the config file
+	defer setEnvironmentTestEnv(map[string]string{"HTSGET_CORS_MAX_AGE": "0"})()
+	getCliArgs().configuration.Container.ServerProps.URLExpiry = 0
+	getCliArgs().setProperties["urlExpiry"] = true
+	configFile.Container.ServerProps.URLExpiry = 600
+	props = buildConfiguration(configFile).Container.ServerProps
+	assert.Equal(t, 0, props.CorsMaxAge)
+	assert.Equal(t, 0, props.URLExpiry)
+}
+
+// TestEnvOnlyProperties tests secrets can't be set by command line flags
+func TestEnvOnlyProperties(t *testing.T) {
+	for _, property := range overridableProperties() {
+		assert.Equal(t, property.name == "urlSigningKey", property.envOnly, property.name)
+	}
+	getCliArgs()
+	assert.Nil(t, flag.Lookup("urlSigningKey"))
+	assert.NotNil(t, flag.Lookup("urlExpiry"))
+}
@@ -0,0 +1,107 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module reload.go reloads the configuration while the server is running,
+// when the config file changes or the process receives SIGHUP
+package htsconfig
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
+)
+
+// configWatchInterval how often the config file is checked for changes
+var configWatchInterval = 2 * time.Second
+
+// reloadConfigFrom reads the config file, and swaps it in for the current
+// configuration if valid. the current configuration is kept otherwise
+//
+// Arguments
+//	filePath (string): path to the JSON config file
+// Returns
+//	(error): if not nil, the config file was rejected
+func reloadConfigFrom(filePath string) error {
+	configFile, err := readConfigFile(filePath)
+	if err != nil {
+		return err
+	}
+	newConfiguration := buildConfiguration(configFile)
+	if problems := validateConfiguration(newConfiguration); len(problems) > 0 {
+		return problemsError(problems)
+	}
+	SetConfigFile(configFile)
+	SetConfig(newConfiguration)
+	return nil
+}
+
+// ReloadConfig reloads the config file passed on the command line. if the
+// file is invalid, the current configuration is kept
+//
+// Returns
+//	(error): if not nil, the config file was rejected
+func ReloadConfig() error {
+	return reloadConfigFrom(getCliArgs().configFile)
+}
+
+// configFileVersion identifies a version of the config file by its
+// modification time and size
+func configFileVersion(filePath string) string {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return ""
+	}
+	return info.ModTime().String() + "/" + strconv.FormatInt(info.Size(), 10)
+}
+
+// watchConfig reloads the config file whenever a signal is received on
+// reload, or the file changes, until stop is closed
+//
+// Arguments
+//	filePath (string): path to the JSON config file
+//	interval (time.Duration): how often the file is checked for changes
+//	reload (<-chan os.Signal): signals requesting a reload
+//	stop (<-chan struct{}): closed to stop watching
+func watchConfig(filePath string, interval time.Duration, reload <-chan os.Signal, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	version := configFileVersion(filePath)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-reload:
+		case <-ticker.C:
+			if current := configFileVersion(filePath); current == version || current == "" {
+				continue
+			}
+		}
+		version = configFileVersion(filePath)
+		if err := reloadConfigFrom(filePath); err != nil {
+			htslog.Warn("rejected configuration, keeping the current configuration", htslog.Fields{"configFile": filePath, "error": err.Error()})
+		} else {
+			htslog.Info("reloaded configuration", htslog.Fields{"configFile": filePath})
+		}
+	}
+}
+
+// WatchConfig reloads the config file passed on the command line on SIGHUP,
+// and when the file changes. invalid config files are logged and rejected
+//
+// Returns
+//	(func()): stops watching
+func WatchConfig() func() {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	stop := make(chan struct{})
+	go watchConfig(getCliArgs().configFile, configWatchInterval, reload, stop)
+	return func() {
+		signal.Stop(reload)
+		close(stop)
+	}
+}
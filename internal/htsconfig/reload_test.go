@@ -0,0 +1,106 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module reload_test tests module reload
+package htsconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// reloadTestConfig config file with a single reads data source
+const reloadTestConfig = `{
+  "htsgetConfig": {
+    "props": {"corsAllowedOrigins": "https://a.example.org"},
+    "reads": {
+      "dataSourceRegistry": {
+        "sources": [{"pattern": "^(?P<id>.*)$", "path": "%s/{id}.bam"}]
+      }
+    }
+  }
+}`
+
+// writeReloadTestConfig writes the content to the config file
+func writeReloadTestConfig(t *testing.T, filePath string, content string) {
+	if err := ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resetReloadTestConfig restores the default configuration after a test
+func resetReloadTestConfig() {
+	SetConfigFile(DefaultConfiguration)
+	SetConfig(DefaultConfiguration)
+}
+
+func TestReloadConfigFrom(t *testing.T) {
+	GetConfig()
+	defer resetReloadTestConfig()
+	dir, _ := ioutil.TempDir("", "htsget-reload")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "config.json")
+
+	writeReloadTestConfig(t, filePath, fmt.Sprintf(reloadTestConfig, "/data/a"))
+	assert.Nil(t, reloadConfigFrom(filePath))
+	previous := GetDataSourceRegistry(htsconstants.APIEndpointReadsTicket)
+	path, _ := previous.GetMatchingPath("object")
+	assert.Equal(t, "/data/a/object.bam", path)
+	assert.Equal(t, "https://a.example.org", GetCorsAllowedOrigins())
+
+	// the new registry is swapped in, registries held by in-flight requests
+	// are unchanged
+	writeReloadTestConfig(t, filePath, fmt.Sprintf(reloadTestConfig, "/data/b"))
+	assert.Nil(t, reloadConfigFrom(filePath))
+	path, _ = GetObjectPath(htsconstants.APIEndpointReadsTicket, "object")
+	assert.Equal(t, "/data/b/object.bam", path)
+	path, _ = previous.GetMatchingPath("object")
+	assert.Equal(t, "/data/a/object.bam", path)
+
+	// invalid config files are rejected, keeping the current configuration
+	for _, invalid := range []string{
+		`{"htsgetConfig": {`,
+		`{"htsgetConfig": {"reads": {"dataSourceRegistry": {"sources": [{"pattern": "^(?P<id>.*$", "path": "/data/c/{id}.bam"}]}}}}`,
+	} {
+		writeReloadTestConfig(t, filePath, invalid)
+		assert.NotNil(t, reloadConfigFrom(filePath))
+		path, _ = GetObjectPath(htsconstants.APIEndpointReadsTicket, "object")
+		assert.Equal(t, "/data/b/object.bam", path)
+	}
+	assert.NotNil(t, reloadConfigFrom(filepath.Join(dir, "missing.json")))
+}
+
+func TestWatchConfig(t *testing.T) {
+	GetConfig()
+	defer resetReloadTestConfig()
+	dir, _ := ioutil.TempDir("", "htsget-reload")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "config.json")
+	writeReloadTestConfig(t, filePath, fmt.Sprintf(reloadTestConfig, "/data/a"))
+
+	reload := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go watchConfig(filePath, 10*time.Millisecond, reload, stop)
+
+	// reloads can be requested without a change to the file
+	reload <- os.Interrupt
+	assert.Eventually(t, func() bool {
+		path, _ := GetObjectPath(htsconstants.APIEndpointReadsTicket, "object")
+		return path == "/data/a/object.bam"
+	}, time.Second, 10*time.Millisecond)
+
+	// changes to the file are picked up
+	writeReloadTestConfig(t, filePath, fmt.Sprintf(reloadTestConfig, "/data/changed"))
+	assert.Eventually(t, func() bool {
+		path, _ := GetObjectPath(htsconstants.APIEndpointReadsTicket, "object")
+		return path == "/data/changed/object.bam"
+	}, time.Second, 10*time.Millisecond)
+}
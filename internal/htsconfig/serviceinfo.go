@@ -1,34 +1,35 @@
 package htsconfig
 
 type ServiceInfo struct {
-	ID               string           `json:"id"`
-	Name             string           `json:"name"`
-	Type             *ServiceType     `json:"type"`
-	Description      string           `json:"description"`
-	Organization     *Organization    `json:"organization"`
-	ContactURL       string           `json:"contactUrl"`
-	DocumentationURL string           `json:"documentationUrl"`
-	CreatedAt        string           `json:"createdAt"`
-	UpdatedAt        string           `json:"updatedAt"`
-	Environment      string           `json:"environment"`
-	Version          string           `json:"version"`
-	HtsgetExtension  *HtsgetExtension `json:"htsget"`
+	ID               string           `json:"id" yaml:"id" toml:"id"`
+	Name             string           `json:"name" yaml:"name" toml:"name"`
+	Type             *ServiceType     `json:"type" yaml:"type" toml:"type"`
+	Description      string           `json:"description" yaml:"description" toml:"description"`
+	Organization     *Organization    `json:"organization" yaml:"organization" toml:"organization"`
+	ContactURL       string           `json:"contactUrl" yaml:"contactUrl" toml:"contactUrl"`
+	DocumentationURL string           `json:"documentationUrl" yaml:"documentationUrl" toml:"documentationUrl"`
+	CreatedAt        string           `json:"createdAt" yaml:"createdAt" toml:"createdAt"`
+	UpdatedAt        string           `json:"updatedAt" yaml:"updatedAt" toml:"updatedAt"`
+	Environment      string           `json:"environment" yaml:"environment" toml:"environment"`
+	Version          string           `json:"version" yaml:"version" toml:"version"`
+	HtsgetExtension  *HtsgetExtension `json:"htsget" yaml:"htsget" toml:"htsget"`
 }
 
 type ServiceType struct {
-	Group    string `json:"group"`
-	Artifact string `json:"artifact"`
-	Version  string `json:"version"`
+	Group    string `json:"group" yaml:"group" toml:"group"`
+	Artifact string `json:"artifact" yaml:"artifact" toml:"artifact"`
+	Version  string `json:"version" yaml:"version" toml:"version"`
 }
 
 type Organization struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name string `json:"name" yaml:"name" toml:"name"`
+	URL  string `json:"url" yaml:"url" toml:"url"`
 }
 
 type HtsgetExtension struct {
-	Datatype                 string   `json:"datatype"`
-	Formats                  []string `json:"formats"`
-	FieldsParameterEffective *bool    `json:"fieldsParameterEffective"`
-	TagsParametersEffective  *bool    `json:"tagsParametersEffective"`
+	Datatype                 string   `json:"datatype" yaml:"datatype" toml:"datatype"`
+	Formats                  []string `json:"formats" yaml:"formats" toml:"formats"`
+	FieldsParameterEffective *bool    `json:"fieldsParameterEffective" yaml:"fieldsParameterEffective" toml:"fieldsParameterEffective"`
+	TagsParametersEffective  *bool    `json:"tagsParametersEffective" yaml:"tagsParametersEffective" toml:"tagsParametersEffective"`
+	ReadFilterParameters     []string `json:"readFilterParameters,omitempty" yaml:"readFilterParameters" toml:"readFilterParameters"`
 }
@@ -0,0 +1,197 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module validate.go checks configurations for problems that would otherwise
+// be ignored, or only surface while serving requests
+package htsconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
+)
+
+// validateDataSourceRegistry checks every data source in the registry can be
+// used to resolve ids
+//
+// Arguments
+//	name (string): name of the endpoint the registry belongs to, for errors
+//	registry (*DataSourceRegistry): registry to validate
+// Returns
+//	([]error): problems found with the data sources, empty if valid
+func validateDataSourceRegistry(name string, registry *DataSourceRegistry) []error {
+	if registry == nil {
+		return []error{errors.New(name + " dataSourceRegistry is missing")}
+	}
+	var problems []error
+	for i, dataSource := range registry.Sources {
+		source := name + " data source " + strconv.Itoa(i)
+		if dataSource.Path == "" {
+			problems = append(problems, errors.New(source+" has no path"))
+		}
+		pattern, err := regexp.Compile(dataSource.Pattern)
+		if err != nil {
+			problems = append(problems, errors.New(source+" pattern '"+dataSource.Pattern+"' is invalid: "+err.Error()))
+			continue
+		}
+
+		// every placeholder must be populated by a named group of the pattern
+		groups := map[string]bool{}
+		for _, group := range pattern.SubexpNames() {
+			groups[group] = true
+		}
+		for _, template := range []string{dataSource.Path, dataSource.ReferencePath} {
+			placeholders, _ := htsutils.CreateRegexNamedParameterMap("\\{(?P<paramName>.+?)\\}", template)
+			for _, placeholder := range placeholders["paramName"] {
+				if !groups[placeholder] {
+					problems = append(problems, errors.New(source+" template '"+template+"' uses {"+placeholder+"}, which is not a named group of pattern '"+dataSource.Pattern+"'"))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// validateConfiguration checks a configuration can be used to serve requests
+//
+// Arguments
+//	config (*Configuration): configuration to validate
+// Returns
+//	([]error): problems found with the configuration, empty if valid
+func validateConfiguration(config *Configuration) []error {
+	container := config.Container
+	if container == nil || container.ServerProps == nil || container.ReadsConfig == nil || container.VariantsConfig == nil {
+		return []error{errors.New("configuration is incomplete")}
+	}
+	var problems []error
+	if port, err := strconv.Atoi(container.ServerProps.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, errors.New("props port '"+container.ServerProps.Port+"' is not a valid port number"))
+	}
+	if _, err := htslog.ParseLevel(container.ServerProps.LogLevel); err != nil {
+		problems = append(problems, errors.New("props logLevel: "+err.Error()))
+	}
+	problems = append(problems, validateDataSourceRegistry("reads", container.ReadsConfig.DataSourceRegistry)...)
+	problems = append(problems, validateDataSourceRegistry("variants", container.VariantsConfig.DataSourceRegistry)...)
+	return problems
+}
+
+// fieldName gets the name a struct field is decoded from in the config file
+// format, empty if the field is not decoded
+func fieldName(field reflect.StructField, format string) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get(format), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return field.Name
+	}
+	return tag
+}
+
+// unknownFields lists the keys of the decoded config file value with no
+// matching field in the type. JSON and TOML keys are matched
+// case-insensitively, as their decoders do
+//
+// Arguments
+//	value (interface{}): generically decoded config file value
+//	t (reflect.Type): type the value is decoded into
+//	path (string): location of the value in the document, prefixed to unknown keys
+//	format (string): config file format
+// Returns
+//	([]string): locations of the unknown keys, sorted by key within each object
+func unknownFields(value interface{}, t reflect.Type, path string, format string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var matched *reflect.StructField
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				name := fieldName(field, format)
+				if name == key || (name != "" && format != configFormatYAML && strings.EqualFold(name, key)) {
+					matched = &field
+					break
+				}
+			}
+			location := strings.TrimPrefix(path+"."+key, ".")
+			if matched == nil {
+				unknown = append(unknown, location)
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], matched.Type, location, format)...)
+		}
+	case reflect.Slice:
+		// TOML arrays of tables are decoded as []map[string]interface{}
+		array := reflect.ValueOf(value)
+		if array.Kind() != reflect.Slice {
+			return nil
+		}
+		for i := 0; i < array.Len(); i++ {
+			unknown = append(unknown, unknownFields(array.Index(i).Interface(), t.Elem(), path+"["+strconv.Itoa(i)+"]", format)...)
+		}
+	}
+	return unknown
+}
+
+// ValidateConfigFile strictly checks the config file, reporting unknown
+// properties, invalid data source patterns, and path templates that cannot
+// be populated from their pattern. properties overridden by environment
+// variables are checked as well, command line flags are not
+//
+// Arguments
+//	filePath (string): path to the JSON, YAML or TOML config file
+// Returns
+//	([]error): all problems found with the config file, empty if valid
+func ValidateConfigFile(filePath string) []error {
+	configFile, err := readConfigFile(filePath)
+	if err != nil {
+		return []error{err}
+	}
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return []error{err}
+	}
+	format := configFileFormat(filePath)
+	var document interface{}
+	decodeConfigFile(format, content, &document)
+
+	var problems []error
+	if _, err := getEnvConfiguration(); err != nil {
+		problems = append(problems, err)
+	}
+	for _, location := range unknownFields(document, reflect.TypeOf(configFile), "", format) {
+		problems = append(problems, errors.New("unknown property "+location))
+	}
+	return append(problems, validateConfiguration(buildConfiguration(configFile))...)
+}
+
+// problemsError combines configuration problems into a single error
+func problemsError(problems []error) error {
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
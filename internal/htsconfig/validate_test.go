@@ -0,0 +1,143 @@
+// Package htsconfig allows the program to be configured with modifiable
+// properties, affecting runtime properties. also contains program constants
+//
+// Module validate_test tests module validate
+package htsconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validateDataSourceRegistryTC test cases for validateDataSourceRegistry
+var validateDataSourceRegistryTC = []struct {
+	pattern       string
+	path          string
+	referencePath string
+	expProblems   int
+}{
+	{"^(?P<id>.*)$", "/data/{id}.bam", "", 0},
+	{"^(?P<id>.*)$", "/data/{id}.cram", "/refs/{id}.fa", 0},
+	{"^(?P<id>.*)$", "/data/{id}.cram", "/refs/GRCh38.fa", 0},
+	{"^(?P<id>.*$", "/data/{id}.bam", "", 1},
+	{"^(?P<id>.*)$", "", "", 1},
+	{"^(?P<id>.*)$", "/data/{accession}.bam", "", 1},
+	{"^(?P<id>.*)$", "/data/{accession}.cram", "/refs/{accession}.fa", 2},
+}
+
+// validateConfigFileTC test cases for ValidateConfigFile
+var validateConfigFileTC = []struct {
+	content     string
+	expProblems []string
+}{
+	{
+		`{"htsgetConfig": {"props": {"port": "3000", "tempDir": "/tmp"}}}`,
+		nil,
+	},
+	{
+		`{"htsgetConfig": {"props": {"port": "3000", "corsOrigins": "*"}, "variants": {"serviceinfo": {"organisation": {}}}}}`,
+		[]string{
+			"unknown property htsgetConfig.props.corsOrigins",
+			"unknown property htsgetConfig.variants.serviceinfo.organisation",
+		},
+	},
+	{
+		`{"htsgetConfig": {"reads": {"dataSourceRegistry": {"sources": [
+			{"pattern": "^(?P<id>.*)$", "path": "/data/{id}.bam", "visa": "x"},
+			{"pattern": "^(?P<id>.*$", "path": "/data/{id}.bam"},
+			{"pattern": "^(?P<id>.*)$", "path": "/data/{name}.bam"}
+		]}}}}`,
+		[]string{
+			"unknown property htsgetConfig.reads.dataSourceRegistry.sources[0].visa",
+			"reads data source 1 pattern '^(?P<id>.*$' is invalid: error parsing regexp: missing closing ): `^(?P<id>.*$`",
+			"reads data source 2 template '/data/{name}.bam' uses {name}, which is not a named group of pattern '^(?P<id>.*)$'",
+		},
+	},
+	{
+		`{"htsgetConfig": {"props": {"port": "http"}}`,
+		[]string{"json: line 1: unexpected end of JSON input"},
+	},
+	{
+		`{"htsgetConfig": {"props": {"port": "http"}}}`,
+		[]string{"props port 'http' is not a valid port number"},
+	},
+	{
+		`{"htsgetConfig": {"props": {"logLevel": "verbose"}}}`,
+		[]string{"props logLevel: unknown log level 'verbose', expected debug, info, warn or error"},
+	},
+}
+
+func TestValidateDataSourceRegistry(t *testing.T) {
+	for _, tc := range validateDataSourceRegistryTC {
+		registry := newDataSourceRegistry()
+		dataSource := newDataSource(tc.pattern, tc.path)
+		dataSource.ReferencePath = tc.referencePath
+		registry.addDataSource(dataSource)
+		assert.Equal(t, tc.expProblems, len(validateDataSourceRegistry("reads", registry)), tc)
+	}
+	assert.Equal(t, 1, len(validateDataSourceRegistry("reads", nil)))
+	assert.Empty(t, validateConfiguration(DefaultConfiguration))
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsget-validate")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "config.json")
+
+	for _, tc := range validateConfigFileTC {
+		ioutil.WriteFile(filePath, []byte(tc.content), 0644)
+		var problems []string
+		for _, problem := range ValidateConfigFile(filePath) {
+			problems = append(problems, problem.Error())
+		}
+		assert.Equal(t, tc.expProblems, problems, tc.content)
+	}
+	assert.Equal(t, 1, len(ValidateConfigFile(filepath.Join(dir, "missing.json"))))
+
+	// YAML keys are case sensitive, TOML arrays of tables are checked
+	yamlPath := filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(yamlPath, []byte("htsgetConfig:\n  props:\n    tempdir: /tmp\n"), 0644)
+	assert.Equal(t, []error{errors.New("unknown property htsgetConfig.props.tempdir")}, ValidateConfigFile(yamlPath))
+	tomlPath := filepath.Join(dir, "config.toml")
+	ioutil.WriteFile(tomlPath, []byte("[[htsgetConfig.reads.dataSourceRegistry.sources]]\npatern = '^(?P<id>.*)$'\npath = '/data/{id}.bam'\n"), 0644)
+	assert.Equal(t, []error{
+		errors.New("unknown property htsgetConfig.reads.dataSourceRegistry.sources[0].patern"),
+		errors.New("reads data source 0 template '/data/{id}.bam' uses {id}, which is not a named group of pattern ''"),
+	}, ValidateConfigFile(tomlPath))
+
+	// the config files distributed with the server are valid
+	for _, example := range []string{
+		"../../data/config/example-0.config.json",
+		"../../data/config/integration-tests.config.json",
+		"../../deployments/ga4gh/prod/config-server.json",
+	} {
+		assert.Empty(t, ValidateConfigFile(example), example)
+	}
+}
+
+// TestLoadConfigValidates tests LoadConfig reports invalid configurations
+func TestLoadConfigValidates(t *testing.T) {
+	configFileLoaded, configFileLoadError := configFileSingletonLoaded, configFileSingletonLoadedError
+	configFileSingletonLoaded, configFileSingletonLoadedError = true, nil
+	defer func() {
+		configFileSingletonLoaded, configFileSingletonLoadedError = configFileLoaded, configFileLoadError
+	}()
+	defer SetConfig(DefaultConfiguration)
+	defer SetConfigFile(DefaultConfiguration)
+
+	config := new(Configuration)
+	json.Unmarshal([]byte(`{"htsgetConfig": {"props": {"port": "99999"}}}`), config)
+	SetConfigFile(config)
+	LoadConfig()
+	assert.EqualError(t, GetConfigLoadError(), "props port '99999' is not a valid port number")
+
+	SetConfigFile(DefaultConfiguration)
+	LoadConfig()
+	assert.Nil(t, GetConfigLoadError())
+}
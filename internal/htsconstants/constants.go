@@ -63,15 +63,18 @@ var BamEOF, _ = hex.DecodeString("1f8b08040000000000ff0600424302001b000300000000
 // BamEOFLen length (number of bytes) of BAM end of file byte sequence
 var BamEOFLen = len(BamEOF)
 
+// CramEOF CRAM (v3.x) end of file container byte sequence
+var CramEOF, _ = hex.DecodeString("0f000000ffffffff0fe0454f4600000000010005bdd94f0001000606010001000100ee63014b")
+
+// CramEOFLen length (number of bytes) of CRAM end of file container byte sequence
+var CramEOFLen = len(CramEOF)
+
 // ReadsDataURLPath path to reads data endpoint
 var ReadsDataURLPath = "reads/data/"
 
 // VariantsDataURLPath path to variants data endpoint
 var VariantsDataURLPath = "variants/data/"
 
-// FileByteRangeURLPath path to local file bytestream endpoint
-var FileByteRangeURLPath = "file-bytes"
-
 // FormatBam canonical htsget format string for .bam files
 var FormatBam = "BAM"
 
@@ -14,7 +14,8 @@ const (
 	APIEndpointVariantsTicket      APIEndpoint = 3
 	APIEndpointVariantsData        APIEndpoint = 4
 	APIEndpointVariantsServiceInfo APIEndpoint = 5
-	APIEndpointFileBytes           APIEndpoint = 6
+	APIEndpointReadsFileBytes      APIEndpoint = 6
+	APIEndpointVariantsFileBytes   APIEndpoint = 7
 )
 
 // maps enum int values to string representation
@@ -25,7 +26,8 @@ var htsEndpointStringMap = map[APIEndpoint]string{
 	APIEndpointVariantsTicket:      "/variants/{id}*",
 	APIEndpointVariantsData:        "/variants/data/{id}*",
 	APIEndpointVariantsServiceInfo: "/variants/service-info",
-	APIEndpointFileBytes:           "/file-bytes",
+	APIEndpointReadsFileBytes:      "/reads/file-bytes/{id}*",
+	APIEndpointVariantsFileBytes:   "/variants/file-bytes/{id}*",
 }
 
 // maps ticket endpoints to their corresponding data endpoint prefixes
@@ -34,12 +36,18 @@ var ticketEndpointToDataEndpointPathMap = map[APIEndpoint]string{
 	APIEndpointVariantsTicket: "/variants/data/",
 }
 
+// maps ticket endpoints to their corresponding file bytes endpoint prefixes
+var ticketEndpointToFileBytesEndpointPathMap = map[APIEndpoint]string{
+	APIEndpointReadsTicket:    "/reads/file-bytes/",
+	APIEndpointVariantsTicket: "/variants/file-bytes/",
+}
+
 // maps endpoints to allowed format values
 var endpointToEnabledFormatsMap = map[APIEndpoint][]string{
-	APIEndpointReadsTicket:    []string{FormatBam /*, FormatCram */},
-	APIEndpointReadsData:      []string{FormatBam /*, FormatCram */},
-	APIEndpointVariantsTicket: []string{FormatVcf /*, FormatBcf */},
-	APIEndpointVariantsData:   []string{FormatVcf /*, FormatBcf */},
+	APIEndpointReadsTicket:    []string{FormatBam, FormatCram},
+	APIEndpointReadsData:      []string{FormatBam, FormatCram},
+	APIEndpointVariantsTicket: []string{FormatVcf, FormatBcf},
+	APIEndpointVariantsData:   []string{FormatVcf, FormatBcf},
 }
 
 // String gets the string representation of a ServerEndpoint enum value
@@ -53,6 +61,12 @@ func (e APIEndpoint) DataEndpointPath() string {
 	return ticketEndpointToDataEndpointPathMap[e]
 }
 
+// FileBytesEndpointPath gets the corresponding file bytes endpoint prefix for
+// a given ticket APIEndpoint
+func (e APIEndpoint) FileBytesEndpointPath() string {
+	return ticketEndpointToFileBytesEndpointPathMap[e]
+}
+
 // AllowedFormats gets the acceptable requested formats based on the API Endpoint
 func (e APIEndpoint) AllowedFormats() []string {
 	return endpointToEnabledFormatsMap[e]
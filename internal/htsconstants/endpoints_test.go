@@ -17,7 +17,8 @@ var endpointsStringTC = []struct {
 	{APIEndpointReadsTicket, "/reads/{id}*"},
 	{APIEndpointReadsData, "/reads/data/{id}*"},
 	{APIEndpointVariantsServiceInfo, "/variants/service-info"},
-	{APIEndpointFileBytes, "/file-bytes"},
+	{APIEndpointReadsFileBytes, "/reads/file-bytes/{id}*"},
+	{APIEndpointVariantsFileBytes, "/variants/file-bytes/{id}*"},
 }
 
 // endpointsDataEndpointPathTC test cases for DataEndpointPath
@@ -29,15 +30,24 @@ var endpointsDataEndpointPathTC = []struct {
 	{APIEndpointVariantsTicket, "/variants/data/"},
 }
 
+// endpointsFileBytesEndpointPathTC test cases for FileBytesEndpointPath
+var endpointsFileBytesEndpointPathTC = []struct {
+	e   APIEndpoint
+	exp string
+}{
+	{APIEndpointReadsTicket, "/reads/file-bytes/"},
+	{APIEndpointVariantsTicket, "/variants/file-bytes/"},
+}
+
 // endpointsAllowedFormatsTC test cases for AllowedFormats
 var endpointsAllowedFormatsTC = []struct {
 	e   APIEndpoint
 	exp []string
 }{
-	{APIEndpointReadsTicket, []string{"BAM"}},
-	{APIEndpointReadsData, []string{"BAM"}},
-	{APIEndpointVariantsTicket, []string{"VCF"}},
-	{APIEndpointVariantsData, []string{"VCF"}},
+	{APIEndpointReadsTicket, []string{"BAM", "CRAM"}},
+	{APIEndpointReadsData, []string{"BAM", "CRAM"}},
+	{APIEndpointVariantsTicket, []string{"VCF", "BCF"}},
+	{APIEndpointVariantsData, []string{"VCF", "BCF"}},
 }
 
 // TestEndpointsString tests String function
@@ -54,6 +64,13 @@ func TestEndpointsDataEndpointPath(t *testing.T) {
 	}
 }
 
+// TestEndpointsFileBytesEndpointPath tests FileBytesEndpointPath function
+func TestEndpointsFileBytesEndpointPath(t *testing.T) {
+	for _, tc := range endpointsFileBytesEndpointPathTC {
+		assert.Equal(t, tc.exp, tc.e.FileBytesEndpointPath())
+	}
+}
+
 // TestEndpointsAllowedFormats tests AllowedFormats function
 func TestEndpointsAllowedFormats(t *testing.T) {
 	for _, tc := range endpointsAllowedFormatsTC {
@@ -22,6 +22,9 @@ var DfltServerPropsTempDir = "."
 // DfltServerPropsLogFile default logfile to write logs
 var DfltServerPropsLogFile = "htsget-refserver.log"
 
+// DfltServerPropsLogLevel default minimum level of log entries written
+var DfltServerPropsLogLevel = "info"
+
 // Only allow client from origin http://localhost by default
 var DfltCorsAllowedOrigins = "http://localhost"
 
@@ -37,6 +40,46 @@ var DfltCorsMaxAge = 300
 
 var DfltAwsAssumeRole = false
 
+// DfltAwsPresignExpiry default number of seconds presigned S3 urls remain valid for
+var DfltAwsPresignExpiry = 3600
+
+// DfltGcsSignedURLExpiry default number of seconds signed GCS urls remain valid for
+var DfltGcsSignedURLExpiry = 3600
+
+// DfltAzureSasExpiry default number of seconds SAS-signed Azure urls remain valid for
+var DfltAzureSasExpiry = 3600
+
+// DfltDrsCacheTTL default number of seconds DRS resolutions are cached for
+var DfltDrsCacheTTL = 300
+
+// DfltURLSigningKey ticket urls are unsigned by default
+var DfltURLSigningKey = ""
+
+// DfltURLExpiry default number of seconds signed ticket urls remain valid for
+var DfltURLExpiry = 3600
+
+// DfltMetricsEnabled metrics are not served by default
+var DfltMetricsEnabled = false
+
+/* **************************************************
+ * AUTH
+ * ************************************************** */
+
+// DfltAuthEnabled data is served without authentication by default
+var DfltAuthEnabled = false
+
+// DfltAuthJwksFile default path to the JWKS file of trusted token signing keys
+var DfltAuthJwksFile = ""
+
+// DfltAuthIssuerKeyFile default path to the PEM encoded token issuer key
+var DfltAuthIssuerKeyFile = ""
+
+// DfltAuthIssuer tokens from any issuer are accepted by default
+var DfltAuthIssuer = ""
+
+// DfltAuthAudience tokens for any audience are accepted by default
+var DfltAuthAudience = ""
+
 /* **************************************************
  * READS DATA SOURCE REGISTRY
  * ************************************************** */
@@ -79,3 +79,7 @@ var HtsgetExtensionDatatypeReads = "reads"
 
 // HtsgetExtensionDatatypeVariants datatype keyword for variants API
 var HtsgetExtensionDatatypeVariants = "variants"
+
+// HtsgetExtensionReadFilterParameters read filtering parameters supported by
+// the reads API, in addition to those of the htsget protocol
+var HtsgetExtensionReadFilterParameters = []string{"minMappingQuality", "requiredFlags", "excludedFlags", "readGroups", "library"}
@@ -0,0 +1,84 @@
+package htsdao
+
+import (
+	"io"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
+)
+
+type AzureDao struct {
+	id  string
+	url string
+}
+
+// NewAzureDao instantiates a dao for an az:// path or Azure blob url. byte
+// range urls carry a read-only SAS token if an account key is available
+func NewAzureDao(id string, url string) *AzureDao {
+	dao := new(AzureDao)
+	dao.id = id
+	dao.url = url
+	return dao
+}
+
+func (dao *AzureDao) azureDto(url string) azureutils.AzureDto {
+	return azureutils.AzureDto{
+		ObjPath: url,
+	}
+}
+
+func (dao *AzureDao) GetContentLength() (int64, error) {
+	return azureutils.GetAzureBlobSize(dao.azureDto(dao.url))
+}
+
+func (dao *AzureDao) GetByteRangeUrls() ([]*htsticket.URL, error) {
+	contentLength, err := dao.GetContentLength()
+	if err != nil {
+		return nil, err
+	}
+	return byteRangeURLs(contentLength, dao.GetByteRangeURL)
+}
+
+func (dao *AzureDao) GetByteRangeURL(start int64, end int64) (*htsticket.URL, error) {
+	blobURL, err := dao.blobURL()
+	if err != nil {
+		return nil, err
+	}
+	headers := htsticket.NewHeaders()
+	headers.SetRangeHeader(start, end)
+	url := htsticket.NewURL()
+	url.SetURL(blobURL)
+	url.SetHeaders(headers)
+	return url, nil
+}
+
+// blobURL gets the url clients can download the blob from, SAS-signed if an
+// account key is available, otherwise unsigned for public containers. a
+// signing error is returned rather than handing out the unsigned url
+func (dao *AzureDao) blobURL() (string, error) {
+	dto := dao.azureDto(dao.url)
+	if azureutils.CanSignAzureURLs(dto) {
+		expires := time.Duration(htsconfig.GetAzureSasExpiry()) * time.Second
+		return azureutils.SignAzureBlobURL(dto, expires, time.Now())
+	}
+	return azureutils.GetAzureBlobURL(dto)
+}
+
+// ReadByteRange reads the inclusive byte range of the blob, a negative end
+// reads to the end of the blob
+func (dao *AzureDao) ReadByteRange(start int64, end int64) (io.ReadCloser, error) {
+	return azureutils.GetAzureBlobRange(dao.azureDto(dao.url), htsutils.FormatRangeHeader(start, end))
+}
+
+// ReadAssociatedFile reads a blob stored alongside the blob, e.g. an index,
+// whose name is the blob name with the suffix appended
+func (dao *AzureDao) ReadAssociatedFile(suffix string) (io.ReadCloser, error) {
+	return azureutils.GetAzureBlobRange(dao.azureDto(dao.url+suffix), "")
+}
+
+func (dao *AzureDao) String() string {
+	return "AzureDao id=" + dao.id + ", url=" + dao.url
+}
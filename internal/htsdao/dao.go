@@ -1,9 +1,40 @@
 package htsdao
 
-import "github.com/ga4gh/htsget-refserver/internal/htsticket"
+import (
+	"io"
+	"math"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+)
 
 type DataAccessObject interface {
-	GetContentLength() int64
-	GetByteRangeUrls() []*htsticket.URL
+	GetContentLength() (int64, error)
+	GetByteRangeUrls() ([]*htsticket.URL, error)
+	GetByteRangeURL(start int64, end int64) (*htsticket.URL, error)
+	ReadByteRange(start int64, end int64) (io.ReadCloser, error)
+	ReadAssociatedFile(suffix string) (io.ReadCloser, error)
 	String() string
 }
+
+// byteRangeURLs splits an object into byte ranges of at most
+// SingleBlockByteSize bytes, getting the url of each range
+func byteRangeURLs(numBytes int64, byteRangeURL func(start int64, end int64) (*htsticket.URL, error)) ([]*htsticket.URL, error) {
+	blockSize := htsconstants.SingleBlockByteSize
+	var start, end int64 = 0, 0
+	numBlocks := int(math.Ceil(float64(numBytes) / float64(blockSize)))
+	urls := []*htsticket.URL{}
+	for i := 1; i <= numBlocks; i++ {
+		end = start + blockSize - 1
+		if end >= numBytes {
+			end = numBytes - 1
+		}
+		url, err := byteRangeURL(start, end)
+		if err != nil {
+			return nil, err
+		}
+		start = end + 1
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
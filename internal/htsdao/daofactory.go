@@ -1,23 +1,42 @@
 package htsdao
 
 import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ga4gh/htsget-refserver/internal/awsutils"
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
 	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
-func getMatchingDao(id string, registry *htsconfig.DataSourceRegistry) (DataAccessObject, error) {
+func getMatchingDao(id string, ep htsconstants.APIEndpoint, registry *htsconfig.DataSourceRegistry, credentials aws.CredentialsProvider) (DataAccessObject, error) {
 	path, err := registry.GetMatchingPath(id)
 	if err != nil {
 		return nil, err
 	}
+	if azureutils.IsAzurePath(path) {
+		return NewAzureDao(id, path), nil
+	}
+	if strings.HasPrefix(path, gcsutils.GCSProto) {
+		return NewGCSDao(id, path, ep), nil
+	}
 	if htsutils.IsValidURL(path) {
-		return NewURLDao(id, path), nil
+		return NewURLDao(id, path).SetCredentials(credentials), nil
+	}
+	// local files must stay within the data source root
+	path, err = registry.GetMatchingFilePath(id)
+	if err != nil {
+		return nil, err
 	}
-	return NewFilePathDao(id, path), nil
+	return NewFilePathDao(id, path, ep), nil
 }
 
 func GetDao(req *htsrequest.HtsgetRequest) (DataAccessObject, error) {
 	registry := req.GetDataSourceRegistry()
-	return getMatchingDao(req.GetID(), registry)
+	credentials := awsutils.CredentialsProviderFromContext(req.GetContext())
+	return getMatchingDao(req.GetID(), req.GetEndpoint(), registry, credentials)
 }
@@ -1,59 +1,99 @@
 package htsdao
 
 import (
-	"math"
+	"io"
 	"os"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
 type FilePathDao struct {
 	id       string
 	filePath string
+	endpoint htsconstants.APIEndpoint
 }
 
-func NewFilePathDao(id string, filePath string) *FilePathDao {
+// NewFilePathDao instantiates a dao for a local file. byte range urls point
+// to the file bytes endpoint of the given ticket endpoint
+func NewFilePathDao(id string, filePath string, endpoint htsconstants.APIEndpoint) *FilePathDao {
 	dao := new(FilePathDao)
 	dao.id = id
 	dao.filePath = filePath
+	dao.endpoint = endpoint
 	return dao
 }
 
-func (dao *FilePathDao) GetContentLength() int64 {
-	fileInfo, _ := os.Stat(dao.filePath)
-	return fileInfo.Size()
+func (dao *FilePathDao) GetContentLength() (int64, error) {
+	fileInfo, err := os.Stat(dao.filePath)
+	if err != nil {
+		return 0, err
+	}
+	return fileInfo.Size(), nil
 }
 
 func (dao *FilePathDao) constructByteRangeURL(start int64, end int64) *htsticket.URL {
-	host := htsconfig.GetHost()
-	path := host + htsconstants.FileByteRangeURLPath
+	return fileBytesURL(dao.id, dao.endpoint, start, end)
+}
+
+// fileBytesURL gets the url of a byte range served by the file bytes
+// endpoint. the object is referenced by its id, resolved again through the
+// data source registry when the url is followed
+func fileBytesURL(id string, endpoint htsconstants.APIEndpoint, start int64, end int64) *htsticket.URL {
+	host := htsutils.RemoveTrailingSlash(htsconfig.GetHost())
+	path := host + endpoint.FileBytesEndpointPath() + id
 	headers := htsticket.NewHeaders()
 	headers.SetRangeHeader(start, end)
-	headers.SetFilePathHeader(dao.filePath)
 	url := htsticket.NewURL()
 	url.SetURL(path)
 	url.SetHeaders(headers)
 	return url
 }
 
-func (dao *FilePathDao) GetByteRangeUrls() []*htsticket.URL {
-	numBytes := dao.GetContentLength()
-	blockSize := htsconstants.SingleBlockByteSize
-	var start, end int64 = 0, 0
-	numBlocks := int(math.Ceil(float64(numBytes) / float64(blockSize)))
-	urls := []*htsticket.URL{}
-	for i := 1; i <= numBlocks; i++ {
-		end = start + blockSize - 1
-		if end >= numBytes {
-			end = numBytes - 1
-		}
-		url := dao.constructByteRangeURL(start, end)
-		start = end + 1
-		urls = append(urls, url)
+func (dao *FilePathDao) GetByteRangeUrls() ([]*htsticket.URL, error) {
+	contentLength, err := dao.GetContentLength()
+	if err != nil {
+		return nil, err
+	}
+	return byteRangeURLs(contentLength, dao.GetByteRangeURL)
+}
+
+func (dao *FilePathDao) GetByteRangeURL(start int64, end int64) (*htsticket.URL, error) {
+	return dao.constructByteRangeURL(start, end), nil
+}
+
+// ReadByteRange reads the inclusive byte range of the file, a negative end
+// reads to the end of the file
+func (dao *FilePathDao) ReadByteRange(start int64, end int64) (io.ReadCloser, error) {
+	return openFileByteRange(dao.filePath, start, end)
+}
+
+// ReadAssociatedFile reads a file stored alongside the object, e.g. an index,
+// whose path is the object path with the suffix appended
+func (dao *FilePathDao) ReadAssociatedFile(suffix string) (io.ReadCloser, error) {
+	return os.Open(dao.filePath + suffix)
+}
+
+type fileByteRange struct {
+	io.Reader
+	io.Closer
+}
+
+func openFileByteRange(filePath string, start int64, end int64) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if end < 0 {
+		return file, nil
 	}
-	return urls
+	return fileByteRange{io.LimitReader(file, end-start+1), file}, nil
 }
 
 func (dao *FilePathDao) String() string {
@@ -0,0 +1,84 @@
+package htsdao
+
+import (
+	"io"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
+)
+
+type GCSDao struct {
+	id       string
+	url      string
+	endpoint htsconstants.APIEndpoint
+}
+
+// NewGCSDao instantiates a dao for a gs:// object. byte range urls are
+// signed GCS urls if a service account key is available, otherwise they
+// point to the file bytes endpoint of the given ticket endpoint, which
+// proxies the object
+func NewGCSDao(id string, url string, endpoint htsconstants.APIEndpoint) *GCSDao {
+	dao := new(GCSDao)
+	dao.id = id
+	dao.url = url
+	dao.endpoint = endpoint
+	return dao
+}
+
+func (dao *GCSDao) gcsDto(url string) gcsutils.GCSDto {
+	return gcsutils.GCSDto{
+		ObjPath: url,
+	}
+}
+
+func (dao *GCSDao) GetContentLength() (int64, error) {
+	return gcsutils.GetGCSObjectSize(dao.gcsDto(dao.url))
+}
+
+func (dao *GCSDao) GetByteRangeUrls() ([]*htsticket.URL, error) {
+	contentLength, err := dao.GetContentLength()
+	if err != nil {
+		return nil, err
+	}
+	return byteRangeURLs(contentLength, dao.GetByteRangeURL)
+}
+
+// GetByteRangeURL gets a signed GCS url for the byte range, falling back on
+// the proxied file bytes url if signing isn't possible
+func (dao *GCSDao) GetByteRangeURL(start int64, end int64) (*htsticket.URL, error) {
+	dto := dao.gcsDto(dao.url)
+	if !gcsutils.CanSignGCSURLs(dto) {
+		return fileBytesURL(dao.id, dao.endpoint, start, end), nil
+	}
+	headers := htsticket.NewHeaders()
+	headers.SetRangeHeader(start, end)
+	expires := time.Duration(htsconfig.GetGcsSignedURLExpiry()) * time.Second
+	signedURL, err := gcsutils.SignGCSObjectRange(dto, headers.Range, expires, time.Now())
+	if err != nil {
+		return fileBytesURL(dao.id, dao.endpoint, start, end), nil
+	}
+	url := htsticket.NewURL()
+	url.SetURL(signedURL)
+	url.SetHeaders(headers)
+	return url, nil
+}
+
+// ReadByteRange reads the inclusive byte range of the object, a negative end
+// reads to the end of the object
+func (dao *GCSDao) ReadByteRange(start int64, end int64) (io.ReadCloser, error) {
+	return gcsutils.GetGCSObjectRange(dao.gcsDto(dao.url), htsutils.FormatRangeHeader(start, end))
+}
+
+// ReadAssociatedFile reads an object stored alongside the object, e.g. an
+// index, whose name is the object name with the suffix appended
+func (dao *GCSDao) ReadAssociatedFile(suffix string) (io.ReadCloser, error) {
+	return gcsutils.GetGCSObjectRange(dao.gcsDto(dao.url+suffix), "")
+}
+
+func (dao *GCSDao) String() string {
+	return "GCSDao id=" + dao.id + ", url=" + dao.url
+}
@@ -1,18 +1,29 @@
 package htsdao
 
 import (
-	"math"
+	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
-	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/ga4gh/htsget-refserver/internal/awsutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
 type URLDao struct {
-	id  string
-	url string
+	id          string
+	url         string
+	credentials aws.CredentialsProvider
+	s3Once      sync.Once
+	s3Client    awsutils.S3ClientApi
+	s3Presigner awsutils.S3PresignApi
+	s3Err       error
 }
 
 func NewURLDao(id string, url string) *URLDao {
@@ -22,38 +33,145 @@ func NewURLDao(id string, url string) *URLDao {
 	return dao
 }
 
-func (dao *URLDao) GetContentLength() int64 {
+// SetCredentials sets the request-scoped credentials s3:// objects are
+// accessed with, instead of the default credentials chain
+func (dao *URLDao) SetCredentials(credentials aws.CredentialsProvider) *URLDao {
+	dao.credentials = credentials
+	return dao
+}
+
+// s3Dto gets the transfer object of an s3:// url. the s3 client and presigner
+// are created on first use, and shared by all requests of the dao. fails if
+// they could not be created
+func (dao *URLDao) s3Dto(url string) (awsutils.S3Dto, error) {
+	dao.s3Once.Do(func() {
+		dto := awsutils.S3Dto{Credentials: dao.credentials}
+		dao.s3Client, dao.s3Err = dto.NewS3Client()
+		if dao.s3Err != nil {
+			return
+		}
+		dto.Client = dao.s3Client
+		dao.s3Presigner, dao.s3Err = dto.NewS3PresignClient()
+	})
+	return awsutils.S3Dto{
+		ObjPath:     url,
+		Client:      dao.s3Client,
+		Presigner:   dao.s3Presigner,
+		Credentials: dao.credentials,
+	}, dao.s3Err
+}
+
+func (dao *URLDao) GetContentLength() (int64, error) {
 	if strings.HasPrefix(dao.url, awsutils.S3Proto) {
-		contentLength, _ := awsutils.HeadS3Object(awsutils.S3Dto{
-			ObjPath: dao.url,
-		})
-		return contentLength
-	}
-	res, _ := http.Head(dao.url)
-	return res.ContentLength
-}
-
-func (dao *URLDao) GetByteRangeUrls() []*htsticket.URL {
-
-	numBytes := dao.GetContentLength()
-	blockSize := htsconstants.SingleBlockByteSize
-	var start, end int64 = 0, 0
-	numBlocks := int(math.Ceil(float64(numBytes) / float64(blockSize)))
-	urls := []*htsticket.URL{}
-	for i := 1; i <= numBlocks; i++ {
-		end = start + blockSize - 1
-		if end >= numBytes {
-			end = numBytes - 1
+		dto, err := dao.s3Dto(dao.url)
+		if err != nil {
+			return 0, err
 		}
-		headers := htsticket.NewHeaders()
-		headers.SetRangeHeader(start, end)
-		url := htsticket.NewURL()
-		url.SetURL(dao.url)
-		url.SetHeaders(headers)
-		start = end + 1
-		urls = append(urls, url)
-	}
-	return urls
+		return awsutils.HeadS3Object(dto)
+	}
+	res, err := http.Head(dao.url)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, errors.New("could not get content length of " + dao.url + ": " + res.Status)
+	}
+	return res.ContentLength, nil
+}
+
+func (dao *URLDao) GetByteRangeUrls() ([]*htsticket.URL, error) {
+	contentLength, err := dao.GetContentLength()
+	if err != nil {
+		return nil, err
+	}
+	return byteRangeURLs(contentLength, dao.GetByteRangeURL)
+}
+
+func (dao *URLDao) GetByteRangeURL(start int64, end int64) (*htsticket.URL, error) {
+	headers := htsticket.NewHeaders()
+	headers.SetRangeHeader(start, end)
+	byteRangeURL, err := dao.byteRangeURL(headers.Range)
+	if err != nil {
+		return nil, err
+	}
+	url := htsticket.NewURL()
+	url.SetURL(byteRangeURL)
+	url.SetHeaders(headers)
+	return url, nil
+}
+
+// byteRangeURL gets the url clients can download the byte range from. s3://
+// objects are presigned, as clients can't download from s3:// urls
+func (dao *URLDao) byteRangeURL(rangeHeader string) (string, error) {
+	if !strings.HasPrefix(dao.url, awsutils.S3Proto) {
+		return dao.url, nil
+	}
+	expires := time.Duration(htsconfig.GetAwsPresignExpiry()) * time.Second
+	dto, err := dao.s3Dto(dao.url)
+	if err != nil {
+		return "", err
+	}
+	return awsutils.PresignS3ObjectRange(dto, rangeHeader, expires)
+}
+
+// ReadByteRange reads the inclusive byte range of the object, a negative end
+// reads to the end of the object
+func (dao *URLDao) ReadByteRange(start int64, end int64) (io.ReadCloser, error) {
+	return dao.readURLByteRange(dao.url, start, end)
+}
+
+// ReadAssociatedFile reads an object stored alongside the object, e.g. an
+// index, whose url path is the object url path with the suffix appended
+func (dao *URLDao) ReadAssociatedFile(suffix string) (io.ReadCloser, error) {
+	associatedURL := dao.url + suffix
+	if i := strings.Index(dao.url, "?"); i >= 0 {
+		associatedURL = dao.url[:i] + suffix + dao.url[i:]
+	}
+	return dao.readURLByteRange(associatedURL, 0, -1)
+}
+
+type urlByteRange struct {
+	io.Reader
+	io.Closer
+}
+
+func (dao *URLDao) readURLByteRange(url string, start int64, end int64) (io.ReadCloser, error) {
+	rangeHeader := htsutils.FormatRangeHeader(start, end)
+	if strings.HasPrefix(url, awsutils.S3Proto) {
+		dto, err := dao.s3Dto(url)
+		if err != nil {
+			return nil, err
+		}
+		return awsutils.GetS3ObjectRange(dto, rangeHeader)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", rangeHeader)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return res.Body, nil
+	case http.StatusOK:
+		// the server ignored the range header, skip to the requested range
+		if _, err := io.CopyN(ioutil.Discard, res.Body, start); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		if end < 0 {
+			return res.Body, nil
+		}
+		return urlByteRange{io.LimitReader(res.Body, end-start+1), res.Body}, nil
+	}
+	res.Body.Close()
+	return nil, errors.New("could not read byte range of " + url + ": " + res.Status)
 }
 
 func (dao *URLDao) String() string {
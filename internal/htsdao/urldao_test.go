@@ -0,0 +1,91 @@
+package htsdao
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+type urlDaoMockPresigner struct {
+	err error
+}
+
+func (presigner *urlDaoMockPresigner) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	if presigner.err != nil {
+		return nil, presigner.err
+	}
+	return &v4.PresignedHTTPRequest{
+		URL: "https://" + *params.Bucket + ".s3.amazonaws.com/" + *params.Key + "?range=" + *params.Range,
+	}, nil
+}
+
+// newMockS3URLDao instantiates a dao for an s3:// object, presigning urls with
+// the mock presigner
+func newMockS3URLDao(presigner *urlDaoMockPresigner) *URLDao {
+	dao := NewURLDao("object0001", "s3://bucket/object0001.bam")
+	dao.s3Once.Do(func() {})
+	dao.s3Presigner = presigner
+	return dao
+}
+
+// TestURLDaoGetByteRangeURL tests GetByteRangeURL function
+func TestURLDaoGetByteRangeURL(t *testing.T) {
+	url, err := newMockS3URLDao(&urlDaoMockPresigner{}).GetByteRangeURL(0, 99)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://bucket.s3.amazonaws.com/object0001.bam?range=bytes=0-99", url.URL)
+	assert.Equal(t, "bytes=0-99", url.Headers.Range)
+
+	// s3:// urls can't be downloaded by clients, so presigning errors are
+	// returned
+	url, err = newMockS3URLDao(&urlDaoMockPresigner{err: errors.New("no credentials")}).GetByteRangeURL(0, 99)
+	assert.NotNil(t, err)
+	assert.Nil(t, url)
+
+	// other urls are returned as-is
+	url, err = NewURLDao("object0001", "https://example.org/object0001.bam").GetByteRangeURL(0, 99)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.org/object0001.bam", url.URL)
+}
+
+// TestURLDaoS3Dto tests the s3 clients are created once per dao
+func TestURLDaoS3Dto(t *testing.T) {
+	dao := NewURLDao("object0001", "s3://bucket/object0001.bam")
+	first, err := dao.s3Dto("s3://bucket/object0001.bam")
+	assert.Nil(t, err)
+	second, err := dao.s3Dto("s3://bucket/object0001.bam.bai")
+	assert.Nil(t, err)
+	assert.NotNil(t, first.Client)
+	assert.NotNil(t, first.Presigner)
+	assert.True(t, first.Client == second.Client)
+	assert.True(t, first.Presigner == second.Presigner)
+	assert.Equal(t, "s3://bucket/object0001.bam.bai", second.ObjPath)
+}
+
+// TestURLDaoGetContentLength tests GetContentLength function
+func TestURLDaoGetContentLength(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/object0001.bam" {
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Header().Set("Content-Length", "100")
+	}))
+	defer server.Close()
+
+	contentLength, err := NewURLDao("object0001", server.URL+"/object0001.bam").GetContentLength()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), contentLength)
+
+	// missing objects are errors, rather than empty objects
+	urls, err := NewURLDao("object0002", server.URL+"/object0002.bam").GetByteRangeUrls()
+	assert.NotNil(t, err)
+	assert.Nil(t, urls)
+	_, err = NewURLDao("object0001", "http://127.0.0.1:0/object0001.bam").GetContentLength()
+	assert.NotNil(t, err)
+}
@@ -18,6 +18,12 @@ type htsgetError struct {
 	Htsget errorContainer `json:"htsget"`
 }
 
+// ErrorRecorder is implemented by response writers recording the type of
+// htsget error written to them, e.g. for logging and metrics
+type ErrorRecorder interface {
+	RecordHtsgetError(errorName string)
+}
+
 // errorContainer contains attributes for the main htsget error response body
 type errorContainer struct {
 	Error   string `json:"error"`
@@ -67,6 +73,9 @@ func writeHtsgetErrorToHTTPError(writer http.ResponseWriter, code int, err strin
 // to overall the default message
 func htsgetErrorTemplate(writer http.ResponseWriter, errorName string, msgPtr *string) {
 	code, _ := strconv.Atoi(errorInfoMap[errorName]["code"])
+	if recorder, ok := writer.(ErrorRecorder); ok {
+		recorder.RecordHtsgetError(errorName)
+	}
 
 	// use the default message if the message pointer is nil
 	msg := errorInfoMap[errorName]["dfltMsg"]
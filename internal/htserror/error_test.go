@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,3 +100,24 @@ func TestHTTPErrorDefault(t *testing.T) {
 	writeHTTPError(writer, err)
 
 }
+
+// recordingWriter response writer recording htsget errors
+type recordingWriter struct {
+	*httptest.ResponseRecorder
+	errorName string
+}
+
+func (writer *recordingWriter) RecordHtsgetError(errorName string) {
+	writer.errorName = errorName
+}
+
+// TestErrorRecorder tests the error type is recorded by writers implementing
+// ErrorRecorder
+func TestErrorRecorder(t *testing.T) {
+	for _, tc := range errorsTC {
+		writer := &recordingWriter{ResponseRecorder: httptest.NewRecorder()}
+		tc.errorFunction(writer, tc.message)
+		assert.True(t, strings.HasPrefix(tc.expString, writer.errorName+": "), writer.errorName)
+		assert.Equal(t, tc.expCode, writer.Code)
+	}
+}
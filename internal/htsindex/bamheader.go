@@ -0,0 +1,86 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module bamheader reads the header of a BAM file, mapping reference
+// sequence names to the ids used by the index
+package htsindex
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+var bamMagic = []byte("BAM\x01")
+
+// BAMHeader the header of a BAM file
+//
+// Attributes
+//	Text (string): plain text SAM header
+//	References ([]*BAMReference): reference sequences, in id order
+//	End (htsbgzf.VirtualOffset): position of the first alignment record
+type BAMHeader struct {
+	Text       string
+	References []*BAMReference
+	End        htsbgzf.VirtualOffset
+}
+
+// BAMReference a reference sequence listed in the BAM header
+//
+// Attributes
+//	Name (string): reference sequence name
+//	Length (int64): reference sequence length
+type BAMReference struct {
+	Name   string
+	Length int64
+}
+
+// ReadBAMHeader reads the header from the start of a BAM file
+//
+// Arguments
+//	reader (*htsbgzf.Reader): reader positioned at the start of the BAM file
+// Returns
+//	(*BAMHeader): the parsed header
+//	(error): if not nil, the data is not a valid BAM file
+func ReadBAMHeader(reader *htsbgzf.Reader) (*BAMHeader, error) {
+	ir := &indexReader{r: reader}
+	ir.magic(bamMagic)
+	if ir.err != nil {
+		return nil, errors.New("not a BAM file")
+	}
+
+	header := new(BAMHeader)
+	header.Text = string(bytes.TrimRight(ir.read(ir.count()), "\x00"))
+	nRefs := ir.count()
+	for i := 0; i < nRefs && ir.err == nil; i++ {
+		name := ir.read(ir.count())
+		length := ir.uint32()
+		header.References = append(header.References, &BAMReference{
+			Name:   string(bytes.TrimRight(name, "\x00")),
+			Length: int64(length),
+		})
+	}
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	header.End = reader.VirtualOffset()
+	return header, nil
+}
+
+// ReferenceID gets the id of a reference sequence by name
+//
+//	Type: BAMHeader
+// Arguments
+//	name (string): reference sequence name
+// Returns
+//	(int): reference id, -1 if the name is not in the header
+func (header *BAMHeader) ReferenceID(name string) int {
+	for i, reference := range header.References {
+		if reference.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,62 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module bamheader_test tests module bamheader
+package htsindex
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/stretchr/testify/assert"
+)
+
+// bamHeaderReferenceIDTC test cases for ReferenceID
+var bamHeaderReferenceIDTC = []struct {
+	name  string
+	expID int
+}{
+	{"chr1", 0},
+	{"chr10", 1},
+	{"chrM", 36},
+	{"ERCC-00002", 66},
+	{"chr23", -1},
+	{"*", -1},
+}
+
+// TestBAMHeaderReadBAMHeader tests ReadBAMHeader function
+func TestBAMHeaderReadBAMHeader(t *testing.T) {
+	file, _ := os.Open(testBAMPath)
+	defer file.Close()
+	header, err := ReadBAMHeader(htsbgzf.NewReader(file, 0))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(header.Text, "@HD"))
+	assert.Equal(t, 162, len(header.References))
+	assert.Equal(t, "chr1", header.References[0].Name)
+	assert.Equal(t, int64(195471971), header.References[0].Length)
+	assert.Equal(t, htsbgzf.NewVirtualOffset(2580, 0), header.End)
+}
+
+// TestBAMHeaderReferenceID tests ReferenceID function
+func TestBAMHeaderReferenceID(t *testing.T) {
+	file, _ := os.Open(testBAMPath)
+	defer file.Close()
+	header, _ := ReadBAMHeader(htsbgzf.NewReader(file, 0))
+	for _, tc := range bamHeaderReferenceIDTC {
+		assert.Equal(t, tc.expID, header.ReferenceID(tc.name))
+	}
+}
+
+// TestBAMHeaderReadBAMHeaderError tests ReadBAMHeader on invalid data
+func TestBAMHeaderReadBAMHeaderError(t *testing.T) {
+	notBAM, _ := htsbgzf.Compress([]byte("##fileformat=VCFv4.2\n"))
+	truncated, _ := htsbgzf.Compress([]byte("BAM\x01\x10\x00\x00\x00@HD"))
+	for _, data := range [][]byte{notBAM, truncated, []byte("BAM\x01")} {
+		_, err := ReadBAMHeader(htsbgzf.NewReader(bytes.NewReader(data), 0))
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module index contains the binning index structure and region queries
+package htsindex
+
+import (
+	"sort"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+// Chunk a contiguous section of an indexed BGZF file
+//
+// Attributes
+//	Begin (htsbgzf.VirtualOffset): start of the chunk (inclusive)
+//	End (htsbgzf.VirtualOffset): end of the chunk (exclusive)
+type Chunk struct {
+	Begin htsbgzf.VirtualOffset
+	End   htsbgzf.VirtualOffset
+}
+
+// bin chunks of records falling within a single bin, and the smallest
+// virtual offset of records overlapping the bin (CSI only)
+type bin struct {
+	loffset htsbgzf.VirtualOffset
+	chunks  []Chunk
+}
+
+// referenceIndex binning and linear index for a single reference sequence
+type referenceIndex struct {
+	bins      map[uint32]*bin
+	intervals []htsbgzf.VirtualOffset
+}
+
+// Index a binning index, as described by the BAI/CSI specifications
+//
+// Attributes
+//	minShift (int): number of bits of the smallest bin/linear index window
+//	depth (int): number of levels of the binning scheme
+//	references ([]*referenceIndex): index per reference sequence
+//	names ([]string): reference sequence names, for indices carrying them
+//	(tabix, and CSI with tabix metadata)
+type Index struct {
+	minShift   int
+	depth      int
+	references []*referenceIndex
+	names      []string
+}
+
+// newIndex instantiates an empty index with the given binning scheme
+func newIndex(minShift int, depth int) *Index {
+	index := new(Index)
+	index.minShift = minShift
+	index.depth = depth
+	return index
+}
+
+// newReferenceIndex instantiates an empty reference index
+func newReferenceIndex() *referenceIndex {
+	referenceIndex := new(referenceIndex)
+	referenceIndex.bins = make(map[uint32]*bin)
+	return referenceIndex
+}
+
+// NumReferences gets the number of reference sequences in the index
+//
+//	Type: Index
+// Returns
+//	(int): number of reference sequences
+func (index *Index) NumReferences() int {
+	return len(index.references)
+}
+
+// ReferenceID gets the id of a reference sequence by name, for indices
+// carrying reference sequence names
+//
+//	Type: Index
+// Arguments
+//	name (string): reference sequence name
+// Returns
+//	(int): reference id, -1 if the name is not in the index
+func (index *Index) ReferenceID(name string) int {
+	for i, indexName := range index.names {
+		if indexName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// MaxPosition gets the (exclusive) upper limit of positions addressable
+// by the index binning scheme
+//
+//	Type: Index
+// Returns
+//	(int64): maximum position
+func (index *Index) MaxPosition() int64 {
+	return int64(1) << uint(index.minShift+3*index.depth)
+}
+
+// pseudoBin gets the id of the bin holding per-reference metadata rather
+// than chunks, which is one past the last bin of the scheme
+func (index *Index) pseudoBin() uint32 {
+	return binFirst(index.depth+1) + 1
+}
+
+// binFirst gets the id of the first bin at the given level
+func binFirst(level int) uint32 {
+	return uint32(((1 << uint(3*level)) - 1) / 7)
+}
+
+// binParent gets the id of the bin containing the given bin
+func binParent(b uint32) uint32 {
+	return (b - 1) >> 3
+}
+
+// reg2bins gets the ids of all bins that may contain records overlapping
+// the region [beg, end), given the binning scheme
+//
+// Arguments
+//	beg (int64): 0-based start of the region (inclusive)
+//	end (int64): 0-based end of the region (exclusive)
+//	minShift (int): number of bits of the smallest bin
+//	depth (int): number of levels of the binning scheme
+// Returns
+//	([]uint32): ids of overlapping bins
+func reg2bins(beg int64, end int64, minShift int, depth int) []uint32 {
+	if beg >= end {
+		return nil
+	}
+	maxPos := int64(1) << uint(minShift+3*depth)
+	if end > maxPos {
+		end = maxPos
+	}
+	end--
+	bins := []uint32{}
+	shift := uint(minShift + 3*depth)
+	for level := 0; level <= depth; level++ {
+		first := int64(binFirst(level))
+		for b := first + (beg >> shift); b <= first+(end>>shift); b++ {
+			bins = append(bins, uint32(b))
+		}
+		shift -= 3
+	}
+	return bins
+}
+
+// minOffset gets the smallest virtual offset at which records overlapping
+// the given position may start, from the linear index (BAI) or the bins'
+// loffset (CSI)
+func (index *Index) minOffset(reference *referenceIndex, beg int64) htsbgzf.VirtualOffset {
+	if len(reference.intervals) > 0 {
+		window := int(beg >> uint(index.minShift))
+		if window >= len(reference.intervals) {
+			window = len(reference.intervals) - 1
+		}
+		return reference.intervals[window]
+	}
+
+	// walk from the smallest bin containing the position towards the root,
+	// until a bin present in the index is found
+	b := binFirst(index.depth) + uint32(beg>>uint(index.minShift))
+	for b > 0 {
+		if found, ok := reference.bins[b]; ok {
+			return found.loffset
+		}
+		first := (binParent(b) << 3) + 1
+		if b > first {
+			b--
+		} else {
+			b = binParent(b)
+		}
+	}
+	if found, ok := reference.bins[0]; ok {
+		return found.loffset
+	}
+	return 0
+}
+
+// Query gets the chunks of the indexed file that may contain records
+// overlapping a region of a reference sequence. chunks are sorted and merged
+//
+//	Type: Index
+// Arguments
+//	refID (int): index of the reference sequence
+//	beg (int64): 0-based start of the region (inclusive)
+//	end (int64): 0-based end of the region (exclusive)
+// Returns
+//	([]Chunk): chunks containing overlapping records
+func (index *Index) Query(refID int, beg int64, end int64) []Chunk {
+	if refID < 0 || refID >= len(index.references) {
+		return nil
+	}
+	if beg < 0 {
+		beg = 0
+	}
+	reference := index.references[refID]
+	minOffset := index.minOffset(reference, beg)
+
+	chunks := []Chunk{}
+	for _, b := range reg2bins(beg, end, index.minShift, index.depth) {
+		found, ok := reference.bins[b]
+		if !ok {
+			continue
+		}
+		for _, chunk := range found.chunks {
+			if chunk.End > minOffset {
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+	return MergeChunks(chunks)
+}
+
+// MergeChunks sorts chunks, and merges those that overlap or are adjacent,
+// so that no part of the indexed file is covered twice
+//
+// Arguments
+//	chunks ([]Chunk): chunks to merge
+// Returns
+//	([]Chunk): sorted, non-overlapping chunks
+func MergeChunks(chunks []Chunk) []Chunk {
+	if len(chunks) == 0 {
+		return chunks
+	}
+	sorted := make([]Chunk, len(chunks))
+	copy(sorted, chunks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Begin < sorted[j].Begin
+	})
+
+	merged := []Chunk{sorted[0]}
+	for _, chunk := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if chunk.Begin <= last.End {
+			if chunk.End > last.End {
+				last.End = chunk.End
+			}
+		} else {
+			merged = append(merged, chunk)
+		}
+	}
+	return merged
+}
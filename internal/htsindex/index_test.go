@@ -0,0 +1,129 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module index_test tests module index
+package htsindex
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/stretchr/testify/assert"
+)
+
+// paths to test BAM, BAI and CSI files
+var testBAMPath = "../../data/test/sources/tabulamuris/A1-B000168-3_57_F-1-1_R2.mus.Aligned.out.sorted.bam"
+var testBAIPath = testBAMPath + ".bai"
+var testCSIPath = "../../data/test/sources/giab/HG002_GIAB.filtered.vcf.gz.csi"
+
+// indexReg2binsTC test cases for reg2bins
+var indexReg2binsTC = []struct {
+	beg, end        int64
+	minShift, depth int
+	exp             []uint32
+}{
+	// first 16kbp window, one bin per level
+	{0, 1, 14, 5, []uint32{0, 1, 9, 73, 585, 4681}},
+	// spanning two 16kbp windows
+	{16383, 16385, 14, 5, []uint32{0, 1, 9, 73, 585, 4681, 4682}},
+	// empty region
+	{100, 100, 14, 5, nil},
+	// region clipped to the maximum position
+	{(1 << 29) - 1, 1 << 30, 14, 5, []uint32{0, 8, 72, 584, 4680, 37448}},
+	// CSI scheme with deeper binning
+	{0, 1, 14, 6, []uint32{0, 1, 9, 73, 585, 4681, 37449}},
+}
+
+// indexMergeChunksTC test cases for MergeChunks
+var indexMergeChunksTC = []struct {
+	chunks []Chunk
+	exp    []Chunk
+}{
+	{[]Chunk{}, []Chunk{}},
+	// unsorted, overlapping
+	{
+		[]Chunk{{vo(500, 0), vo(900, 10)}, {vo(100, 0), vo(600, 0)}},
+		[]Chunk{{vo(100, 0), vo(900, 10)}},
+	},
+	// adjacent chunks
+	{
+		[]Chunk{{vo(100, 0), vo(200, 50)}, {vo(200, 50), vo(300, 0)}},
+		[]Chunk{{vo(100, 0), vo(300, 0)}},
+	},
+	// chunks sharing a block, without overlapping
+	{
+		[]Chunk{{vo(100, 0), vo(200, 50)}, {vo(200, 80), vo(300, 0)}},
+		[]Chunk{{vo(100, 0), vo(200, 50)}, {vo(200, 80), vo(300, 0)}},
+	},
+	// contained chunk
+	{
+		[]Chunk{{vo(100, 0), vo(900, 0)}, {vo(200, 0), vo(300, 0)}},
+		[]Chunk{{vo(100, 0), vo(900, 0)}},
+	},
+	// disjoint chunks
+	{
+		[]Chunk{{vo(400, 0), vo(500, 0)}, {vo(100, 0), vo(200, 0)}},
+		[]Chunk{{vo(100, 0), vo(200, 0)}, {vo(400, 0), vo(500, 0)}},
+	},
+}
+
+// vo convenience method to construct a virtual offset
+func vo(compressed int64, uncompressed int) htsbgzf.VirtualOffset {
+	return htsbgzf.NewVirtualOffset(compressed, uncompressed)
+}
+
+// TestIndexReg2bins tests reg2bins function
+func TestIndexReg2bins(t *testing.T) {
+	for _, tc := range indexReg2binsTC {
+		assert.Equal(t, tc.exp, reg2bins(tc.beg, tc.end, tc.minShift, tc.depth))
+	}
+}
+
+// TestIndexMergeChunks tests MergeChunks function
+func TestIndexMergeChunks(t *testing.T) {
+	for _, tc := range indexMergeChunksTC {
+		assert.Equal(t, tc.exp, MergeChunks(tc.chunks))
+	}
+}
+
+// TestIndexMaxPosition tests MaxPosition function
+func TestIndexMaxPosition(t *testing.T) {
+	assert.Equal(t, int64(1<<29), newIndex(baiMinShift, baiDepth).MaxPosition())
+	assert.Equal(t, int64(1<<32), newIndex(14, 6).MaxPosition())
+}
+
+// indexQueryTC test cases for Query against the test BAM index
+var indexQueryTC = []struct {
+	refID     int
+	beg, end  int64
+	expChunks bool
+}{
+	{0, 0, 1 << 29, true},            // chr1, whole reference
+	{0, 20000000, 30000000, true},    // chr1 20-30Mbp
+	{36, 0, 16569, true},             // chrM
+	{0, 195000000, 196000000, false}, // chr1, past the last read
+	{19, 0, 1 << 29, false},          // reference without reads
+	{-1, 0, 1 << 29, false},          // unknown reference
+	{500, 0, 1 << 29, false},         // out of range reference
+}
+
+// TestIndexQuery tests Query function
+func TestIndexQuery(t *testing.T) {
+	file, _ := os.Open(testBAIPath)
+	defer file.Close()
+	index, err := ReadBAI(file)
+	assert.Nil(t, err)
+
+	for _, tc := range indexQueryTC {
+		chunks := index.Query(tc.refID, tc.beg, tc.end)
+		assert.Equal(t, tc.expChunks, len(chunks) > 0)
+		for i, chunk := range chunks {
+			assert.True(t, chunk.Begin < chunk.End)
+			if i > 0 {
+				assert.True(t, chunks[i-1].End < chunk.Begin)
+			}
+		}
+	}
+}
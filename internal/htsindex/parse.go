@@ -0,0 +1,174 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module parse reads BAI and CSI index files
+package htsindex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+// BAI binning scheme: 16kbp smallest bins, 5 levels
+const baiMinShift = 14
+const baiDepth = 5
+
+var baiMagic = []byte("BAI\x01")
+var csiMagic = []byte("CSI\x01")
+
+// indexReader reads little-endian binary fields of index (and BAM header)
+// data, retaining the first error
+type indexReader struct {
+	r   io.Reader
+	err error
+}
+
+// read reads n bytes. the buffer grows as data is read, so corrupt lengths
+// fail at the end of the data rather than allocating up front
+func (ir *indexReader) read(n int) []byte {
+	if ir.err == nil {
+		buf, err := ioutil.ReadAll(io.LimitReader(ir.r, int64(n)))
+		if err == nil && len(buf) == n {
+			return buf
+		}
+		ir.err = errors.New("unexpected end of file")
+	}
+	// zeroed placeholder for fixed size fields, once an error has occurred
+	if n > 8 {
+		return nil
+	}
+	return make([]byte, n)
+}
+
+func (ir *indexReader) int32() int32 {
+	return int32(binary.LittleEndian.Uint32(ir.read(4)))
+}
+
+func (ir *indexReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(ir.read(4))
+}
+
+func (ir *indexReader) virtualOffset() htsbgzf.VirtualOffset {
+	return htsbgzf.VirtualOffset(binary.LittleEndian.Uint64(ir.read(8)))
+}
+
+// count reads a non-negative int32 count, failing on nonsensical values
+func (ir *indexReader) count() int {
+	n := ir.int32()
+	if n < 0 && ir.err == nil {
+		ir.err = errors.New("invalid count field")
+	}
+	if ir.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (ir *indexReader) magic(expected []byte) {
+	if string(ir.read(len(expected))) != string(expected) && ir.err == nil {
+		ir.err = errors.New("unrecognized file magic")
+	}
+}
+
+// chunks reads a chunk count, followed by the chunks
+func (ir *indexReader) chunks() []Chunk {
+	nChunks := ir.count()
+	chunks := []Chunk{}
+	for i := 0; i < nChunks && ir.err == nil; i++ {
+		begin := ir.virtualOffset()
+		end := ir.virtualOffset()
+		chunks = append(chunks, Chunk{Begin: begin, End: end})
+	}
+	return chunks
+}
+
+// ReadBAI parses a BAI index file
+//
+// Arguments
+//	r (io.Reader): uncompressed BAI index data
+// Returns
+//	(*Index): the parsed index
+//	(error): if not nil, the data is not a valid BAI index
+func ReadBAI(r io.Reader) (*Index, error) {
+	ir := &indexReader{r: bufio.NewReader(r)}
+	ir.magic(baiMagic)
+	index := newIndex(baiMinShift, baiDepth)
+	index.references = ir.binningIndices(index, false, true)
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	return index, nil
+}
+
+// ReadCSI parses a CSI index file
+//
+// Arguments
+//	r (io.Reader): BGZF compressed CSI index data
+// Returns
+//	(*Index): the parsed index
+//	(error): if not nil, the data is not a valid CSI index
+func ReadCSI(r io.Reader) (*Index, error) {
+	ir := &indexReader{r: htsbgzf.NewReader(r, 0)}
+	ir.magic(csiMagic)
+	minShift := ir.count()
+	depth := ir.count()
+	aux := ir.read(ir.count())
+	if ir.err == nil && (minShift+3*depth > 62 || depth > 20) {
+		return nil, errors.New("unsupported CSI binning scheme")
+	}
+	index := newIndex(minShift, depth)
+	// indices of tabix-indexed files carry the tabix header, including the
+	// reference names, as auxiliary data
+	if names, err := readTabixHeader(bytes.NewReader(aux)); err == nil {
+		index.names = names
+	}
+	index.references = ir.binningIndices(index, true, false)
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	return index, nil
+}
+
+// binningIndices reads the reference count, followed by the bins (and
+// optionally linear index) of each reference
+func (ir *indexReader) binningIndices(index *Index, withLoffset bool, withIntervals bool) []*referenceIndex {
+	return ir.referenceIndices(index, ir.count(), withLoffset, withIntervals)
+}
+
+// referenceIndices reads the bins (and optionally linear index) of nRefs
+// references
+func (ir *indexReader) referenceIndices(index *Index, nRefs int, withLoffset bool, withIntervals bool) []*referenceIndex {
+	references := []*referenceIndex{}
+	for i := 0; i < nRefs && ir.err == nil; i++ {
+		reference := newReferenceIndex()
+		nBins := ir.count()
+		for j := 0; j < nBins && ir.err == nil; j++ {
+			id := ir.uint32()
+			b := new(bin)
+			if withLoffset {
+				b.loffset = ir.virtualOffset()
+			}
+			b.chunks = ir.chunks()
+			// the pseudo-bin holds mapped/unmapped counts, not chunks
+			if id != index.pseudoBin() {
+				reference.bins[id] = b
+			}
+		}
+		if withIntervals {
+			nIntervals := ir.count()
+			reference.intervals = []htsbgzf.VirtualOffset{}
+			for j := 0; j < nIntervals && ir.err == nil; j++ {
+				reference.intervals = append(reference.intervals, ir.virtualOffset())
+			}
+		}
+		references = append(references, reference)
+	}
+	return references
+}
@@ -0,0 +1,65 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module parse_test tests module parse
+package htsindex
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseReadBAI tests ReadBAI function
+func TestParseReadBAI(t *testing.T) {
+	file, _ := os.Open(testBAIPath)
+	defer file.Close()
+	index, err := ReadBAI(file)
+	assert.Nil(t, err)
+	assert.Equal(t, 162, index.NumReferences())
+	assert.Equal(t, baiMinShift, index.minShift)
+	assert.Equal(t, baiDepth, index.depth)
+
+	// the pseudo-bin is not treated as a bin holding records
+	for _, reference := range index.references {
+		_, ok := reference.bins[index.pseudoBin()]
+		assert.False(t, ok)
+	}
+}
+
+// TestParseReadCSI tests ReadCSI function
+func TestParseReadCSI(t *testing.T) {
+	file, _ := os.Open(testCSIPath)
+	defer file.Close()
+	index, err := ReadCSI(file)
+	assert.Nil(t, err)
+	assert.Equal(t, 22, index.NumReferences())
+	assert.Equal(t, 14, index.minShift)
+	assert.Equal(t, 6, index.depth)
+	assert.NotEmpty(t, index.Query(0, 0, index.MaxPosition()))
+}
+
+// parseReadErrorTC test cases for invalid index data
+var parseReadErrorTC = []struct {
+	data []byte
+}{
+	{[]byte{}},
+	{[]byte("CSI\x01")},
+	{[]byte("BAI\x01\x02\x00\x00\x00")},
+	{[]byte("BAI\x01\xff\xff\xff\xff")},
+}
+
+// TestParseReadError tests that ReadBAI and ReadCSI reject invalid data
+func TestParseReadError(t *testing.T) {
+	for _, tc := range parseReadErrorTC {
+		_, err := ReadBAI(bytes.NewReader(tc.data))
+		assert.NotNil(t, err)
+		compressed, _ := htsbgzf.Compress(tc.data)
+		_, err = ReadCSI(bytes.NewReader(compressed))
+		assert.NotNil(t, err)
+	}
+}
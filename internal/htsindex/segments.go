@@ -0,0 +1,164 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module segments converts index chunks into the pieces of the source file
+// that, when concatenated, form a valid BGZF stream. whole BGZF blocks are
+// referenced by byte range, while the partial blocks at chunk boundaries are
+// recompressed and returned inline
+package htsindex
+
+import (
+	"io"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+// RangeReader provides ranged read access to the source file
+type RangeReader interface {
+	ReadByteRange(start int64, end int64) (io.ReadCloser, error)
+}
+
+// Segment a single piece of the response, either a byte range of the
+// source file or inline BGZF data
+//
+// Attributes
+//	Start (int64): first byte of the range (inclusive)
+//	End (int64): last byte of the range (inclusive)
+//	Data ([]byte): if not nil, inline BGZF data to use instead of a byte range
+type Segment struct {
+	Start int64
+	End   int64
+	Data  []byte
+}
+
+// IsInline checks if the segment holds inline data rather than a byte range
+//
+//	Type: Segment
+// Returns
+//	(bool): true if the segment holds inline data
+func (segment *Segment) IsInline() bool {
+	return segment.Data != nil
+}
+
+// newRangeSegment creates a byte range segment
+func newRangeSegment(start int64, end int64) *Segment {
+	segment := new(Segment)
+	segment.Start = start
+	segment.End = end
+	return segment
+}
+
+// newInlineSegment creates an inline segment by compressing data
+func newInlineSegment(data []byte) (*Segment, error) {
+	compressed, err := htsbgzf.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	segment := new(Segment)
+	segment.Data = compressed
+	return segment, nil
+}
+
+// readBlockAt reads the single BGZF block at a position of the source file
+func readBlockAt(source RangeReader, offset int64) (*htsbgzf.Block, error) {
+	r, err := source.ReadByteRange(offset, offset+htsbgzf.MaxBlockSize-1)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return htsbgzf.ReadBlock(r, offset)
+}
+
+// HeaderSegments gets the segments holding the file header, ie. all data
+// before the first record
+//
+// Arguments
+//	source (RangeReader): access to the source file
+//	headerEnd (htsbgzf.VirtualOffset): position of the first record
+// Returns
+//	([]*Segment): header segments
+//	(error): if not nil, the source file could not be read
+func HeaderSegments(source RangeReader, headerEnd htsbgzf.VirtualOffset) ([]*Segment, error) {
+	return ChunkSegments(source, []Chunk{{Begin: 0, End: headerEnd}})
+}
+
+// ChunkSegments gets the segments holding the data of the given chunks
+//
+// Arguments
+//	source (RangeReader): access to the source file
+//	chunks ([]Chunk): sorted, non-overlapping chunks
+// Returns
+//	([]*Segment): segments, in order
+//	(error): if not nil, the source file could not be read
+func ChunkSegments(source RangeReader, chunks []Chunk) ([]*Segment, error) {
+	segments := []*Segment{}
+	addInline := func(data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
+		segment, err := newInlineSegment(data)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, segment)
+		return nil
+	}
+
+	for _, chunk := range chunks {
+		begin, end := chunk.Begin, chunk.End
+		if end <= begin {
+			continue
+		}
+
+		// chunk begins and ends within the same block
+		if begin.Compressed() == end.Compressed() {
+			block, err := readBlockAt(source, begin.Compressed())
+			if err != nil {
+				return nil, err
+			}
+			if end.Uncompressed() > len(block.Data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if err := addInline(block.Data[begin.Uncompressed():end.Uncompressed()]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// leading partial block, from the first record onwards
+		start := begin.Compressed()
+		if begin.Uncompressed() > 0 {
+			block, err := readBlockAt(source, begin.Compressed())
+			if err != nil {
+				return nil, err
+			}
+			if begin.Uncompressed() < len(block.Data) {
+				if err := addInline(block.Data[begin.Uncompressed():]); err != nil {
+					return nil, err
+				}
+			}
+			start = block.NextOffset()
+		}
+
+		// whole blocks, returned as-is
+		if start < end.Compressed() {
+			segments = append(segments, newRangeSegment(start, end.Compressed()-1))
+		}
+
+		// trailing partial block, up to the end of the chunk
+		if end.Uncompressed() > 0 {
+			block, err := readBlockAt(source, end.Compressed())
+			if err != nil {
+				return nil, err
+			}
+			if end.Uncompressed() > len(block.Data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if err := addInline(block.Data[:end.Uncompressed()]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,348 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module segments_test tests module segments. the segments produced for a
+// region are joined into a BAM stream, whose decoded records are checked
+// against a full scan of the source file
+package htsindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRangeReader in-memory RangeReader
+type testRangeReader struct {
+	data []byte
+}
+
+func (source *testRangeReader) ReadByteRange(start int64, end int64) (io.ReadCloser, error) {
+	if end < 0 || end >= int64(len(source.data)) {
+		end = int64(len(source.data)) - 1
+	}
+	return ioutil.NopCloser(bytes.NewReader(source.data[start : end+1])), nil
+}
+
+// testRecord a decoded BAM record, with its position in the source file
+type testRecord struct {
+	refID    int
+	beg, end int64
+	raw      []byte
+}
+
+// readTestRecords decodes the header and all records of a BAM stream
+func readTestRecords(t *testing.T, data []byte) (*BAMHeader, []*testRecord) {
+	reader := htsbgzf.NewReader(bytes.NewReader(data), 0)
+	header, err := ReadBAMHeader(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*testRecord{}
+	for {
+		blockSize := make([]byte, 4)
+		if _, err := io.ReadFull(reader, blockSize); err != nil {
+			break
+		}
+		raw := make([]byte, binary.LittleEndian.Uint32(blockSize))
+		if _, err := io.ReadFull(reader, raw); err != nil {
+			t.Fatal("truncated record")
+		}
+		records = append(records, decodeTestRecord(raw))
+	}
+	return header, records
+}
+
+// decodeTestRecord gets the reference and span of a raw BAM record
+func decodeTestRecord(raw []byte) *testRecord {
+	record := &testRecord{raw: raw}
+	record.refID = int(int32(binary.LittleEndian.Uint32(raw[0:4])))
+	record.beg = int64(int32(binary.LittleEndian.Uint32(raw[4:8])))
+	lReadName := int(raw[8])
+	nCigar := int(binary.LittleEndian.Uint16(raw[12:14]))
+	flag := binary.LittleEndian.Uint16(raw[14:16])
+
+	// reference length from CIGAR ops consuming the reference (M, D, N, =, X)
+	refLen := int64(0)
+	for i := 0; i < nCigar; i++ {
+		op := binary.LittleEndian.Uint32(raw[32+lReadName+4*i:])
+		switch op & 0xf {
+		case 0, 2, 3, 7, 8:
+			refLen += int64(op >> 4)
+		}
+	}
+	if flag&0x4 != 0 || refLen == 0 {
+		refLen = 1
+	}
+	record.end = record.beg + refLen
+	return record
+}
+
+// reblockTestBAM recompresses the test BAM with small BGZF blocks, so that
+// the header and records straddle block boundaries, and builds a matching
+// BAI-style index in memory
+func reblockTestBAM(t *testing.T, blockDataSize int) ([]byte, *Index) {
+	file, _ := os.Open(testBAMPath)
+	defer file.Close()
+	gz, _ := gzip.NewReader(file)
+	data, _ := ioutil.ReadAll(gz)
+
+	// compress in fixed size pieces, recording the virtual offset of every
+	// uncompressed position
+	var compressed []byte
+	blockOffsets := []int64{}
+	for i := 0; i < len(data); i += blockDataSize {
+		end := i + blockDataSize
+		if end > len(data) {
+			end = len(data)
+		}
+		blockOffsets = append(blockOffsets, int64(len(compressed)))
+		block, _ := htsbgzf.Compress(data[i:end])
+		compressed = append(compressed, block...)
+	}
+	voffset := func(pos int) htsbgzf.VirtualOffset {
+		if pos == len(data) {
+			return htsbgzf.NewVirtualOffset(int64(len(compressed)), 0)
+		}
+		return htsbgzf.NewVirtualOffset(blockOffsets[pos/blockDataSize], pos%blockDataSize)
+	}
+	compressed = append(compressed, htsconstants.BamEOF...)
+
+	// index every record, locating records by walking the uncompressed data
+	header, records := readTestRecords(t, compressed)
+	index := newIndex(baiMinShift, baiDepth)
+	for range header.References {
+		index.references = append(index.references, newReferenceIndex())
+	}
+	pos := int(binary.LittleEndian.Uint32(data[4:8])) + 12
+	for range header.References {
+		pos += int(binary.LittleEndian.Uint32(data[pos:pos+4])) + 8
+	}
+	for _, record := range records {
+		chunk := Chunk{Begin: voffset(pos), End: voffset(pos + 4 + len(record.raw))}
+		pos += 4 + len(record.raw)
+		if record.refID < 0 {
+			continue
+		}
+		reference := index.references[record.refID]
+		id := testReg2bin(record.beg, record.end)
+		if _, ok := reference.bins[id]; !ok {
+			reference.bins[id] = new(bin)
+		}
+		reference.bins[id].chunks = append(reference.bins[id].chunks, chunk)
+		for w := int(record.beg >> baiMinShift); w <= int((record.end-1)>>baiMinShift); w++ {
+			for len(reference.intervals) <= w {
+				reference.intervals = append(reference.intervals, 0)
+			}
+			if reference.intervals[w] == 0 {
+				reference.intervals[w] = chunk.Begin
+			}
+		}
+	}
+	return compressed, index
+}
+
+// testReg2bin gets the smallest bin fully containing [beg, end)
+func testReg2bin(beg int64, end int64) uint32 {
+	end--
+	for level := baiDepth; level > 0; level-- {
+		shift := uint(baiMinShift + 3*(baiDepth-level))
+		if beg>>shift == end>>shift {
+			return binFirst(level) + uint32(beg>>shift)
+		}
+	}
+	return 0
+}
+
+// assembleSegments joins segments into a single stream, as a client would
+func assembleSegments(source *testRangeReader, segments []*Segment) []byte {
+	var joined []byte
+	for _, segment := range segments {
+		if segment.IsInline() {
+			joined = append(joined, segment.Data...)
+		} else {
+			joined = append(joined, source.data[segment.Start:segment.End+1]...)
+		}
+	}
+	return joined
+}
+
+// segmentsRegionTC regions to slice out of the test BAM
+var segmentsRegionTC = []struct {
+	referenceName string
+	beg, end      int64
+}{
+	{"chr1", 20000000, 30000000},
+	{"chr1", 0, 1 << 29},
+	{"chr17", 25830648, 25830649},
+	{"chr17", 30000000, 80000000},
+	{"chrM", 0, 16569},
+	{"chrX", 100000000, 1 << 29},
+	{"ERCC-00130", 0, 100},
+	{"chr19", 0, 1000},
+	{"chr5", 0, 1 << 29},
+}
+
+// TestSegmentsRegions tests HeaderSegments and ChunkSegments, asserting the
+// joined segments are a valid BAM stream containing every record
+// overlapping the region, in order, without duplicates
+func TestSegmentsRegions(t *testing.T) {
+	original, _ := ioutil.ReadFile(testBAMPath)
+	indexFile, _ := os.Open(testBAIPath)
+	originalIndex, _ := ReadBAI(indexFile)
+	indexFile.Close()
+
+	sources := []struct {
+		data  []byte
+		index *Index
+	}{
+		{original, originalIndex},
+	}
+	for _, blockDataSize := range []int{997, 4096, 20000} {
+		data, index := reblockTestBAM(t, blockDataSize)
+		sources = append(sources, struct {
+			data  []byte
+			index *Index
+		}{data, index})
+	}
+
+	for _, src := range sources {
+		source := &testRangeReader{data: src.data}
+		header, allRecords := readTestRecords(t, src.data)
+
+		for _, tc := range segmentsRegionTC {
+			refID := header.ReferenceID(tc.referenceName)
+			headerSegments, err := HeaderSegments(source, header.End)
+			assert.Nil(t, err)
+			bodySegments, err := ChunkSegments(source, src.index.Query(refID, tc.beg, tc.end))
+			assert.Nil(t, err)
+
+			joined := assembleSegments(source, append(headerSegments, bodySegments...))
+			joined = append(joined, htsconstants.BamEOF...)
+			joinedHeader, records := readTestRecords(t, joined)
+			assert.Equal(t, header.Text, joinedHeader.Text)
+
+			// returned records are a subsequence of the source records
+			i := 0
+			for _, record := range records {
+				for i < len(allRecords) && !bytes.Equal(allRecords[i].raw, record.raw) {
+					i++
+				}
+				assert.Less(t, i, len(allRecords))
+				i++
+			}
+
+			// all overlapping records are returned
+			returned := map[string]bool{}
+			for _, record := range records {
+				returned[string(record.raw)] = true
+			}
+			nExpected := 0
+			for _, record := range allRecords {
+				if record.refID == refID && record.beg < tc.end && record.end > tc.beg {
+					nExpected++
+					assert.True(t, returned[string(record.raw)])
+				}
+			}
+			assert.LessOrEqual(t, nExpected, len(records))
+		}
+	}
+}
+
+// TestSegmentsByteRanges tests that whole blocks are returned as byte ranges
+// of the source file, and only partial blocks are inlined
+func TestSegmentsByteRanges(t *testing.T) {
+	data, index := reblockTestBAM(t, 997)
+	source := &testRangeReader{data: data}
+	header, _ := readTestRecords(t, data)
+
+	headerSegments, err := HeaderSegments(source, header.End)
+	assert.Nil(t, err)
+	assert.False(t, headerSegments[0].IsInline())
+	assert.Equal(t, int64(0), headerSegments[0].Start)
+
+	segments, err := ChunkSegments(source, index.Query(header.ReferenceID("chr17"), 0, 1<<29))
+	assert.Nil(t, err)
+	nRanges := 0
+	for _, segment := range segments {
+		if segment.IsInline() {
+			assert.LessOrEqual(t, len(segment.Data), htsbgzf.MaxBlockSize)
+		} else {
+			nRanges++
+			block, err := htsbgzf.ReadBlock(bytes.NewReader(data[segment.Start:]), segment.Start)
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, block.NextOffset(), segment.End+1)
+		}
+	}
+	assert.Greater(t, nRanges, 0)
+}
+
+// segmentsVCFRegionTC regions to slice out of the test VCF
+var segmentsVCFRegionTC = []struct {
+	referenceName string
+	beg, end      int64
+}{
+	{"1", 0, 1 << 29},
+	{"10", 60000000, 90000000},
+	{"11", 70000000, 100000000},
+	{"22", 16050074, 16050075},
+	{"22", 50000000, 1 << 29},
+	{"X", 0, 1 << 29},
+}
+
+// TestSegmentsVCFRegions tests HeaderSegments and ChunkSegments on the test
+// VCF, with both its CSI index and a tabix index, asserting the joined
+// segments hold the header and every record overlapping the region
+func TestSegmentsVCFRegions(t *testing.T) {
+	data, _ := ioutil.ReadFile(testVCFPath)
+	source := &testRangeReader{data: data}
+	header, allRecords := readTestVCF(t, data)
+	headerEnd, _ := ReadVCFHeaderEnd(htsbgzf.NewReader(bytes.NewReader(data), 0))
+
+	csiFile, _ := os.Open(testCSIPath)
+	csiIndex, _ := ReadCSI(csiFile)
+	csiFile.Close()
+	tabixIndex, _ := ReadTabix(bytes.NewReader(writeTestTabix(testVCFReferenceNames, allRecords)))
+
+	for _, index := range []*Index{csiIndex, tabixIndex} {
+		for _, tc := range segmentsVCFRegionTC {
+			headerSegments, err := HeaderSegments(source, headerEnd)
+			assert.Nil(t, err)
+			bodySegments, err := ChunkSegments(source, index.Query(index.ReferenceID(tc.referenceName), tc.beg, tc.end))
+			assert.Nil(t, err)
+
+			joined := assembleSegments(source, append(headerSegments, bodySegments...))
+			joined = append(joined, htsconstants.BamEOF...)
+			joinedHeader, records := readTestVCF(t, joined)
+			assert.Equal(t, header, joinedHeader)
+
+			// returned records are a subsequence of the source records
+			i := 0
+			returned := map[string]bool{}
+			for _, record := range records {
+				for i < len(allRecords) && allRecords[i].line != record.line {
+					i++
+				}
+				assert.Less(t, i, len(allRecords))
+				i++
+				returned[record.line] = true
+			}
+
+			// all overlapping records are returned
+			for _, record := range allRecords {
+				if record.chrom == tc.referenceName && record.beg < tc.end && record.end > tc.beg {
+					assert.True(t, returned[record.line])
+				}
+			}
+		}
+	}
+}
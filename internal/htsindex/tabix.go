@@ -0,0 +1,70 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module tabix reads tabix index files, as produced for bgzipped VCF
+package htsindex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+var tabixMagic = []byte("TBI\x01")
+
+// readTabixHeader reads the tabix header (format, column and comment
+// settings, followed by the reference sequence names), which begins tabix
+// files after the magic, and makes up the auxiliary data of CSI files
+func readTabixHeader(r io.Reader) ([]string, error) {
+	ir := &indexReader{r: r}
+	// format, sequence/begin/end columns, meta char, lines to skip
+	for i := 0; i < 6; i++ {
+		ir.int32()
+	}
+	namesData := ir.read(ir.count())
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	names := []string{}
+	for _, name := range bytes.Split(bytes.TrimSuffix(namesData, []byte{0}), []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+// ReadTabix parses a tabix index file
+//
+// Arguments
+//	r (io.Reader): BGZF compressed tabix index data
+// Returns
+//	(*Index): the parsed index
+//	(error): if not nil, the data is not a valid tabix index
+func ReadTabix(r io.Reader) (*Index, error) {
+	ir := &indexReader{r: htsbgzf.NewReader(r, 0)}
+	ir.magic(tabixMagic)
+	nRefs := ir.count()
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	names, err := readTabixHeader(ir.r)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) != nRefs {
+		return nil, errors.New("reference names do not match reference count")
+	}
+
+	// the remainder is laid out as a BAI index, without the reference count
+	index := newIndex(baiMinShift, baiDepth)
+	index.names = names
+	index.references = ir.referenceIndices(index, nRefs, false, true)
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	return index, nil
+}
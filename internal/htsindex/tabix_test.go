@@ -0,0 +1,205 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module tabix_test tests module tabix. as no tabix index of the test VCF is
+// available, one is built from the records of the file
+package htsindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/stretchr/testify/assert"
+)
+
+// testVCFRecord a data line of a VCF file, with its position in the file
+type testVCFRecord struct {
+	chrom    string
+	beg, end int64
+	line     string
+	chunk    Chunk
+}
+
+// readTestVCF decompresses a bgzipped VCF, returning the header lines and
+// the data lines with the chunk each occupies
+func readTestVCF(t *testing.T, data []byte) ([]string, []*testVCFRecord) {
+	header := []string{}
+	records := []*testVCFRecord{}
+	var partial []byte
+	var partialBegin htsbgzf.VirtualOffset
+	for offset := int64(0); offset < int64(len(data)); {
+		block, err := htsbgzf.ReadBlock(bytes.NewReader(data[offset:]), offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for pos := 0; pos < len(block.Data); {
+			if partial == nil {
+				partialBegin = htsbgzf.NewVirtualOffset(offset, pos)
+			}
+			i := bytes.IndexByte(block.Data[pos:], '\n')
+			if i < 0 {
+				partial = append(partial, block.Data[pos:]...)
+				break
+			}
+			line := string(append(partial, block.Data[pos:pos+i]...))
+			partial = nil
+			pos += i + 1
+			end := htsbgzf.NewVirtualOffset(offset, pos)
+			if pos == len(block.Data) {
+				end = htsbgzf.NewVirtualOffset(block.NextOffset(), 0)
+			}
+			if strings.HasPrefix(line, "#") {
+				header = append(header, line)
+			} else {
+				records = append(records, newTestVCFRecord(line, Chunk{Begin: partialBegin, End: end}))
+			}
+		}
+		offset = block.NextOffset()
+	}
+	return header, records
+}
+
+// newTestVCFRecord gets the reference and 0-based span of a VCF data line
+func newTestVCFRecord(line string, chunk Chunk) *testVCFRecord {
+	columns := strings.SplitN(line, "\t", 5)
+	pos, _ := strconv.ParseInt(columns[1], 10, 64)
+	return &testVCFRecord{
+		chrom: columns[0],
+		beg:   pos - 1,
+		end:   pos - 1 + int64(len(columns[3])),
+		line:  line,
+		chunk: chunk,
+	}
+}
+
+// writeTestTabix builds the BGZF compressed tabix index of VCF records
+func writeTestTabix(names []string, records []*testVCFRecord) []byte {
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	writeInt := func(v int) {
+		binary.Write(&buf, le, int32(v))
+	}
+	buf.Write(tabixMagic)
+	writeInt(len(names))
+	nameData := []byte(strings.Join(names, "\x00") + "\x00")
+	for _, v := range []int{2, 1, 2, 0, '#', 0, len(nameData)} {
+		writeInt(v)
+	}
+	buf.Write(nameData)
+
+	for _, name := range names {
+		bins := map[uint32][]Chunk{}
+		intervals := []htsbgzf.VirtualOffset{}
+		for _, record := range records {
+			if record.chrom != name {
+				continue
+			}
+			id := testReg2bin(record.beg, record.end)
+			bins[id] = append(bins[id], record.chunk)
+			for w := int(record.beg >> baiMinShift); w <= int((record.end-1)>>baiMinShift); w++ {
+				for len(intervals) <= w {
+					intervals = append(intervals, 0)
+				}
+				if intervals[w] == 0 {
+					intervals[w] = record.chunk.Begin
+				}
+			}
+		}
+		ids := []int{}
+		for id := range bins {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+		writeInt(len(ids))
+		for _, id := range ids {
+			chunks := MergeChunks(bins[uint32(id)])
+			binary.Write(&buf, le, uint32(id))
+			writeInt(len(chunks))
+			for _, chunk := range chunks {
+				binary.Write(&buf, le, chunk)
+			}
+		}
+		writeInt(len(intervals))
+		binary.Write(&buf, le, intervals)
+	}
+	compressed, _ := htsbgzf.Compress(buf.Bytes())
+	return compressed
+}
+
+// testVCFReferenceNames the reference sequences of the test VCF
+var testVCFReferenceNames = []string{
+	"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11",
+	"12", "13", "14", "15", "16", "17", "18", "19", "20", "21", "22",
+}
+
+// tabixReferenceIDTC test cases for ReferenceID
+var tabixReferenceIDTC = []struct {
+	name  string
+	expID int
+}{
+	{"1", 0},
+	{"10", 9},
+	{"22", 21},
+	{"X", -1},
+	{"chr1", -1},
+}
+
+// TestTabixReadTabix tests ReadTabix function
+func TestTabixReadTabix(t *testing.T) {
+	data, _ := ioutil.ReadFile(testVCFPath)
+	_, records := readTestVCF(t, data)
+	index, err := ReadTabix(bytes.NewReader(writeTestTabix(testVCFReferenceNames, records)))
+	assert.Nil(t, err)
+	assert.Equal(t, 22, index.NumReferences())
+	assert.Equal(t, testVCFReferenceNames, index.names)
+	for _, tc := range tabixReferenceIDTC {
+		assert.Equal(t, tc.expID, index.ReferenceID(tc.name))
+	}
+	assert.NotEmpty(t, index.Query(index.ReferenceID("22"), 0, index.MaxPosition()))
+}
+
+// TestTabixCSIReferenceNames tests that reference names are read from the
+// tabix metadata of CSI indices
+func TestTabixCSIReferenceNames(t *testing.T) {
+	file, _ := os.Open(testCSIPath)
+	defer file.Close()
+	index, err := ReadCSI(file)
+	assert.Nil(t, err)
+	assert.Equal(t, testVCFReferenceNames, index.names)
+	for _, tc := range tabixReferenceIDTC {
+		assert.Equal(t, tc.expID, index.ReferenceID(tc.name))
+	}
+
+	// BAM indices hold no reference names
+	file, _ = os.Open(testBAIPath)
+	defer file.Close()
+	index, _ = ReadBAI(file)
+	assert.Equal(t, -1, index.ReferenceID("chr1"))
+}
+
+// TestTabixReadTabixError tests that ReadTabix rejects invalid data
+func TestTabixReadTabixError(t *testing.T) {
+	for _, data := range [][]byte{
+		writeTestTabix(testVCFReferenceNames, nil)[:40],
+		[]byte("TBI\x01"),
+	} {
+		_, err := ReadTabix(bytes.NewReader(data))
+		assert.NotNil(t, err)
+	}
+
+	// reference count not matching the names
+	valid := writeTestTabix([]string{"1", "2"}, nil)
+	block, _ := htsbgzf.ReadBlock(bytes.NewReader(valid), 0)
+	binary.LittleEndian.PutUint32(block.Data[4:8], 3)
+	mismatched, _ := htsbgzf.Compress(block.Data)
+	_, err := ReadTabix(bytes.NewReader(mismatched))
+	assert.NotNil(t, err)
+}
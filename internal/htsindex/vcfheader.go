@@ -0,0 +1,46 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module vcfheader locates the end of the header of a bgzipped VCF file
+package htsindex
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+)
+
+// ReadVCFHeaderEnd reads the meta-information and header lines ('#'
+// prefixed) from the start of a bgzipped VCF file
+//
+// Arguments
+//	reader (*htsbgzf.Reader): reader positioned at the start of the VCF file
+// Returns
+//	(htsbgzf.VirtualOffset): position of the first data line, or of the end
+//		of the file if it holds no data lines
+//	(error): if not nil, the data is not a valid bgzipped VCF file
+func ReadVCFHeaderEnd(reader *htsbgzf.Reader) (htsbgzf.VirtualOffset, error) {
+	c := make([]byte, 1)
+	lineStart := true
+	for {
+		position := reader.VirtualOffset()
+		if _, err := reader.Read(c); err != nil {
+			if err == io.EOF && position > 0 {
+				return position, nil
+			}
+			if err == io.EOF {
+				err = errors.New("not a VCF file")
+			}
+			return 0, err
+		}
+		if lineStart && c[0] != '#' {
+			if position == 0 {
+				return 0, errors.New("not a VCF file")
+			}
+			return position, nil
+		}
+		lineStart = c[0] == '\n'
+	}
+}
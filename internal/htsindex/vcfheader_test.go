@@ -0,0 +1,84 @@
+// Package htsindex parses genomic file indices (BAI, CSI, tabix), resolving
+// genomic regions to the BGZF compressed chunks of the indexed file
+// containing overlapping records
+//
+// Module vcfheader_test tests module vcfheader
+package htsindex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// path to test bgzipped VCF file
+var testVCFPath = "../../data/test/sources/giab/HG002_GIAB.filtered.vcf.gz"
+
+// vcfHeaderTC test cases for ReadVCFHeaderEnd. the expected position is
+// given as the index of a block, and the offset within its data
+var vcfHeaderTC = []struct {
+	blocks          [][]byte
+	expBlock        int
+	expUncompressed int
+}{
+	// header and data in the same block
+	{[][]byte{[]byte("##fileformat=VCFv4.2\n#CHROM\n1\t100\n")}, 0, 28},
+	// header ending at a block boundary
+	{[][]byte{[]byte("##fileformat=VCFv4.2\n#CHROM\n"), []byte("1\t100\n")}, 1, 0},
+	// header spanning blocks
+	{[][]byte{[]byte("##fileformat=VCFv4.2\n#CH"), []byte("ROM\n1\t100\n")}, 1, 4},
+	// header only, ending at the EOF marker
+	{[][]byte{[]byte("##fileformat=VCFv4.2\n#CHROM\n")}, 1, 0},
+}
+
+// compressTestBlocks compresses each piece of data into its own block,
+// followed by the EOF marker. the offsets of all blocks are returned
+func compressTestBlocks(blocks [][]byte) ([]byte, []int64) {
+	var compressed []byte
+	offsets := []int64{}
+	for _, data := range blocks {
+		offsets = append(offsets, int64(len(compressed)))
+		block, _ := htsbgzf.Compress(data)
+		compressed = append(compressed, block...)
+	}
+	offsets = append(offsets, int64(len(compressed)))
+	return append(compressed, htsconstants.BamEOF...), offsets
+}
+
+// TestVCFHeaderReadVCFHeaderEnd tests ReadVCFHeaderEnd function
+func TestVCFHeaderReadVCFHeaderEnd(t *testing.T) {
+	for _, tc := range vcfHeaderTC {
+		data, offsets := compressTestBlocks(tc.blocks)
+		end, err := ReadVCFHeaderEnd(htsbgzf.NewReader(bytes.NewReader(data), 0))
+		assert.Nil(t, err)
+		assert.Equal(t, vo(offsets[tc.expBlock], tc.expUncompressed), end)
+	}
+}
+
+// TestVCFHeaderReadVCFHeaderEndFile tests ReadVCFHeaderEnd on the test VCF,
+// asserting the first data line begins at the returned position
+func TestVCFHeaderReadVCFHeaderEndFile(t *testing.T) {
+	file, _ := os.Open(testVCFPath)
+	defer file.Close()
+	end, err := ReadVCFHeaderEnd(htsbgzf.NewReader(file, 0))
+	assert.Nil(t, err)
+
+	data, _ := ioutil.ReadFile(testVCFPath)
+	block, _ := htsbgzf.ReadBlock(bytes.NewReader(data[end.Compressed():]), end.Compressed())
+	assert.Equal(t, "1\t", string(block.Data[end.Uncompressed():end.Uncompressed()+2]))
+}
+
+// TestVCFHeaderReadVCFHeaderEndError tests ReadVCFHeaderEnd on invalid data
+func TestVCFHeaderReadVCFHeaderEndError(t *testing.T) {
+	notVCF, _ := compressTestBlocks([][]byte{[]byte("BAM\x01")})
+	empty, _ := compressTestBlocks(nil)
+	for _, data := range [][]byte{notVCF, empty, []byte("##fileformat=VCFv4.2\n")} {
+		_, err := ReadVCFHeaderEnd(htsbgzf.NewReader(bytes.NewReader(data), 0))
+		assert.NotNil(t, err)
+	}
+}
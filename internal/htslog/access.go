@@ -0,0 +1,96 @@
+// Package htslog writes structured, leveled logs as one JSON object per line
+//
+// Module access.go contains the access log entry, populated over the course
+// of a request and written once the response is complete
+package htslog
+
+import (
+	"context"
+	"sync"
+)
+
+// AccessEntry properties of a single request, collected by the handlers and
+// subprocesses serving it. methods are safe to call on a nil entry, making
+// logging optional for callers
+//
+// Attributes
+//	fields (Fields): properties collected so far
+//	exitCodes ([]Fields): command and exit code of each subprocess run
+type AccessEntry struct {
+	mu        sync.Mutex
+	fields    Fields
+	exitCodes []Fields
+}
+
+// NewAccessEntry instantiates a new AccessEntry for the request
+func NewAccessEntry(requestID string) *AccessEntry {
+	return &AccessEntry{
+		fields: Fields{"requestId": requestID},
+	}
+}
+
+// Set sets a property of the request
+func (entry *AccessEntry) Set(key string, value interface{}) {
+	if entry == nil {
+		return
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.fields[key] = value
+}
+
+// Get gets a property of the request, nil if unset
+func (entry *AccessEntry) Get(key string) interface{} {
+	if entry == nil {
+		return nil
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return entry.fields[key]
+}
+
+// AddExitCode records the exit code of a subprocess run for the request
+//
+// Arguments
+//	command (string): base command of the subprocess, e.g. samtools
+//	exitCode (int): exit code, -1 if it did not start or was killed by a signal
+func (entry *AccessEntry) AddExitCode(command string, exitCode int) {
+	if entry == nil {
+		return
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.exitCodes = append(entry.exitCodes, Fields{"command": command, "exitCode": exitCode})
+}
+
+// Fields gets a copy of all properties of the request, including the
+// subprocess exit codes if any were recorded
+func (entry *AccessEntry) Fields() Fields {
+	if entry == nil {
+		return Fields{}
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	fields := make(Fields, len(entry.fields)+1)
+	for key, value := range entry.fields {
+		fields[key] = value
+	}
+	if len(entry.exitCodes) > 0 {
+		fields["exitCodes"] = append([]Fields(nil), entry.exitCodes...)
+	}
+	return fields
+}
+
+// accessEntryKey context key the request's access entry is stored under
+type accessEntryKey struct{}
+
+// NewContext attaches the access entry to the context
+func NewContext(ctx context.Context, entry *AccessEntry) context.Context {
+	return context.WithValue(ctx, accessEntryKey{}, entry)
+}
+
+// FromContext gets the access entry attached to the context, nil if none is
+func FromContext(ctx context.Context) *AccessEntry {
+	entry, _ := ctx.Value(accessEntryKey{}).(*AccessEntry)
+	return entry
+}
@@ -0,0 +1,47 @@
+// Package htslog writes structured, leveled logs as one JSON object per line
+//
+// Module access_test tests module access
+package htslog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAccessEntry tests Set, Get, AddExitCode and Fields functions
+func TestAccessEntry(t *testing.T) {
+	entry := NewAccessEntry("abc")
+	assert.Equal(t, Fields{"requestId": "abc"}, entry.Fields())
+
+	entry.Set("status", 200)
+	assert.Equal(t, 200, entry.Get("status"))
+	entry.AddExitCode("samtools", 0)
+	entry.AddExitCode("bcftools", 1)
+	fields := entry.Fields()
+	assert.Equal(t, []Fields{
+		{"command": "samtools", "exitCode": 0},
+		{"command": "bcftools", "exitCode": 1},
+	}, fields["exitCodes"])
+
+	// the copy is unaffected by later changes
+	entry.Set("status", 500)
+	assert.Equal(t, 200, fields["status"])
+}
+
+// TestAccessEntryNil tests methods can be called on a nil entry
+func TestAccessEntryNil(t *testing.T) {
+	var entry *AccessEntry
+	entry.Set("status", 200)
+	entry.AddExitCode("samtools", 0)
+	assert.Nil(t, entry.Get("status"))
+	assert.Equal(t, Fields{}, entry.Fields())
+}
+
+// TestAccessEntryContext tests NewContext and FromContext functions
+func TestAccessEntryContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	entry := NewAccessEntry("abc")
+	assert.Equal(t, entry, FromContext(NewContext(context.Background(), entry)))
+}
@@ -0,0 +1,187 @@
+// Package htslog writes structured, leveled logs as one JSON object per line
+//
+// Module htslog.go contains the logger, and the process-wide logger used by
+// the package level functions
+package htslog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level severity of a log entry, entries below the logger's level are
+// discarded
+type Level int
+
+// enum values for Level
+const (
+	LevelDebug Level = 0
+	LevelInfo  Level = 1
+	LevelWarn  Level = 2
+	LevelError Level = 3
+)
+
+// maps enum int values to string representation
+var levelStringMap = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String gets the representation of the level, as written to the log
+func (level Level) String() string {
+	return levelStringMap[level]
+}
+
+// ParseLevel gets the level by its name, case-insensitively
+//
+// Arguments
+//	name (string): level name, one of debug, info, warn or error
+// Returns
+//	(Level): the named level
+//	(error): if not nil, the name is not a level
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelStringMap {
+		if strings.EqualFold(levelName, name) {
+			return level, nil
+		}
+	}
+	return LevelInfo, errors.New("unknown log level '" + name + "', expected debug, info, warn or error")
+}
+
+// Fields properties of a log entry, in addition to its time, level and message
+type Fields map[string]interface{}
+
+// Logger writes entries at or above its level as JSON lines
+//
+// Attributes
+//	out (io.Writer): entries are written to this writer
+//	level (Level): minimum level of entries written
+//	now (func() time.Time): gets the time of an entry
+type Logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level Level
+	now   func() time.Time
+}
+
+// NewLogger instantiates a new Logger
+func NewLogger(out io.Writer, level Level) *Logger {
+	return &Logger{
+		out:   out,
+		level: level,
+		now:   time.Now,
+	}
+}
+
+// OpenLogger opens a logger writing to the log file, created if it doesn't
+// exist and appended to otherwise
+//
+// Arguments
+//	logFile (string): path to the log file, stdout if empty or "-"
+//	levelName (string): name of the minimum level of entries written
+// Returns
+//	(*Logger): logger writing to the log file
+//	(error): if not nil, the level is unknown or the file cannot be opened
+func OpenLogger(logFile string, levelName string) (*Logger, error) {
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return nil, err
+	}
+	if logFile == "" || logFile == "-" {
+		return NewLogger(os.Stdout, level), nil
+	}
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(file, level), nil
+}
+
+// Enabled checks if entries at the level are written
+func (logger *Logger) Enabled(level Level) bool {
+	return level >= logger.level
+}
+
+// Log writes an entry, if at or above the logger's level. the time, level
+// and message take precedence over fields of the same name
+//
+// Arguments
+//	level (Level): severity of the entry
+//	msg (string): message describing the entry
+//	fields (Fields): properties of the entry, may be nil
+func (logger *Logger) Log(level Level, msg string, fields Fields) {
+	if !logger.Enabled(level) {
+		return
+	}
+	entry := make(Fields, len(fields)+3)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["time"] = logger.now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(Fields{
+			"time":  entry["time"],
+			"level": LevelError.String(),
+			"msg":   "could not encode log entry '" + msg + "': " + err.Error(),
+		})
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.out.Write(append(line, '\n'))
+}
+
+// std logger used by the package level functions, stdout at info level
+// until replaced
+var std struct {
+	sync.RWMutex
+	logger *Logger
+}
+
+func init() {
+	std.logger = NewLogger(os.Stdout, LevelInfo)
+}
+
+// SetLogger replaces the logger used by the package level functions
+func SetLogger(logger *Logger) {
+	std.Lock()
+	defer std.Unlock()
+	std.logger = logger
+}
+
+// GetLogger gets the logger used by the package level functions
+func GetLogger() *Logger {
+	std.RLock()
+	defer std.RUnlock()
+	return std.logger
+}
+
+// Debug writes a debug entry with the process-wide logger
+func Debug(msg string, fields Fields) {
+	GetLogger().Log(LevelDebug, msg, fields)
+}
+
+// Info writes an info entry with the process-wide logger
+func Info(msg string, fields Fields) {
+	GetLogger().Log(LevelInfo, msg, fields)
+}
+
+// Warn writes a warn entry with the process-wide logger
+func Warn(msg string, fields Fields) {
+	GetLogger().Log(LevelWarn, msg, fields)
+}
+
+// Error writes an error entry with the process-wide logger
+func Error(msg string, fields Fields) {
+	GetLogger().Log(LevelError, msg, fields)
+}
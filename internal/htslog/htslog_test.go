@@ -0,0 +1,123 @@
+// Package htslog writes structured, leveled logs as one JSON object per line
+//
+// Module htslog_test tests module htslog
+package htslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// htslogParseLevelTC test cases for ParseLevel
+var htslogParseLevelTC = []struct {
+	name     string
+	expLevel Level
+	expError bool
+}{
+	{"debug", LevelDebug, false},
+	{"info", LevelInfo, false},
+	{"WARN", LevelWarn, false},
+	{"Error", LevelError, false},
+	{"verbose", LevelInfo, true},
+	{"", LevelInfo, true},
+}
+
+// htslogLogTC test cases for Log
+var htslogLogTC = []struct {
+	level    Level
+	msg      string
+	fields   Fields
+	expEntry map[string]interface{}
+}{
+	{LevelDebug, "discarded", nil, nil},
+	{LevelInfo, "request", Fields{"status": 200}, map[string]interface{}{"time": "2020-01-02T03:04:05Z", "level": "info", "msg": "request", "status": 200.0}},
+	{LevelError, "failed", Fields{"msg": "overridden", "level": "debug"}, map[string]interface{}{"time": "2020-01-02T03:04:05Z", "level": "error", "msg": "failed"}},
+	{LevelWarn, "unencodable", Fields{"value": make(chan int)}, map[string]interface{}{"time": "2020-01-02T03:04:05Z", "level": "error", "msg": "could not encode log entry 'unencodable': json: unsupported type: chan int"}},
+}
+
+// newTestLogger creates a logger at info level with a fixed time
+func newTestLogger(out *bytes.Buffer) *Logger {
+	logger := NewLogger(out, LevelInfo)
+	logger.now = func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	return logger
+}
+
+// TestParseLevel tests ParseLevel function
+func TestParseLevel(t *testing.T) {
+	for _, tc := range htslogParseLevelTC {
+		level, err := ParseLevel(tc.name)
+		assert.Equal(t, tc.expLevel, level)
+		assert.Equal(t, tc.expError, err != nil)
+	}
+}
+
+// TestLog tests Log function
+func TestLog(t *testing.T) {
+	for _, tc := range htslogLogTC {
+		out := new(bytes.Buffer)
+		newTestLogger(out).Log(tc.level, tc.msg, tc.fields)
+		if tc.expEntry == nil {
+			assert.Equal(t, "", out.String())
+			continue
+		}
+		assert.True(t, strings.HasSuffix(out.String(), "}\n"))
+		assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, tc.expEntry, entry)
+	}
+}
+
+// TestOpenLogger tests OpenLogger function
+func TestOpenLogger(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "htsget-log")
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "htsget.log")
+
+	// entries are appended to the log file
+	for i := 0; i < 2; i++ {
+		logger, err := OpenLogger(logFile, "warn")
+		assert.Nil(t, err)
+		logger.Log(LevelInfo, "discarded", nil)
+		logger.Log(LevelWarn, "written", nil)
+	}
+	content, _ := ioutil.ReadFile(logFile)
+	assert.Equal(t, 2, strings.Count(string(content), "\"msg\":\"written\""))
+	assert.NotContains(t, string(content), "discarded")
+
+	logger, err := OpenLogger("-", "debug")
+	assert.Nil(t, err)
+	assert.Equal(t, os.Stdout, logger.out)
+	assert.True(t, logger.Enabled(LevelDebug))
+
+	_, err = OpenLogger(logFile, "verbose")
+	assert.NotNil(t, err)
+	_, err = OpenLogger(filepath.Join(dir, "missing", "htsget.log"), "info")
+	assert.NotNil(t, err)
+}
+
+// TestSetLogger tests the package level functions write with the logger set
+func TestSetLogger(t *testing.T) {
+	previous := GetLogger()
+	defer SetLogger(previous)
+	out := new(bytes.Buffer)
+	SetLogger(newTestLogger(out))
+
+	Debug("debug", nil)
+	Info("info", nil)
+	Warn("warn", nil)
+	Error("error", Fields{"id": "object"})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[2], "\"id\":\"object\"")
+}
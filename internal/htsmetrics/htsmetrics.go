@@ -0,0 +1,127 @@
+// Package htsmetrics collects server metrics, and exposes them in the
+// Prometheus text format
+//
+// Module htsmetrics.go contains the metrics recorded while serving requests
+package htsmetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultRegistry registry of all server metrics, served at /metrics
+var DefaultRegistry = prometheus.NewRegistry()
+
+// durationBuckets upper bounds (seconds) of request and subprocess duration
+// histograms
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// blockBuckets upper bounds of the ticket block count histogram
+var blockBuckets = []float64{1, 2, 3, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// newCounterVec creates a counter vector and registers it with the default
+// registry
+func newCounterVec(name string, help string, labels ...string) *prometheus.CounterVec {
+	counterVec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	DefaultRegistry.MustRegister(counterVec)
+	return counterVec
+}
+
+// newHistogramVec creates a histogram vector with the given bucket upper
+// bounds and registers it with the default registry
+func newHistogramVec(name string, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	histogramVec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	DefaultRegistry.MustRegister(histogramVec)
+	return histogramVec
+}
+
+// Handler serves all metrics in the default registry in the Prometheus text
+// format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(DefaultRegistry, promhttp.HandlerOpts{})
+}
+
+// Requests requests served, by endpoint and HTTP status
+var Requests = newCounterVec(
+	"htsget_requests_total",
+	"Requests served, by endpoint and HTTP status.",
+	"endpoint", "status",
+)
+
+// RequestDuration time taken to serve requests, by endpoint
+var RequestDuration = newHistogramVec(
+	"htsget_request_duration_seconds",
+	"Time taken to serve requests, by endpoint.",
+	durationBuckets,
+	"endpoint",
+)
+
+// Errors htsget errors returned, by endpoint and htsget error type
+var Errors = newCounterVec(
+	"htsget_errors_total",
+	"htsget errors returned, by endpoint and htsget error type.",
+	"endpoint", "error",
+)
+
+// TicketBlocks number of blocks (urls) in the tickets returned, by endpoint
+var TicketBlocks = newHistogramVec(
+	"htsget_ticket_blocks",
+	"Number of blocks (urls) in the tickets returned, by endpoint.",
+	blockBuckets,
+	"endpoint",
+)
+
+// StreamedBytes bytes streamed from samtools/bcftools to clients, by endpoint
+var StreamedBytes = newCounterVec(
+	"htsget_streamed_bytes_total",
+	"Bytes streamed from samtools/bcftools to clients, by endpoint.",
+	"endpoint",
+)
+
+// SubprocessDuration time taken by subprocesses to exit, by command
+var SubprocessDuration = newHistogramVec(
+	"htsget_subprocess_duration_seconds",
+	"Time taken by subprocesses to exit, by command.",
+	durationBuckets,
+	"command",
+)
+
+// SubprocessFailures subprocesses that could not be started or exited with a
+// non-zero code, by command
+var SubprocessFailures = newCounterVec(
+	"htsget_subprocess_failures_total",
+	"Subprocesses that could not be started or exited with a non-zero code, by command.",
+	"command",
+)
+
+// ObserveRequest records a request that has been served
+//
+// Arguments
+//	endpoint (string): route of the endpoint, e.g. /reads/{id}*
+//	status (int): HTTP status of the response
+//	htsgetError (string): htsget error type returned, empty if none was
+//	duration (time.Duration): time taken to serve the request
+func ObserveRequest(endpoint string, status int, htsgetError string, duration time.Duration) {
+	Requests.WithLabelValues(endpoint, strconv.Itoa(status)).Inc()
+	RequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+	if htsgetError != "" {
+		Errors.WithLabelValues(endpoint, htsgetError).Inc()
+	}
+}
+
+// ObserveSubprocess records a subprocess that has exited
+//
+// Arguments
+//	command (string): base command of the subprocess, e.g. samtools
+//	exitCode (int): exit code, -1 if it did not start or was killed by a signal
+//	duration (time.Duration): time from the subprocess starting to exiting
+func ObserveSubprocess(command string, exitCode int, duration time.Duration) {
+	SubprocessDuration.WithLabelValues(command).Observe(duration.Seconds())
+	if exitCode != 0 {
+		SubprocessFailures.WithLabelValues(command).Inc()
+	}
+}
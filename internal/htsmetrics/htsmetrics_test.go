@@ -0,0 +1,55 @@
+// Package htsmetrics collects server metrics, and exposes them in the
+// Prometheus text format
+//
+// Module htsmetrics_test tests module htsmetrics
+package htsmetrics
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleCount gets the number of observations made by a histogram
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	metric := new(dto.Metric)
+	assert.Nil(t, observer.(prometheus.Metric).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+// TestObserveRequest tests ObserveRequest function
+func TestObserveRequest(t *testing.T) {
+	endpoint := "/test/observe-request"
+	ObserveRequest(endpoint, 200, "", time.Second)
+	ObserveRequest(endpoint, 404, "NotFound", time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(Requests.WithLabelValues(endpoint, "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(Requests.WithLabelValues(endpoint, "404")))
+	assert.Equal(t, uint64(2), sampleCount(t, RequestDuration.WithLabelValues(endpoint)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(Errors.WithLabelValues(endpoint, "NotFound")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(Errors.WithLabelValues(endpoint, "")))
+}
+
+// TestObserveSubprocess tests ObserveSubprocess function
+func TestObserveSubprocess(t *testing.T) {
+	command := "test-observe-subprocess"
+	ObserveSubprocess(command, 0, time.Second)
+	ObserveSubprocess(command, 1, time.Second)
+	ObserveSubprocess(command, -1, 0)
+	assert.Equal(t, uint64(3), sampleCount(t, SubprocessDuration.WithLabelValues(command)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(SubprocessFailures.WithLabelValues(command)))
+}
+
+// TestHandler tests Handler function
+func TestHandler(t *testing.T) {
+	StreamedBytes.WithLabelValues("/test/handler").Add(10)
+	writer := httptest.NewRecorder()
+	Handler().ServeHTTP(writer, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, writer.Code)
+	assert.Contains(t, writer.Body.String(), "# TYPE htsget_streamed_bytes_total counter")
+	assert.Contains(t, writer.Body.String(), `htsget_streamed_bytes_total{endpoint="/test/handler"} 10`)
+}
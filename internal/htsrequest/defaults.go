@@ -12,14 +12,21 @@ var defaultClass = ""
 var defaultReferenceName = ""
 var defaultStart = -1
 var defaultEnd = -1
+var defaultMinStart = -1
+var defaultHeaderBytes = -1
 var defaultFields = []string{"ALL"}
 var defaultTags = []string{"ALL"}
 var defaultNoTags = []string{"NONE"}
+var defaultSamples = []string{"ALL"}
+var defaultMinMappingQuality = -1
+var defaultRequiredFlags = -1
+var defaultExcludedFlags = -1
+var defaultReadGroups = []string{"ALL"}
+var defaultLibrary = ""
 var defaultRegions = []*Region{}
 var defaultHtsgetBlockClass = ""
 var defaultHtsgetCurrentBlock = "0"
 var defaultHtsgetTotalBlocks = "1"
-var defaultHtsgetFilePath = ""
 var defaultHtsgetRange = ""
 
 /*
@@ -0,0 +1,65 @@
+// Package htsrequest provides operations for parsing htsget-related
+// parameters from the HTTP request, and performing validation and
+// transformation
+//
+// Module header reads the header of the requested object, which parameters
+// such as referenceName are validated against. the header is read once per
+// request, however many parameters depend on it
+package htsrequest
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+)
+
+// HeaderReader reads the plain text header of the requested object, as output
+// by 'samtools view -H' for reads and 'bcftools view -h' for variants
+type HeaderReader func(htsgetReq *HtsgetRequest) ([]byte, error)
+
+// IsReadsEndpoint checks if the endpoint serves alignment files
+func IsReadsEndpoint(endpoint htsconstants.APIEndpoint) bool {
+	switch endpoint {
+	case htsconstants.APIEndpointReadsTicket, htsconstants.APIEndpointReadsData:
+		return true
+	default:
+		return false
+	}
+}
+
+// readHeaderFromPath the default HeaderReader, in which samtools/bcftools read
+// the object from its path
+func readHeaderFromPath(htsgetReq *HtsgetRequest) ([]byte, error) {
+	objPath, err := htsgetReq.GetObjectPath()
+	if err != nil {
+		return nil, err
+	}
+	if IsReadsEndpoint(htsgetReq.GetEndpoint()) {
+		return exec.Command("samtools", "view", "-H", objPath).Output()
+	}
+	return exec.Command("bcftools", "view", "-h", objPath).Output()
+}
+
+// SetHeaderReader sets how the header of the requested object is read
+func (r *HtsgetRequest) SetHeaderReader(headerReader HeaderReader) {
+	r.headerReader = headerReader
+}
+
+// getHeaderLines gets the lines of the requested object's header, reading it
+// on first use. a failed read is not retried
+func (r *HtsgetRequest) getHeaderLines() ([]string, error) {
+	if r.headerLines == nil && r.headerErr == nil {
+		headerReader := r.headerReader
+		if headerReader == nil {
+			headerReader = readHeaderFromPath
+		}
+		header, err := headerReader(r)
+		if err != nil {
+			r.headerErr = err
+		} else {
+			r.headerLines = strings.Split(strings.TrimRight(string(header), "\n"), "\n")
+		}
+	}
+	return r.headerLines, r.headerErr
+}
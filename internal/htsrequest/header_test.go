@@ -0,0 +1,153 @@
+package htsrequest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+var headerTestReads = "@HD\tVN:1.4\tSO:coordinate\n" +
+	"@SQ\tSN:chr1\tLN:195471971\n" +
+	"@SQ\tSN:chr2\tLN:182113224\n"
+
+var headerTestVariants = "##fileformat=VCFv4.2\n" +
+	"##contig=<ID=1,length=249250621>\n" +
+	"##contig=<ID=X>\n" +
+	"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tHG002\tHG003,HG004\t^HG005\n"
+
+// countingHeaderReader returns a HeaderReader serving the header, counting
+// how many times it was read
+func countingHeaderReader(header string, err error, count *int) HeaderReader {
+	return func(htsgetReq *HtsgetRequest) ([]byte, error) {
+		*count++
+		if err != nil {
+			return nil, err
+		}
+		return []byte(header), nil
+	}
+}
+
+// TestGetReferenceNamesFromHeader tests reference names are parsed from the
+// header read by the request's HeaderReader, which is only read once
+func TestGetReferenceNamesFromHeader(t *testing.T) {
+	count := 0
+	r := NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+	r.SetHeaderReader(countingHeaderReader(headerTestReads, nil, &count))
+	referenceNames, err := getReferenceNames(r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"chr1", "chr2"}, referenceNames)
+	result, _ := paramValidator.ValidateReferenceName(r, "chr2")
+	assert.True(t, result)
+	assert.Equal(t, 1, count)
+
+	count = 0
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointVariantsData)
+	r.SetHeaderReader(countingHeaderReader(headerTestVariants, nil, &count))
+	referenceNames, err = getReferenceNames(r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "X"}, referenceNames)
+
+	// failed reads are not retried
+	count = 0
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointReadsData)
+	r.SetHeaderReader(countingHeaderReader("", errors.New("not found"), &count))
+	_, err = getReferenceNames(r)
+	assert.EqualError(t, err, "Could not get referenceNames from requested alignment file")
+	result, _ = paramValidator.ValidateReferenceName(r, "chr1")
+	assert.False(t, result)
+	assert.Equal(t, 1, count)
+}
+
+// validateSamplesHeaderTC test cases for ValidateSamples, against the samples
+// of headerTestVariants
+var validateSamplesHeaderTC = []struct {
+	samples []string
+	exp     bool
+}{
+	{[]string{"HG002"}, true},
+	{[]string{"HG003"}, false},
+	{[]string{"HG003,HG004"}, false},
+	{[]string{"HG002", "HG003,HG004"}, false},
+	{[]string{"^HG005"}, false},
+}
+
+// TestGetSampleNamesFromHeader tests sample names are parsed from the #CHROM
+// line of the header, which is only read once
+func TestGetSampleNamesFromHeader(t *testing.T) {
+	count := 0
+	r := NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+	r.SetHeaderReader(countingHeaderReader(headerTestVariants, nil, &count))
+	sampleNames, err := getSampleNamesInVariantsObject(r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"HG002", "HG003,HG004", "^HG005"}, sampleNames)
+	for _, tc := range validateSamplesHeaderTC {
+		result, _ := paramValidator.ValidateSamples(r, tc.samples)
+		assert.Equal(t, tc.exp, result, tc.samples)
+	}
+	assert.Equal(t, 1, count)
+
+	// a header without samples
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+	r.SetHeaderReader(countingHeaderReader("##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n", nil, &count))
+	sampleNames, err = getSampleNamesInVariantsObject(r)
+	assert.Nil(t, err)
+	assert.Empty(t, sampleNames)
+
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+	r.SetHeaderReader(countingHeaderReader("", errors.New("not found"), &count))
+	_, err = getSampleNamesInVariantsObject(r)
+	assert.EqualError(t, err, "Could not get samples from requested variant file")
+}
+
+var headerTestReadGroups = headerTestReads +
+	"@RG\tID:rg1\tLB:lib1\tSM:sample1\n" +
+	"@RG\tID:rg2\tSM:sample1\n" +
+	"@RG\tID:rg\"3\tLB:lib2\n"
+
+// validateReadGroupsHeaderTC test cases for ValidateReadGroups and
+// ValidateLibrary, against the read groups of headerTestReadGroups
+var validateReadGroupsHeaderTC = []struct {
+	readGroups []string
+	library    string
+	expGroups  bool
+	expLibrary bool
+}{
+	{[]string{"rg1"}, "lib1", true, true},
+	{[]string{"rg1", "rg2"}, "lib2", true, true},
+	{[]string{"rg4"}, "lib3", false, false},
+	{[]string{"rg\"3"}, "", false, false},
+	{[]string{"rg\\1"}, "", false, false},
+}
+
+// TestGetReadGroupsFromHeader tests read groups and their libraries are
+// parsed from the @RG lines of the header, which is only read once
+func TestGetReadGroupsFromHeader(t *testing.T) {
+	count := 0
+	r := NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointReadsData)
+	r.SetHeaderReader(countingHeaderReader(headerTestReadGroups, nil, &count))
+	readGroups, err := getReadGroupsInReadsObject(r)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"rg1": "lib1", "rg2": "", "rg\"3": "lib2"}, readGroups)
+	for _, tc := range validateReadGroupsHeaderTC {
+		result, _ := paramValidator.ValidateReadGroups(r, tc.readGroups)
+		assert.Equal(t, tc.expGroups, result, tc.readGroups)
+		result, _ = paramValidator.ValidateLibrary(r, tc.library)
+		assert.Equal(t, tc.expLibrary, result, tc.library)
+	}
+	assert.Equal(t, 1, count)
+
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointReadsData)
+	r.SetHeaderReader(countingHeaderReader("", errors.New("not found"), &count))
+	_, err = getReadGroupsInReadsObject(r)
+	assert.EqualError(t, err, "Could not get read groups from requested alignment file")
+}
@@ -63,6 +63,11 @@ type partialRequestBodyFormat struct {
 	Format *string `json:"format"`
 }
 
+// partialRequestBodyClass parses single class parameter from request body
+type partialRequestBodyClass struct {
+	Class *string `json:"class"`
+}
+
 // partialRequestBodyFields parses single fields parameter from request body
 type partialRequestBodyFields struct {
 	Fields *[]string `json:"fields"`
@@ -78,6 +83,40 @@ type partialRequestBodyNoTags struct {
 	NoTags *[]string `json:"notags"`
 }
 
+// partialRequestBodySamples parses single samples parameter from request body
+type partialRequestBodySamples struct {
+	Samples *[]string `json:"samples"`
+}
+
+// partialRequestBodyMinMappingQuality parses single minMappingQuality
+// parameter from request body
+type partialRequestBodyMinMappingQuality struct {
+	MinMappingQuality *int `json:"minMappingQuality"`
+}
+
+// partialRequestBodyRequiredFlags parses single requiredFlags parameter from
+// request body
+type partialRequestBodyRequiredFlags struct {
+	RequiredFlags *int `json:"requiredFlags"`
+}
+
+// partialRequestBodyExcludedFlags parses single excludedFlags parameter from
+// request body
+type partialRequestBodyExcludedFlags struct {
+	ExcludedFlags *int `json:"excludedFlags"`
+}
+
+// partialRequestBodyReadGroups parses single readGroups parameter from request
+// body
+type partialRequestBodyReadGroups struct {
+	ReadGroups *[]string `json:"readGroups"`
+}
+
+// partialRequestBodyLibrary parses single library parameter from request body
+type partialRequestBodyLibrary struct {
+	Library *string `json:"library"`
+}
+
 // partialRequestBodyRegions parses single regions parameter from request body
 type partialRequestBodyRegions struct {
 	Regions *[]*Region `json:"regions"`
@@ -88,6 +127,11 @@ func (rb *partialRequestBodyFormat) getattr() reflect.Value {
 	return reflect.ValueOf(rb.Format)
 }
 
+// getattr returns reflected class value
+func (rb *partialRequestBodyClass) getattr() reflect.Value {
+	return reflect.ValueOf(rb.Class)
+}
+
 // getattr returns reflected fields value
 func (rb *partialRequestBodyFields) getattr() reflect.Value {
 	return reflect.ValueOf(rb.Fields)
@@ -103,6 +147,36 @@ func (rb *partialRequestBodyNoTags) getattr() reflect.Value {
 	return reflect.ValueOf(rb.NoTags)
 }
 
+// getattr returns reflected samples value
+func (rb *partialRequestBodySamples) getattr() reflect.Value {
+	return reflect.ValueOf(rb.Samples)
+}
+
+// getattr returns reflected minMappingQuality value
+func (rb *partialRequestBodyMinMappingQuality) getattr() reflect.Value {
+	return reflect.ValueOf(rb.MinMappingQuality)
+}
+
+// getattr returns reflected requiredFlags value
+func (rb *partialRequestBodyRequiredFlags) getattr() reflect.Value {
+	return reflect.ValueOf(rb.RequiredFlags)
+}
+
+// getattr returns reflected excludedFlags value
+func (rb *partialRequestBodyExcludedFlags) getattr() reflect.Value {
+	return reflect.ValueOf(rb.ExcludedFlags)
+}
+
+// getattr returns reflected readGroups value
+func (rb *partialRequestBodyReadGroups) getattr() reflect.Value {
+	return reflect.ValueOf(rb.ReadGroups)
+}
+
+// getattr returns reflected library value
+func (rb *partialRequestBodyLibrary) getattr() reflect.Value {
+	return reflect.ValueOf(rb.Library)
+}
+
 // getattr returns reflected regions value
 func (rb *partialRequestBodyRegions) getattr() reflect.Value {
 	return reflect.ValueOf(rb.Regions)
@@ -116,12 +190,26 @@ func newPartialRequestBody(key string) partialRequestBody {
 	switch key {
 	case "format":
 		prb = new(partialRequestBodyFormat)
+	case "class":
+		prb = new(partialRequestBodyClass)
 	case "fields":
 		prb = new(partialRequestBodyFields)
 	case "tags":
 		prb = new(partialRequestBodyTags)
 	case "notags":
 		prb = new(partialRequestBodyNoTags)
+	case "samples":
+		prb = new(partialRequestBodySamples)
+	case "minMappingQuality":
+		prb = new(partialRequestBodyMinMappingQuality)
+	case "requiredFlags":
+		prb = new(partialRequestBodyRequiredFlags)
+	case "excludedFlags":
+		prb = new(partialRequestBodyExcludedFlags)
+	case "readGroups":
+		prb = new(partialRequestBodyReadGroups)
+	case "library":
+		prb = new(partialRequestBodyLibrary)
 	case "regions":
 		prb = new(partialRequestBodyRegions)
 	}
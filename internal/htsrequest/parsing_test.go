@@ -93,6 +93,13 @@ var parseReqBodyParamTC = []struct {
 		true,
 		"string",
 	},
+	{
+		"{\"class\":\"body\"}",
+		"class",
+		false,
+		true,
+		"string",
+	},
 	{
 		"{\"fields\":[\"QNAME\",\"SEQ\",\"QUAL\"]}",
 		"fields",
@@ -114,6 +121,41 @@ var parseReqBodyParamTC = []struct {
 		true,
 		"[]string",
 	},
+	{
+		"{\"samples\":[\"HG002\",\"HG003\"]}",
+		"samples",
+		false,
+		true,
+		"[]string",
+	},
+	{
+		"{\"minMappingQuality\":30}",
+		"minMappingQuality",
+		false,
+		true,
+		"int",
+	},
+	{
+		"{\"excludedFlags\":1024}",
+		"excludedFlags",
+		false,
+		true,
+		"int",
+	},
+	{
+		"{\"readGroups\":[\"rg1\",\"rg2\"]}",
+		"readGroups",
+		false,
+		true,
+		"[]string",
+	},
+	{
+		"{\"library\":\"lib1\"}",
+		"library",
+		false,
+		true,
+		"string",
+	},
 	{
 		"{\"regions\":[{\"referenceName\":\"chr1\"}]}",
 		"regions",
@@ -5,7 +5,10 @@
 // Module region contains genomic intervals
 package htsrequest
 
-import "strconv"
+import (
+	"math"
+	"strconv"
+)
 
 // Region defines a simple genomic interval: contig name, start, and end position
 type Region struct {
@@ -84,7 +87,8 @@ func (region *Region) EndRequested() bool {
 	return !(region.GetEnd() == -1)
 }
 
-// String gets a representation of a genomic region
+// String gets a representation of a genomic region, in htsget 0-based
+// coordinates
 func (region *Region) String() string {
 	if !region.StartRequested() && !region.EndRequested() {
 		return region.ReferenceName
@@ -98,23 +102,63 @@ func (region *Region) String() string {
 	return region.ReferenceName + ":" + region.StartString() + "-" + region.EndString()
 }
 
-// ExportSamtools exports the region in a manner compatible to how region requests
-// are specified on the samtools command-line
-func (region *Region) ExportSamtools() string {
-	return region.String()
+/* COORDINATE CONVERSION */
+
+// htsget regions are 0-based and half-open, while samtools and bcftools
+// regions are 1-based and inclusive. eg. the first 100 bases of chr1 are
+// start=0&end=100 in htsget, and chr1:1-100 in samtools/bcftools
+
+// OneBasedStart converts the 0-based start position to the 1-based position
+// of the first base in the region. the region starts at the first base of the
+// reference if no start was requested
+func (region *Region) OneBasedStart() int {
+	if !region.StartRequested() {
+		return 1
+	}
+	return region.GetStart() + 1
 }
 
-// ExportBcftools exports the region in a manner compatible to how region requests
-// are specified on the samtools command-line
-func (region *Region) ExportBcftools() string {
+// OneBasedEnd converts the exclusive 0-based end position to the 1-based
+// position of the last base in the region, which is the same number
+func (region *Region) OneBasedEnd() int {
+	return region.GetEnd()
+}
+
+// exportOneBased exports the region in the 1-based, inclusive region syntax
+// shared by samtools and bcftools. regions without an end extend to the end
+// of the reference
+func (region *Region) exportOneBased() string {
 	if !region.StartRequested() && !region.EndRequested() {
 		return region.ReferenceName
 	}
-	if region.StartRequested() && !region.EndRequested() {
-		return region.ReferenceName + ":" + region.StartString() + "-"
+	start := strconv.Itoa(region.OneBasedStart())
+	if !region.EndRequested() {
+		return region.ReferenceName + ":" + start + "-"
 	}
-	if !region.StartRequested() && region.EndRequested() {
-		return region.ReferenceName + ":" + "0-" + region.EndString()
+	return region.ReferenceName + ":" + start + "-" + strconv.Itoa(region.OneBasedEnd())
+}
+
+// ExportSamtools exports the region in a manner compatible to how region requests
+// are specified on the samtools command-line
+func (region *Region) ExportSamtools() string {
+	return region.exportOneBased()
+}
+
+// ExportBED exports the region as a single BED interval. BED intervals are
+// 0-based and half-open, as htsget regions are
+func (region *Region) ExportBED() string {
+	start, end := 0, math.MaxInt32
+	if region.StartRequested() {
+		start = region.GetStart()
 	}
-	return region.ReferenceName + ":" + region.StartString() + "-" + region.EndString()
+	if region.EndRequested() {
+		end = region.GetEnd()
+	}
+	return region.ReferenceName + "\t" + strconv.Itoa(start) + "\t" + strconv.Itoa(end) + "\n"
+}
+
+// ExportBcftools exports the region in a manner compatible to how region requests
+// are specified on the bcftools command-line
+func (region *Region) ExportBcftools() string {
+	return region.exportOneBased()
 }
@@ -29,12 +29,36 @@ var regionStringTC = []regionTC{
 	{false, true, "chr21", 100000, 0, "chr21:100000"},
 }
 
-// regionExportBcftoolsTC test cases for ExportBcftools
-var regionExportBcftoolsTC = []regionTC{
+// regionExportSamtoolsTC test cases for ExportSamtools and ExportBcftools,
+// converting to 1-based inclusive coordinates
+var regionExportSamtoolsTC = []regionTC{
 	{false, false, "chr10", -1, -1, "chr10"},
-	{false, false, "chr22", 100, -1, "chr22:100-"},
-	{false, false, "chr5", -1, 250000, "chr5:0-250000"},
-	{false, false, "chr1", 0, 100, "chr1:0-100"},
+	{false, false, "chr22", 100, -1, "chr22:101-"},
+	{false, false, "chr5", -1, 250000, "chr5:1-250000"},
+	{false, false, "chr1", 0, 100, "chr1:1-100"},
+	{false, false, "chr1", 99, 100, "chr1:100-100"},
+	{true, false, "chr21", 0, 100000, "chr21:1-100000"},
+	{false, true, "chr21", 100000, 0, "chr21:100001-"},
+}
+
+// regionExportBEDTC test cases for ExportBED
+var regionExportBEDTC = []regionTC{
+	{false, false, "chr10", -1, -1, "chr10\t0\t2147483647\n"},
+	{false, false, "chr22", 100, -1, "chr22\t100\t2147483647\n"},
+	{false, false, "chr5", -1, 250000, "chr5\t0\t250000\n"},
+	{false, false, "chr1", 0, 100, "chr1\t0\t100\n"},
+	{false, false, "chr1", 1000, 2000, "chr1\t1000\t2000\n"},
+	{true, true, "chr21", 0, 0, "chr21\t0\t2147483647\n"},
+}
+
+// regionOneBasedTC test cases for OneBasedStart and OneBasedEnd
+var regionOneBasedTC = []struct {
+	start, end       int
+	expStart, expEnd int
+}{
+	{-1, 100, 1, 100},
+	{0, 1, 1, 1},
+	{4861645, 4861745, 4861646, 4861745},
 }
 
 // regionReferenceNameTC test cases for GetReferenceName
@@ -85,7 +109,7 @@ func TestRegionString(t *testing.T) {
 
 // TestRegionExportSamtools tests ExportSamtools function
 func TestRegionExportSamtools(t *testing.T) {
-	for _, tc := range regionStringTC {
+	for _, tc := range regionExportSamtoolsTC {
 		r := instantiateRegion(&tc)
 		assert.Equal(t, tc.exp, r.ExportSamtools())
 	}
@@ -93,12 +117,31 @@ func TestRegionExportSamtools(t *testing.T) {
 
 // TestRegionExportBcftools tests ExportBcftools function
 func TestRegionExportBcftools(t *testing.T) {
-	for _, tc := range regionExportBcftoolsTC {
+	for _, tc := range regionExportSamtoolsTC {
 		r := instantiateRegion(&tc)
 		assert.Equal(t, tc.exp, r.ExportBcftools())
 	}
 }
 
+// TestRegionOneBased tests OneBasedStart and OneBasedEnd functions
+func TestRegionOneBased(t *testing.T) {
+	for _, tc := range regionOneBasedTC {
+		r := NewRegion()
+		r.SetStart(tc.start)
+		r.SetEnd(tc.end)
+		assert.Equal(t, tc.expStart, r.OneBasedStart())
+		assert.Equal(t, tc.expEnd, r.OneBasedEnd())
+	}
+}
+
+// TestRegionExportBED tests ExportBED function
+func TestRegionExportBED(t *testing.T) {
+	for _, tc := range regionExportBEDTC {
+		r := instantiateRegion(&tc)
+		assert.Equal(t, tc.exp, r.ExportBED())
+	}
+}
+
 // TestRegionGetReferenceName tests GetReferenceName function
 func TestRegionGetReferenceName(t *testing.T) {
 	for _, tc := range regionReferenceNameTC {
@@ -0,0 +1,116 @@
+// Package htsrequest provides operations for parsing htsget-related
+// parameters from the HTTP request, and performing validation and
+// transformation
+//
+// Module regions normalizes lists of requested genomic regions, so that
+// multi-region tickets return each record once
+package htsrequest
+
+import (
+	"sort"
+)
+
+// regionBounds gets the 0-based start and exclusive end of the region, the
+// end being -1 if the region extends to the end of the reference
+func regionBounds(region *Region) (int, int) {
+	start, end := 0, -1
+	if region.StartRequested() {
+		start = region.GetStart()
+	}
+	if region.EndRequested() {
+		end = region.GetEnd()
+	}
+	return start, end
+}
+
+// NormalizeRegions sorts the regions by the order of their reference in the
+// file, and merges regions on the same reference that overlap or are
+// adjacent. references not found in the file's reference names (eg. unplaced
+// reads, "*") are sorted after all others, in the order they were requested
+//
+// Arguments
+//	regions ([]*Region): requested regions
+//	referenceNames ([]string): reference names, in the order of the file header
+// Returns
+//	([]*Region): new, sorted list of non-overlapping regions
+func NormalizeRegions(regions []*Region, referenceNames []string) []*Region {
+	referenceOrder := make(map[string]int)
+	for i, referenceName := range referenceNames {
+		if _, ok := referenceOrder[referenceName]; !ok {
+			referenceOrder[referenceName] = i
+		}
+	}
+	for _, region := range regions {
+		if _, ok := referenceOrder[region.GetReferenceName()]; !ok {
+			referenceOrder[region.GetReferenceName()] = len(referenceOrder)
+		}
+	}
+
+	sorted := append([]*Region{}, regions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iOrder, jOrder := referenceOrder[sorted[i].GetReferenceName()], referenceOrder[sorted[j].GetReferenceName()]
+		if iOrder != jOrder {
+			return iOrder < jOrder
+		}
+		iStart, _ := regionBounds(sorted[i])
+		jStart, _ := regionBounds(sorted[j])
+		return iStart < jStart
+	})
+
+	normalized := []*Region{}
+	for _, region := range sorted {
+		merged := &Region{ReferenceName: region.ReferenceName, Start: region.Start, End: region.End}
+		if len(normalized) == 0 {
+			normalized = append(normalized, merged)
+			continue
+		}
+		last := normalized[len(normalized)-1]
+		_, lastEnd := regionBounds(last)
+		start, end := regionBounds(region)
+		if last.GetReferenceName() != region.GetReferenceName() || (lastEnd != -1 && start > lastEnd) {
+			normalized = append(normalized, merged)
+			continue
+		}
+		// the region overlaps or is adjacent to the last one, which is
+		// extended to cover both
+		if lastEnd != -1 && (end == -1 || end > lastEnd) {
+			last.End = region.End
+		}
+	}
+	return normalized
+}
+
+// RegionMinStart gets the position records served for a region of
+// normalized regions must start from, so that records overlapping the
+// previous region on the same reference are not served twice
+//
+// Arguments
+//	regions ([]*Region): normalized regions
+//	regionI (int): index of the region
+// Returns
+//	(int): 0-based start position, -1 if all records overlapping the region
+//		are served
+func RegionMinStart(regions []*Region, regionI int) int {
+	if regionI == 0 {
+		return -1
+	}
+	previous, region := regions[regionI-1], regions[regionI]
+	if previous.GetReferenceName() != region.GetReferenceName() || !previous.EndRequested() {
+		return -1
+	}
+	return previous.GetEnd()
+}
+
+// NormalizeRegions normalizes the requested regions, sorting them by the
+// order of the file's references. if the reference names can't be read, the
+// references are sorted in the order they were requested
+func (r *HtsgetRequest) NormalizeRegions() {
+	if r.NRegions() < 2 {
+		return
+	}
+	referenceNames, err := getReferenceNames(r)
+	if err != nil {
+		referenceNames = nil
+	}
+	r.SetRegions(NormalizeRegions(r.GetRegions(), referenceNames))
+}
@@ -0,0 +1,118 @@
+// Package htsrequest provides operations for parsing htsget-related
+// parameters from the HTTP request, and performing validation and
+// transformation
+//
+// Module regions_test tests module regions
+package htsrequest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// regionsReferenceNames reference names of the regions test cases, in file
+// header order
+var regionsReferenceNames = []string{"chr1", "chr2", "chr10"}
+
+// normalizeRegionsTC test cases for NormalizeRegions, regions given as POST
+// request bodies
+var normalizeRegionsTC = []struct {
+	requestBody string
+	expRegions  []string
+}{
+	// sorted by reference order, then start
+	{
+		`{"regions": [{"referenceName": "chr10", "start": 0, "end": 100}, {"referenceName": "chr1", "start": 500, "end": 600}, {"referenceName": "chr1", "start": 100, "end": 200}]}`,
+		[]string{"chr1:100-200", "chr1:500-600", "chr10:0-100"},
+	},
+	// overlapping regions are merged
+	{
+		`{"regions": [{"referenceName": "chr1", "start": 100, "end": 200}, {"referenceName": "chr1", "start": 150, "end": 300}, {"referenceName": "chr1", "start": 160, "end": 170}]}`,
+		[]string{"chr1:100-300"},
+	},
+	// adjacent regions are merged
+	{
+		`{"regions": [{"referenceName": "chr2", "start": 200, "end": 300}, {"referenceName": "chr2", "start": 100, "end": 200}]}`,
+		[]string{"chr2:100-300"},
+	},
+	// regions without start/end cover the start/end of the reference
+	{
+		`{"regions": [{"referenceName": "chr1", "start": 100, "end": 200}, {"referenceName": "chr1", "end": 50}, {"referenceName": "chr1", "start": 150}, {"referenceName": "chr1", "start": 500, "end": 600}]}`,
+		[]string{"chr1:0-50", "chr1:100"},
+	},
+	{
+		`{"regions": [{"referenceName": "chr2"}, {"referenceName": "chr2", "start": 100, "end": 200}]}`,
+		[]string{"chr2"},
+	},
+	// references not in the file are sorted last, in request order
+	{
+		`{"regions": [{"referenceName": "*"}, {"referenceName": "chrUn"}, {"referenceName": "chr2"}, {"referenceName": "*"}]}`,
+		[]string{"chr2", "*", "chrUn"},
+	},
+}
+
+// regionMinStartTC test cases for RegionMinStart
+var regionMinStartTC = []struct {
+	regions     []*Region
+	expMinStart []int
+}{
+	{
+		[]*Region{
+			{ReferenceName: "chr1", Start: intPtr(100), End: intPtr(200)},
+			{ReferenceName: "chr1", Start: intPtr(500), End: intPtr(600)},
+			{ReferenceName: "chr1", Start: intPtr(800)},
+			{ReferenceName: "chr2", Start: intPtr(0), End: intPtr(100)},
+		},
+		[]int{-1, 200, 600, -1},
+	},
+	{
+		[]*Region{
+			{ReferenceName: "chr1", End: intPtr(50)},
+			{ReferenceName: "chr1", Start: intPtr(100), End: intPtr(200)},
+		},
+		[]int{-1, 50},
+	},
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// TestNormalizeRegions tests NormalizeRegions function
+func TestNormalizeRegions(t *testing.T) {
+	for _, tc := range normalizeRegionsTC {
+		var body struct {
+			Regions []*Region `json:"regions"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(tc.requestBody), &body))
+		regions := NormalizeRegions(body.Regions, regionsReferenceNames)
+		strs := []string{}
+		for _, region := range regions {
+			strs = append(strs, region.String())
+		}
+		assert.Equal(t, tc.expRegions, strs, tc.requestBody)
+	}
+}
+
+// TestNormalizeRegionsUnmodified tests NormalizeRegions does not modify the
+// requested regions
+func TestNormalizeRegionsUnmodified(t *testing.T) {
+	regions := []*Region{
+		{ReferenceName: "chr1", Start: intPtr(150), End: intPtr(300)},
+		{ReferenceName: "chr1", Start: intPtr(100), End: intPtr(200)},
+	}
+	NormalizeRegions(regions, regionsReferenceNames)
+	assert.Equal(t, "chr1:150-300", regions[0].String())
+	assert.Equal(t, "chr1:100-200", regions[1].String())
+}
+
+// TestRegionMinStart tests RegionMinStart function
+func TestRegionMinStart(t *testing.T) {
+	for _, tc := range regionMinStartTC {
+		for i, expMinStart := range tc.expMinStart {
+			assert.Equal(t, expMinStart, RegionMinStart(tc.regions, i))
+		}
+	}
+}
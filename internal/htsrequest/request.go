@@ -8,6 +8,7 @@
 package htsrequest
 
 import (
+	"context"
 	"net/url"
 	"strconv"
 	"strings"
@@ -26,27 +27,48 @@ type HtsgetRequest struct {
 	referenceName      string
 	start              int
 	end                int
+	minStart           int
+	headerBytes        int
 	fields             []string
 	tags               []string
 	noTags             []string
+	samples            []string
+	minMappingQuality  int
+	requiredFlags      int
+	excludedFlags      int
+	readGroups         []string
+	library            string
 	regions            []*Region
 	htsgetBlockClass   string
 	htsgetCurrentBlock string
 	htsgetTotalBlocks  string
-	htsgetFilePath     string
 	htsgetRange        string
+	context            context.Context
+	dataSourceRegistry *htsconfig.DataSourceRegistry
+	headerReader       HeaderReader
+	headerLines        []string
+	headerErr          error
 }
 
 // NewHtsgetRequest instantiates a new HtsgetRequest instance
 func NewHtsgetRequest() *HtsgetRequest {
 	r := new(HtsgetRequest)
 	r.SetRegions([]*Region{})
+	r.SetMinStart(defaultMinStart)
+	r.SetHeaderBytes(defaultHeaderBytes)
+	r.SetSamples(defaultSamples)
+	r.SetMinMappingQuality(defaultMinMappingQuality)
+	r.SetRequiredFlags(defaultRequiredFlags)
+	r.SetExcludedFlags(defaultExcludedFlags)
+	r.SetReadGroups(defaultReadGroups)
+	r.SetLibrary(defaultLibrary)
 	return r
 }
 
 // SetEndpoint sets the API endpoint associated with request
 func (r *HtsgetRequest) SetEndpoint(endpoint htsconstants.APIEndpoint) {
 	r.endpoint = endpoint
+	r.dataSourceRegistry = nil
 }
 
 // GetEndpoint retrieves the API endpoint associated with request
@@ -144,6 +166,66 @@ func (r *HtsgetRequest) GetNoTags() []string {
 	return r.noTags
 }
 
+// SetSamples sets the requested samples of a variant file
+func (r *HtsgetRequest) SetSamples(samples []string) {
+	r.samples = samples
+}
+
+// GetSamples retrieves the requested samples of a variant file
+func (r *HtsgetRequest) GetSamples() []string {
+	return r.samples
+}
+
+// SetMinMappingQuality sets the minimum mapping quality of returned reads
+func (r *HtsgetRequest) SetMinMappingQuality(minMappingQuality int) {
+	r.minMappingQuality = minMappingQuality
+}
+
+// GetMinMappingQuality retrieves the minimum mapping quality of returned reads
+func (r *HtsgetRequest) GetMinMappingQuality() int {
+	return r.minMappingQuality
+}
+
+// SetRequiredFlags sets the FLAG bits returned reads must all have set
+func (r *HtsgetRequest) SetRequiredFlags(requiredFlags int) {
+	r.requiredFlags = requiredFlags
+}
+
+// GetRequiredFlags retrieves the FLAG bits returned reads must all have set
+func (r *HtsgetRequest) GetRequiredFlags() int {
+	return r.requiredFlags
+}
+
+// SetExcludedFlags sets the FLAG bits returned reads must have none of
+func (r *HtsgetRequest) SetExcludedFlags(excludedFlags int) {
+	r.excludedFlags = excludedFlags
+}
+
+// GetExcludedFlags retrieves the FLAG bits returned reads must have none of
+func (r *HtsgetRequest) GetExcludedFlags() int {
+	return r.excludedFlags
+}
+
+// SetReadGroups sets the read groups returned reads must belong to
+func (r *HtsgetRequest) SetReadGroups(readGroups []string) {
+	r.readGroups = readGroups
+}
+
+// GetReadGroups retrieves the read groups returned reads must belong to
+func (r *HtsgetRequest) GetReadGroups() []string {
+	return r.readGroups
+}
+
+// SetLibrary sets the library returned reads must belong to
+func (r *HtsgetRequest) SetLibrary(library string) {
+	r.library = library
+}
+
+// GetLibrary retrieves the library returned reads must belong to
+func (r *HtsgetRequest) GetLibrary() string {
+	return r.library
+}
+
 // SetRegions sets the requested list of genomic regions to be returned
 func (r *HtsgetRequest) SetRegions(regions []*Region) {
 	r.regions = regions
@@ -159,6 +241,29 @@ func (r *HtsgetRequest) GetRegions() []*Region {
 	return r.regions
 }
 
+// SetMinStart sets the position records must start from, excluding records
+// served by the block of the previous region
+func (r *HtsgetRequest) SetMinStart(minStart int) {
+	r.minStart = minStart
+}
+
+// GetMinStart retrieves the position records must start from
+func (r *HtsgetRequest) GetMinStart() int {
+	return r.minStart
+}
+
+// SetHeaderBytes sets the size of the header preceding the records in the
+// output of a body block, measured when the ticket was issued
+func (r *HtsgetRequest) SetHeaderBytes(headerBytes int) {
+	r.headerBytes = headerBytes
+}
+
+// GetHeaderBytes retrieves the size of the header preceding the records in
+// the output of a body block
+func (r *HtsgetRequest) GetHeaderBytes() int {
+	return r.headerBytes
+}
+
 // SetHtsgetBlockClass sets the request block class
 func (r *HtsgetRequest) SetHtsgetBlockClass(htsgetBlockClass string) {
 	r.htsgetBlockClass = htsgetBlockClass
@@ -191,16 +296,6 @@ func (r *HtsgetRequest) GetHtsgetTotalBlocks() string {
 	return r.htsgetTotalBlocks
 }
 
-// SetHtsgetFilePath sets the path to the requested source file
-func (r *HtsgetRequest) SetHtsgetFilePath(htsgetFilePath string) {
-	r.htsgetFilePath = htsgetFilePath
-}
-
-// GetHtsgetFilePath retrieves the path to the requested source file
-func (r *HtsgetRequest) GetHtsgetFilePath() string {
-	return r.htsgetFilePath
-}
-
 // SetHtsgetRange sets the byte range for a requested file
 func (r *HtsgetRequest) SetHtsgetRange(htsgetRange string) {
 	r.htsgetRange = htsgetRange
@@ -211,6 +306,21 @@ func (r *HtsgetRequest) GetHtsgetRange() string {
 	return r.htsgetRange
 }
 
+// SetContext sets the context of the HTTP request, carrying request-scoped
+// values such as AWS credentials
+func (r *HtsgetRequest) SetContext(ctx context.Context) {
+	r.context = ctx
+}
+
+// GetContext retrieves the context of the HTTP request, background context if
+// none was set
+func (r *HtsgetRequest) GetContext() context.Context {
+	if r.context == nil {
+		return context.Background()
+	}
+	return r.context
+}
+
 // isDefaultString checks if a given string property matches the expected default value
 func (r *HtsgetRequest) isDefaultString(val string, def string) bool {
 	return val == def
@@ -241,6 +351,12 @@ func (r *HtsgetRequest) HeaderOnlyRequested() bool {
 	return r.GetClass() == htsconstants.ClassHeader
 }
 
+// BodyOnlyRequested checks if the client request is only for the body, ie.
+// all of the file except the header
+func (r *HtsgetRequest) BodyOnlyRequested() bool {
+	return r.GetClass() == htsconstants.ClassBody
+}
+
 // UnplacedUnmappedReadsRequested checks if the client request is for unplaced,
 // unmapped reads
 func (r *HtsgetRequest) UnplacedUnmappedReadsRequested() bool {
@@ -263,6 +379,56 @@ func (r *HtsgetRequest) EndRequested() bool {
 	return !r.isDefaultInt(r.GetEnd(), defaultEnd)
 }
 
+// MinStartRequested checks whether records starting before a position are
+// excluded from the data block
+func (r *HtsgetRequest) MinStartRequested() bool {
+	return !r.isDefaultInt(r.GetMinStart(), defaultMinStart)
+}
+
+// HeaderBytesRequested checks whether the size of the header to remove from
+// the data block was given, rather than measured by the data endpoint
+func (r *HtsgetRequest) HeaderBytesRequested() bool {
+	return !r.isDefaultInt(r.GetHeaderBytes(), defaultHeaderBytes)
+}
+
+// MinMappingQualityRequested checks whether reads below a mapping quality are
+// excluded
+func (r *HtsgetRequest) MinMappingQualityRequested() bool {
+	return !r.isDefaultInt(r.GetMinMappingQuality(), defaultMinMappingQuality)
+}
+
+// RequiredFlagsRequested checks whether returned reads must have FLAG bits set
+func (r *HtsgetRequest) RequiredFlagsRequested() bool {
+	return !r.isDefaultInt(r.GetRequiredFlags(), defaultRequiredFlags)
+}
+
+// ExcludedFlagsRequested checks whether reads with FLAG bits set are excluded
+func (r *HtsgetRequest) ExcludedFlagsRequested() bool {
+	return !r.isDefaultInt(r.GetExcludedFlags(), defaultExcludedFlags)
+}
+
+// AllReadGroupsRequested checks if reads of all read groups were requested,
+// ie. the 'readGroups' parameter was not specified
+func (r *HtsgetRequest) AllReadGroupsRequested() bool {
+	return r.isDefaultList(r.GetReadGroups(), defaultReadGroups)
+}
+
+// LibraryRequested checks whether only reads of a library were requested
+func (r *HtsgetRequest) LibraryRequested() bool {
+	return !r.isDefaultString(r.GetLibrary(), defaultLibrary)
+}
+
+// ReadFiltersRequested checks whether any of the read filtering extension
+// parameters (mapping quality, flags, read groups, library) were specified,
+// in which case reads are filtered by the data endpoint
+func (r *HtsgetRequest) ReadFiltersRequested() bool {
+	return r.MinMappingQualityRequested() ||
+		r.RequiredFlagsRequested() ||
+		r.ExcludedFlagsRequested() ||
+		!r.AllReadGroupsRequested() ||
+		r.LibraryRequested()
+}
+
 // NRegions returns the number of requested genomic loci
 func (r *HtsgetRequest) NRegions() int {
 	return len(r.GetRegions())
@@ -298,9 +464,19 @@ func (r *HtsgetRequest) AllTagsRequested() bool {
 	return r.TagsNotSpecified() && r.NoTagsNotSpecified()
 }
 
+// AllSamplesRequested checks if all samples of a variant file were requested
+// by the client, ie. the 'samples' parameter was not specified
+func (r *HtsgetRequest) AllSamplesRequested() bool {
+	return r.isDefaultList(r.GetSamples(), defaultSamples)
+}
+
 // IsHeaderBlock checks whether the current data block / filepart represents
-// the header of the genomic file
+// the header of the genomic file. the block class is used if given, as the
+// first block of body-only tickets is not the header
 func (r *HtsgetRequest) IsHeaderBlock() bool {
+	if r.GetHtsgetBlockClass() != "" {
+		return r.GetHtsgetBlockClass() == htsconstants.ClassHeader
+	}
 	current, _ := strconv.Atoi(r.GetHtsgetCurrentBlock())
 	return current == 0
 }
@@ -326,6 +502,11 @@ func (r *HtsgetRequest) ConstructDataEndpointURL(useRegion bool, regionI int) (s
 
 	// add query params
 	query := dataEndpoint.Query()
+	// the data endpoint falls back on the default format (BAM or VCF), so the
+	// format only needs to be forwarded when another one was requested
+	if r.GetFormat() != "" && r.GetFormat() != defaultFormatReads && r.GetFormat() != defaultFormatVariants {
+		query.Set("format", r.GetFormat())
+	}
 	if r.HeaderOnlyRequested() {
 		query.Set("class", r.GetClass())
 	}
@@ -341,6 +522,9 @@ func (r *HtsgetRequest) ConstructDataEndpointURL(useRegion bool, regionI int) (s
 		if region.EndRequested() {
 			query.Set("end", region.EndString())
 		}
+		if minStart := RegionMinStart(r.GetRegions(), regionI); minStart != -1 {
+			query.Set("minStart", strconv.Itoa(minStart))
+		}
 	}
 
 	if !r.AllFieldsRequested() {
@@ -355,13 +539,48 @@ func (r *HtsgetRequest) ConstructDataEndpointURL(useRegion bool, regionI int) (s
 		nt := strings.Join(r.GetNoTags(), ",")
 		query.Set("notags", nt)
 	}
+	if !r.AllSamplesRequested() {
+		query.Set("samples", strings.Join(r.GetSamples(), ","))
+	}
+	if r.MinMappingQualityRequested() {
+		query.Set("minMappingQuality", strconv.Itoa(r.GetMinMappingQuality()))
+	}
+	if r.RequiredFlagsRequested() {
+		query.Set("requiredFlags", strconv.Itoa(r.GetRequiredFlags()))
+	}
+	if r.ExcludedFlagsRequested() {
+		query.Set("excludedFlags", strconv.Itoa(r.GetExcludedFlags()))
+	}
+	if !r.AllReadGroupsRequested() {
+		query.Set("readGroups", strings.Join(r.GetReadGroups(), ","))
+	}
+	if r.LibraryRequested() {
+		query.Set("library", r.GetLibrary())
+	}
 	dataEndpoint.RawQuery = query.Encode()
 	return dataEndpoint.String(), nil
 }
 
-// GetDataSourceRegistry retrieves the data sources associated with the endpoint
+// GetDataSourceRegistry retrieves the data sources associated with the endpoint.
+// the registry is kept for the rest of the request, so the request is served
+// from the same data sources if the configuration is reloaded meanwhile
 func (r *HtsgetRequest) GetDataSourceRegistry() *htsconfig.DataSourceRegistry {
-	return htsconfig.GetDataSourceRegistry(r.GetEndpoint())
+	if r.dataSourceRegistry == nil {
+		r.dataSourceRegistry = htsconfig.GetDataSourceRegistry(r.GetEndpoint())
+	}
+	return r.dataSourceRegistry
+}
+
+// GetObjectPath retrieves the path to the requested object, from the request's
+// data sources
+func (r *HtsgetRequest) GetObjectPath() (string, error) {
+	return r.GetDataSourceRegistry().GetMatchingPath(r.GetID())
+}
+
+// GetObjectReferencePath retrieves the path to the reference FASTA of the
+// requested object, from the request's data sources
+func (r *HtsgetRequest) GetObjectReferencePath() (string, error) {
+	return r.GetDataSourceRegistry().GetMatchingReferencePath(r.GetID())
 }
 
 // GetServiceInfo retrieves the service info object associated with the endpoint
@@ -6,6 +6,7 @@
 package htsrequest
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
@@ -93,15 +94,6 @@ var requestHtsgetTotalBlocksTC = []struct {
 	{"1000"},
 }
 
-// requestHtsgetFilePathTC test cases for Set/Get HtsgetFilePath
-var requestHtsgetFilePathTC = []struct {
-	filePath string
-}{
-	{"/path/to/the/file.bam"},
-	{"./object1.vcf"},
-	{"https://example.com/files/file99.cram"},
-}
-
 // requestRangeTC test cases for Set/Get Range
 var requestRangeTC = []struct {
 	Range string
@@ -148,6 +140,16 @@ var requestHeaderOnlyRequestedTC = []struct {
 	{"body", false},
 }
 
+// requestBodyOnlyRequestedTC test cases for BodyOnlyRequested
+var requestBodyOnlyRequestedTC = []struct {
+	class string
+	exp   bool
+}{
+	{"", false},
+	{"header", false},
+	{"body", true},
+}
+
 // requestUnplacedUnmappedReadsRequestedTC test cases for UnplacedUnmappedReadsRequested
 var requestUnplacedUnmappedReadsRequestedTC = []struct {
 	referenceName string
@@ -222,12 +224,16 @@ var requestAllTagsRequestedTC = []struct {
 
 // requestIsHeaderBlockTC test cases for IsHeaderBlock
 var requestIsHeaderBlockTC = []struct {
+	blockClass   string
 	currentBlock string
 	exp          bool
 }{
-	{"0", true},
-	{"10", false},
-	{"100", false},
+	{"", "0", true},
+	{"", "10", false},
+	{"", "100", false},
+	{"header", "0", true},
+	{"body", "0", false},
+	{"body", "1", false},
 }
 
 // requestIsFinalBlockTC test cases for IsFinalBlock
@@ -442,15 +448,6 @@ func TestRequestHtsgetTotalBlocks(t *testing.T) {
 	}
 }
 
-// TestRequestFilePath tests Set/Get HtsgetFilePath functions
-func TestRequestFilePath(t *testing.T) {
-	for _, tc := range requestHtsgetFilePathTC {
-		r := NewHtsgetRequest()
-		r.SetHtsgetFilePath(tc.filePath)
-		assert.Equal(t, tc.filePath, r.GetHtsgetFilePath())
-	}
-}
-
 // TestRequestRange tests Set/Get HtsgetRange functions
 func TestRequestRange(t *testing.T) {
 	for _, tc := range requestRangeTC {
@@ -460,6 +457,17 @@ func TestRequestRange(t *testing.T) {
 	}
 }
 
+type requestTestContextKey struct{}
+
+// TestRequestContext tests Set/Get Context functions
+func TestRequestContext(t *testing.T) {
+	r := NewHtsgetRequest()
+	assert.Equal(t, context.Background(), r.GetContext())
+	ctx := context.WithValue(context.Background(), requestTestContextKey{}, "value")
+	r.SetContext(ctx)
+	assert.Equal(t, ctx, r.GetContext())
+}
+
 // TestRequestFields tests Set/Get Fields functions
 func TestRequestFields(t *testing.T) {
 	for _, tc := range requestFieldsTC {
@@ -496,6 +504,34 @@ func TestRequestHeaderOnlyRequested(t *testing.T) {
 	}
 }
 
+// TestRequestMinStart tests Set/Get MinStart and MinStartRequested functions
+func TestRequestMinStart(t *testing.T) {
+	r := NewHtsgetRequest()
+	assert.False(t, r.MinStartRequested())
+	r.SetMinStart(200)
+	assert.Equal(t, 200, r.GetMinStart())
+	assert.True(t, r.MinStartRequested())
+}
+
+// TestRequestHeaderBytes tests Set/Get HeaderBytes and HeaderBytesRequested
+// functions
+func TestRequestHeaderBytes(t *testing.T) {
+	r := NewHtsgetRequest()
+	assert.False(t, r.HeaderBytesRequested())
+	r.SetHeaderBytes(0)
+	assert.Equal(t, 0, r.GetHeaderBytes())
+	assert.True(t, r.HeaderBytesRequested())
+}
+
+// TestRequestBodyOnlyRequested tests BodyOnlyRequested function
+func TestRequestBodyOnlyRequested(t *testing.T) {
+	for _, tc := range requestBodyOnlyRequestedTC {
+		r := NewHtsgetRequest()
+		r.SetClass(tc.class)
+		assert.Equal(t, tc.exp, r.BodyOnlyRequested())
+	}
+}
+
 // TestRequestUnplacedUnmappedReadsRequested tests UnplacedUnmappedReadsRequested function
 func TestRequestUnplacedUnmappedReadsRequested(t *testing.T) {
 	for _, tc := range requestUnplacedUnmappedReadsRequestedTC {
@@ -564,6 +600,7 @@ func TestRequestAllTagsRequested(t *testing.T) {
 func TestRequestIsHeaderBlock(t *testing.T) {
 	for _, tc := range requestIsHeaderBlockTC {
 		r := NewHtsgetRequest()
+		r.SetHtsgetBlockClass(tc.blockClass)
 		r.SetHtsgetCurrentBlock(tc.currentBlock)
 		assert.Equal(t, tc.exp, r.IsHeaderBlock())
 	}
@@ -579,6 +616,19 @@ func TestRequestIsFinalBlock(t *testing.T) {
 	}
 }
 
+// requestConstructDataEndpointURLFormatTC test cases for the format query
+// parameter of ConstructDataEndpointURL
+var requestConstructDataEndpointURLFormatTC = []struct {
+	endpoint htsconstants.APIEndpoint
+	format   string
+	exp      string
+}{
+	{htsconstants.APIEndpointReadsTicket, "BAM", "http://localhost:3000/reads/data/object0001"},
+	{htsconstants.APIEndpointReadsTicket, "CRAM", "http://localhost:3000/reads/data/object0001?format=CRAM"},
+	{htsconstants.APIEndpointVariantsTicket, "VCF", "http://localhost:3000/variants/data/object0001"},
+	{htsconstants.APIEndpointVariantsTicket, "BCF", "http://localhost:3000/variants/data/object0001?format=BCF"},
+}
+
 // TestRequestConstructDataEndpointURL tests ConstructDataEndpointURL function
 func TestRequestConstructDataEndpointURL(t *testing.T) {
 
@@ -619,6 +669,116 @@ func TestRequestConstructDataEndpointURL(t *testing.T) {
 	}
 }
 
+// TestRequestConstructDataEndpointURLFormat tests that non-default formats
+// are forwarded to the data endpoint by ConstructDataEndpointURL
+func TestRequestConstructDataEndpointURLFormat(t *testing.T) {
+	for _, tc := range requestConstructDataEndpointURLFormatTC {
+		request := NewHtsgetRequest()
+		request.SetEndpoint(tc.endpoint)
+		request.SetID("object0001")
+		request.SetFormat(tc.format)
+		request.SetFields(defaultFields)
+		request.SetTags(defaultTags)
+		request.SetNoTags(defaultNoTags)
+		url, err := request.ConstructDataEndpointURL(false, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.exp, url)
+	}
+}
+
+// TestRequestConstructDataEndpointURLMinStart tests that the blocks of
+// regions following another on the same reference exclude records
+// overlapping it
+func TestRequestConstructDataEndpointURLMinStart(t *testing.T) {
+	request := NewHtsgetRequest()
+	request.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+	request.SetID("object0001")
+	request.SetFields(defaultFields)
+	request.SetTags(defaultTags)
+	request.SetNoTags(defaultNoTags)
+	request.SetRegions([]*Region{
+		{ReferenceName: "chr1", Start: intPtr(100), End: intPtr(200)},
+		{ReferenceName: "chr1", Start: intPtr(300), End: intPtr(400)},
+		{ReferenceName: "chr2", Start: intPtr(300), End: intPtr(400)},
+	})
+	expURLs := []string{
+		"http://localhost:3000/reads/data/object0001?end=200&referenceName=chr1&start=100",
+		"http://localhost:3000/reads/data/object0001?end=400&minStart=200&referenceName=chr1&start=300",
+		"http://localhost:3000/reads/data/object0001?end=400&referenceName=chr2&start=300",
+	}
+	for i, expURL := range expURLs {
+		url, err := request.ConstructDataEndpointURL(true, i)
+		assert.Nil(t, err)
+		assert.Equal(t, expURL, url)
+	}
+}
+
+// TestRequestSamples tests SetSamples, GetSamples and AllSamplesRequested
+// functions
+func TestRequestSamples(t *testing.T) {
+	r := NewHtsgetRequest()
+	assert.True(t, r.AllSamplesRequested())
+	r.SetSamples([]string{"HG002", "HG003"})
+	assert.Equal(t, []string{"HG002", "HG003"}, r.GetSamples())
+	assert.False(t, r.AllSamplesRequested())
+}
+
+// TestRequestConstructDataEndpointURLSamples tests that requested samples
+// and INFO/FORMAT keys are forwarded to the variants data endpoint
+func TestRequestConstructDataEndpointURLSamples(t *testing.T) {
+	request := NewHtsgetRequest()
+	request.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+	request.SetID("object0001")
+	request.SetFields(defaultFields)
+	request.SetTags([]string{"DP", "AD"})
+	request.SetNoTags(defaultNoTags)
+	request.SetSamples([]string{"HG002", "HG003"})
+	url, err := request.ConstructDataEndpointURL(false, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:3000/variants/data/object0001?samples=HG002%2CHG003&tags=DP%2CAD", url)
+}
+
+// TestRequestReadFilters tests the read filter setters, getters and
+// ReadFiltersRequested function
+func TestRequestReadFilters(t *testing.T) {
+	r := NewHtsgetRequest()
+	assert.False(t, r.ReadFiltersRequested())
+	r.SetMinMappingQuality(30)
+	r.SetRequiredFlags(1)
+	r.SetExcludedFlags(1024)
+	r.SetReadGroups([]string{"rg1", "rg2"})
+	r.SetLibrary("lib1")
+	assert.Equal(t, 30, r.GetMinMappingQuality())
+	assert.Equal(t, 1, r.GetRequiredFlags())
+	assert.Equal(t, 1024, r.GetExcludedFlags())
+	assert.Equal(t, []string{"rg1", "rg2"}, r.GetReadGroups())
+	assert.Equal(t, "lib1", r.GetLibrary())
+	assert.True(t, r.ReadFiltersRequested())
+
+	r = NewHtsgetRequest()
+	r.SetRequiredFlags(0)
+	assert.True(t, r.ReadFiltersRequested())
+}
+
+// TestRequestConstructDataEndpointURLReadFilters tests that read filters are
+// forwarded to the reads data endpoint
+func TestRequestConstructDataEndpointURLReadFilters(t *testing.T) {
+	request := NewHtsgetRequest()
+	request.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+	request.SetID("object0001")
+	request.SetFields(defaultFields)
+	request.SetTags(defaultTags)
+	request.SetNoTags(defaultNoTags)
+	request.SetMinMappingQuality(30)
+	request.SetRequiredFlags(0)
+	request.SetExcludedFlags(1024)
+	request.SetReadGroups([]string{"rg1", "rg2"})
+	request.SetLibrary("lib1")
+	url, err := request.ConstructDataEndpointURL(false, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:3000/reads/data/object0001?excludedFlags=1024&library=lib1&minMappingQuality=30&readGroups=rg1%2Crg2&requiredFlags=0", url)
+}
+
 // TestRequestGetDataSourceRegistry tests GetDataSourceRegistry function
 func TestRequestGetDataSourceRegistry(t *testing.T) {
 	for _, tc := range requestDataSourceRegistryTC {
@@ -115,6 +115,46 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"minMappingQuality",
+				"TransformStringToInt",
+				"ValidateMinMappingQuality",
+				"SetMinMappingQuality",
+				defaultMinMappingQuality,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"requiredFlags",
+				"TransformStringToInt",
+				"ValidateRequiredFlags",
+				"SetRequiredFlags",
+				defaultRequiredFlags,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"excludedFlags",
+				"TransformStringToInt",
+				"ValidateExcludedFlags",
+				"SetExcludedFlags",
+				defaultExcludedFlags,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"readGroups",
+				"TransformSplit",
+				"ValidateReadGroups",
+				"SetReadGroups",
+				defaultReadGroups,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"library",
+				"NoTransform",
+				"ValidateLibrary",
+				"SetLibrary",
+				defaultLibrary,
+			},
 		},
 
 		/* **************************************************
@@ -162,6 +202,22 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetEnd",
 				defaultEnd,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"minStart",
+				"TransformStringToInt",
+				"ValidateMinStart",
+				"SetMinStart",
+				defaultMinStart,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"headerBytes",
+				"TransformStringToInt",
+				"ValidateHeaderBytes",
+				"SetHeaderBytes",
+				defaultHeaderBytes,
+			},
 			{
 				htsconstants.ParamLocQuery,
 				"fields",
@@ -186,6 +242,46 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"minMappingQuality",
+				"TransformStringToInt",
+				"ValidateMinMappingQuality",
+				"SetMinMappingQuality",
+				defaultMinMappingQuality,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"requiredFlags",
+				"TransformStringToInt",
+				"ValidateRequiredFlags",
+				"SetRequiredFlags",
+				defaultRequiredFlags,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"excludedFlags",
+				"TransformStringToInt",
+				"ValidateExcludedFlags",
+				"SetExcludedFlags",
+				defaultExcludedFlags,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"readGroups",
+				"TransformSplit",
+				"ValidateReadGroups",
+				"SetReadGroups",
+				defaultReadGroups,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"library",
+				"NoTransform",
+				"ValidateLibrary",
+				"SetLibrary",
+				defaultLibrary,
+			},
 			{
 				htsconstants.ParamLocHeader,
 				"HtsgetBlockClass",
@@ -296,6 +392,14 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"samples",
+				"TransformSplit",
+				"ValidateSamples",
+				"SetSamples",
+				defaultSamples,
+			},
 		},
 
 		/* **************************************************
@@ -343,6 +447,22 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetEnd",
 				defaultEnd,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"minStart",
+				"TransformStringToInt",
+				"ValidateMinStart",
+				"SetMinStart",
+				defaultMinStart,
+			},
+			{
+				htsconstants.ParamLocQuery,
+				"headerBytes",
+				"TransformStringToInt",
+				"ValidateHeaderBytes",
+				"SetHeaderBytes",
+				defaultHeaderBytes,
+			},
 			{
 				htsconstants.ParamLocQuery,
 				"fields",
@@ -367,6 +487,14 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocQuery,
+				"samples",
+				"TransformSplit",
+				"ValidateSamples",
+				"SetSamples",
+				defaultSamples,
+			},
 			{
 				htsconstants.ParamLocHeader,
 				"HtsgetBlockClass",
@@ -400,17 +528,40 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 		htsconstants.APIEndpointVariantsServiceInfo: []SetParameterTuple{},
 
 		/* **************************************************
-		 * HTTP GET FILE BYTES
+		 * HTTP GET READS FILE BYTES
 		 * ************************************************** */
 
-		htsconstants.APIEndpointFileBytes: []SetParameterTuple{
+		htsconstants.APIEndpointReadsFileBytes: []SetParameterTuple{
+			{
+				htsconstants.ParamLocPath,
+				"id",
+				"NoTransform",
+				"NoValidation",
+				"SetID",
+				defaultID,
+			},
 			{
 				htsconstants.ParamLocHeader,
-				"HtsgetFilePath",
+				"Range",
+				"NoTransform",
+				"NoValidation",
+				"SetHtsgetRange",
+				defaultHtsgetRange,
+			},
+		},
+
+		/* **************************************************
+		 * HTTP GET VARIANTS FILE BYTES
+		 * ************************************************** */
+
+		htsconstants.APIEndpointVariantsFileBytes: []SetParameterTuple{
+			{
+				htsconstants.ParamLocPath,
+				"id",
 				"NoTransform",
 				"NoValidation",
-				"SetHtsgetFilePath",
-				defaultHtsgetFilePath,
+				"SetID",
+				defaultID,
 			},
 			{
 				htsconstants.ParamLocHeader,
@@ -450,6 +601,14 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetFormat",
 				defaultFormatReads,
 			},
+			{
+				htsconstants.ParamLocReqBody,
+				"class",
+				"NoTransform",
+				"ValidateClass",
+				"SetClass",
+				defaultClass,
+			},
 			{
 				htsconstants.ParamLocReqBody,
 				"fields",
@@ -474,6 +633,46 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocReqBody,
+				"minMappingQuality",
+				"NoTransform",
+				"ValidateMinMappingQuality",
+				"SetMinMappingQuality",
+				defaultMinMappingQuality,
+			},
+			{
+				htsconstants.ParamLocReqBody,
+				"requiredFlags",
+				"NoTransform",
+				"ValidateRequiredFlags",
+				"SetRequiredFlags",
+				defaultRequiredFlags,
+			},
+			{
+				htsconstants.ParamLocReqBody,
+				"excludedFlags",
+				"NoTransform",
+				"ValidateExcludedFlags",
+				"SetExcludedFlags",
+				defaultExcludedFlags,
+			},
+			{
+				htsconstants.ParamLocReqBody,
+				"readGroups",
+				"NoTransform",
+				"ValidateReadGroups",
+				"SetReadGroups",
+				defaultReadGroups,
+			},
+			{
+				htsconstants.ParamLocReqBody,
+				"library",
+				"NoTransform",
+				"ValidateLibrary",
+				"SetLibrary",
+				defaultLibrary,
+			},
 			{
 				htsconstants.ParamLocReqBody,
 				"regions",
@@ -505,6 +704,14 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetFormat",
 				defaultFormatVariants,
 			},
+			{
+				htsconstants.ParamLocReqBody,
+				"class",
+				"NoTransform",
+				"ValidateClass",
+				"SetClass",
+				defaultClass,
+			},
 			{
 				htsconstants.ParamLocReqBody,
 				"fields",
@@ -529,6 +736,14 @@ var orderedParamsMap = map[htsconstants.HTTPMethod]map[htsconstants.APIEndpoint]
 				"SetNoTags",
 				defaultNoTags,
 			},
+			{
+				htsconstants.ParamLocReqBody,
+				"samples",
+				"NoTransform",
+				"ValidateSamples",
+				"SetSamples",
+				defaultSamples,
+			},
 			{
 				htsconstants.ParamLocReqBody,
 				"regions",
@@ -624,12 +839,16 @@ func setSingleParameter(request *http.Request, setParamTuple SetParameterTuple,
 }
 
 // SetAllParameters parses, transforms, validates, and sets all parameters to
-// an HtsgetRequest for a given ordered list of expected request parameters
-func SetAllParameters(method htsconstants.HTTPMethod, endpoint htsconstants.APIEndpoint, writer http.ResponseWriter, request *http.Request) (*HtsgetRequest, error) {
+// an HtsgetRequest for a given ordered list of expected request parameters.
+// parameters depending on the object header are validated against the header
+// read by headerReader
+func SetAllParameters(method htsconstants.HTTPMethod, endpoint htsconstants.APIEndpoint, writer http.ResponseWriter, request *http.Request, headerReader HeaderReader) (*HtsgetRequest, error) {
 
 	orderedParams := orderedParamsMap[method][endpoint]
 	htsgetReq := NewHtsgetRequest()
 	htsgetReq.SetEndpoint(endpoint)
+	htsgetReq.SetContext(request.Context())
+	htsgetReq.SetHeaderReader(headerReader)
 
 	// for POST requests, unmarshal the JSON body once and pass to individual
 	// setting methods
@@ -142,6 +142,44 @@ var setSingleParamterTC = []struct {
 		false,
 		"",
 	},
+	{
+		// parse class during POST request
+		htsconstants.APIEndpointReadsTicket,
+		"POST",
+		"NoID",
+		"",
+		[][]string{},
+		"{\"class\": \"body\"}",
+		&SetParameterTuple{
+			htsconstants.ParamLocReqBody,
+			"class",
+			"NoTransform",
+			"ValidateClass",
+			"SetClass",
+			defaultClass,
+		},
+		false,
+		"",
+	},
+	{
+		// error when validating class during POST request
+		htsconstants.APIEndpointVariantsTicket,
+		"POST",
+		"NoID",
+		"",
+		[][]string{},
+		"{\"class\": \"Body\"}",
+		&SetParameterTuple{
+			htsconstants.ParamLocReqBody,
+			"class",
+			"NoTransform",
+			"ValidateClass",
+			"SetClass",
+			defaultClass,
+		},
+		true,
+		"class: 'Body' not supported",
+	},
 }
 
 // TestSetSingleParameter tests SetSingleParameter function
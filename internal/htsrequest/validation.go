@@ -7,19 +7,18 @@
 package htsrequest
 
 import (
-	"bufio"
 	"errors"
 	"net/http"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 
-	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 	"github.com/ga4gh/htsget-refserver/internal/htserror"
 	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 	"github.com/ga4gh/htsget-refserver/internal/awsutils"
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
 )
 
 // ParamValidator validates request parameters
@@ -33,21 +32,28 @@ func NewParamValidator() *ParamValidator {
 // errorsByParam (map[string]func(http.ResponseWriter, *string)): the correct
 // error to raise for each request parameter validation
 var errorsByParam = map[string]func(http.ResponseWriter, *string){
-	"id":               htserror.NotFound,
-	"format":           htserror.UnsupportedFormat,
-	"class":            htserror.InvalidInput,
-	"referenceName":    htserror.InvalidRange,
-	"start":            htserror.InvalidRange,
-	"end":              htserror.InvalidRange,
-	"fields":           htserror.InvalidInput,
-	"tags":             htserror.InvalidInput,
-	"notags":           htserror.InvalidInput,
-	"regions":          htserror.InvalidRange,
-	"HtsgetBlockClass": htserror.InvalidInput,
-	"HtsgetBlockId":    htserror.InternalServerError,
-	"HtsgetNumBlocks":  htserror.InternalServerError,
-	"HtsgetFilePath":   htserror.InternalServerError,
-	"Range":            htserror.InternalServerError,
+	"id":                htserror.NotFound,
+	"format":            htserror.UnsupportedFormat,
+	"class":             htserror.InvalidInput,
+	"referenceName":     htserror.InvalidRange,
+	"start":             htserror.InvalidRange,
+	"end":               htserror.InvalidRange,
+	"minStart":          htserror.InvalidRange,
+	"headerBytes":       htserror.InvalidInput,
+	"fields":            htserror.InvalidInput,
+	"tags":              htserror.InvalidInput,
+	"notags":            htserror.InvalidInput,
+	"samples":           htserror.InvalidInput,
+	"minMappingQuality": htserror.InvalidInput,
+	"requiredFlags":     htserror.InvalidInput,
+	"excludedFlags":     htserror.InvalidInput,
+	"readGroups":        htserror.InvalidInput,
+	"library":           htserror.InvalidInput,
+	"regions":           htserror.InvalidRange,
+	"HtsgetBlockClass":  htserror.InvalidInput,
+	"HtsgetBlockId":     htserror.InternalServerError,
+	"HtsgetNumBlocks":   htserror.InternalServerError,
+	"Range":             htserror.InternalServerError,
 }
 
 // isGreaterThanEqualToZero determines if a string can be parsed as an integer,
@@ -68,7 +74,7 @@ func (v *ParamValidator) NoValidation(htsgetReq *HtsgetRequest, value string) (b
 // ValidateID validates the 'id' parameter. checks if an object matching
 // the 'id' could be found from the data source
 func (v *ParamValidator) ValidateID(htsgetReq *HtsgetRequest, id string) (bool, string) {
-	objPath, err := htsconfig.GetObjectPath(htsgetReq.GetEndpoint(), id)
+	objPath, err := htsgetReq.GetDataSourceRegistry().GetMatchingPath(id)
 	if err != nil {
 		return false, "The requested resource could not be associated with a registered data source"
 	}
@@ -76,13 +82,28 @@ func (v *ParamValidator) ValidateID(htsgetReq *HtsgetRequest, id string) (bool,
 	// attempt to locate the object by http request (if url) or on local file
 	// path
 	if htsutils.IsValidURL(objPath) {
-		if strings.HasPrefix(objPath, awsutils.S3Proto) {
-			_, err := awsutils.HeadS3Object(awsutils.S3Dto{
+		if azureutils.IsAzurePath(objPath) {
+			_, err := azureutils.GetAzureBlobSize(azureutils.AzureDto{
 				ObjPath: objPath,
 			})
+			if err != nil {
+				return false, "Error accessing the requested Azure resource"
+			}
+		} else if strings.HasPrefix(objPath, awsutils.S3Proto) {
+			_, err := awsutils.HeadS3Object(awsutils.S3Dto{
+				ObjPath:     objPath,
+				Credentials: awsutils.CredentialsProviderFromContext(htsgetReq.GetContext()),
+			})
 			if err != nil {
 				return false, "Error accessing the requested S3 resource"
 			}
+		} else if strings.HasPrefix(objPath, gcsutils.GCSProto) {
+			_, err := gcsutils.GetGCSObjectSize(gcsutils.GCSDto{
+				ObjPath: objPath,
+			})
+			if err != nil {
+				return false, "Error accessing the requested GCS resource"
+			}
 		} else {
 			res, err := http.Head(objPath)
 			if err != nil {
@@ -115,83 +136,93 @@ func (v *ParamValidator) ValidateFormat(htsgetReq *HtsgetRequest, format string)
 // ValidateClass validates the 'class' parameter. checks if the requested class
 // is one of the allowed options
 func (v *ParamValidator) ValidateClass(htsgetReq *HtsgetRequest, class string) (bool, string) {
-	switch strings.ToLower(class) {
-	case htsconstants.ClassHeader:
+	switch class {
+	case htsconstants.ClassHeader, htsconstants.ClassBody:
 		return true, ""
-	case htsconstants.ClassBody:
-		return false, "'body' only requests currently not supported" // currently not supported
 	default:
 		return false, "class: '" + class + "' not supported"
 	}
 }
 
+// getReferenceNamesInReadsObject gets the names of the reference sequences
+// in the header of the requested alignment file
 func getReferenceNamesInReadsObject(htsgetReq *HtsgetRequest) ([]string, error) {
-
-	var referenceNames []string
-	fileURL, err := htsconfig.GetObjectPath(htsgetReq.GetEndpoint(), htsgetReq.GetID())
-	if err != nil {
-		return nil, err
-	}
-
-	cmd := exec.Command("samtools", "view", "-H", fileURL)
-	pipe, err := cmd.StdoutPipe()
+	headerLines, err := htsgetReq.getHeaderLines()
 	if err != nil {
-		return nil, err
+		return nil, errors.New("Could not get referenceNames from requested alignment file")
 	}
-
-	cmd.Start()
-	reader := bufio.NewReader(pipe)
-	l, _, err := reader.ReadLine()
-
-	for ; err == nil; l, _, err = reader.ReadLine() {
-		pattern := regexp.MustCompile("^@SQ\tSN:(.+?)\t.+?$")
-		submatches := pattern.FindStringSubmatch(string(l))
+	var referenceNames []string
+	pattern := regexp.MustCompile("^@SQ\tSN:(.+?)\t.+?$")
+	for _, line := range headerLines {
+		submatches := pattern.FindStringSubmatch(line)
 		if len(submatches) > 1 {
 			referenceNames = append(referenceNames, submatches[1])
 		}
 	}
-
-	// wait till job completes, if the exit code wasn't 0, then raise an error
-	// likely the file was not found
-	err = cmd.Wait()
-	if err != nil {
-		return nil, errors.New("Could not get referenceNames from requested alignment file")
-	}
-
 	return referenceNames, nil
 }
 
+// getReferenceNamesInVariantsObject gets the IDs of the contigs in the header
+// of the requested variant file
 func getReferenceNamesInVariantsObject(htsgetReq *HtsgetRequest) ([]string, error) {
-	var referenceNames []string
-	fileURL, err := htsconfig.GetObjectPath(htsgetReq.GetEndpoint(), htsgetReq.GetID())
+	headerLines, err := htsgetReq.getHeaderLines()
 	if err != nil {
-		return nil, err
-	}
-	cmd := exec.Command("bcftools", "view", "-h", fileURL)
-	pipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+		return nil, errors.New("Could not get referenceNames from requested variant file")
 	}
-
-	cmd.Start()
-	reader := bufio.NewReader(pipe)
-	l, _, err := reader.ReadLine()
-
-	for ; err == nil; l, _, err = reader.ReadLine() {
-		pattern := regexp.MustCompile("^##contig=<.*?ID=(.+?)[,>]")
-		submatches := pattern.FindStringSubmatch(string(l))
+	var referenceNames []string
+	pattern := regexp.MustCompile("^##contig=<.*?ID=(.+?)[,>]")
+	for _, line := range headerLines {
+		submatches := pattern.FindStringSubmatch(line)
 		if len(submatches) > 1 {
 			referenceNames = append(referenceNames, submatches[1])
 		}
 	}
+	return referenceNames, nil
+}
 
-	// wait till job completes, if the exit code wasn't 0, then raise an error
-	// likely the file was not found
-	err = cmd.Wait()
+// getReadGroupsInReadsObject gets the read groups in the header of the
+// requested alignment file, mapping each read group ID to its library (empty
+// if the read group has none)
+func getReadGroupsInReadsObject(htsgetReq *HtsgetRequest) (map[string]string, error) {
+	headerLines, err := htsgetReq.getHeaderLines()
 	if err != nil {
-		return nil, errors.New("Could not get referenceNames from requested variant file")
+		return nil, errors.New("Could not get read groups from requested alignment file")
 	}
-	return referenceNames, nil
+	readGroups := make(map[string]string)
+	for _, line := range headerLines {
+		if !strings.HasPrefix(line, "@RG\t") {
+			continue
+		}
+		id, library := "", ""
+		for _, tag := range strings.Split(line, "\t")[1:] {
+			if strings.HasPrefix(tag, "ID:") {
+				id = strings.TrimPrefix(tag, "ID:")
+			} else if strings.HasPrefix(tag, "LB:") {
+				library = strings.TrimPrefix(tag, "LB:")
+			}
+		}
+		readGroups[id] = library
+	}
+	return readGroups, nil
+}
+
+// getSampleNamesInVariantsObject gets the names of the samples in the
+// requested variant file, in the order of its header
+func getSampleNamesInVariantsObject(htsgetReq *HtsgetRequest) ([]string, error) {
+	headerLines, err := htsgetReq.getHeaderLines()
+	if err != nil {
+		return nil, errors.New("Could not get samples from requested variant file")
+	}
+	// samples are the columns after FORMAT in the #CHROM line
+	for _, line := range headerLines {
+		if strings.HasPrefix(line, "#CHROM\t") {
+			columns := strings.Split(line, "\t")
+			if len(columns) > 9 {
+				return columns[9:], nil
+			}
+		}
+	}
+	return nil, nil
 }
 
 // getAllowedReferenceNames
@@ -299,6 +330,29 @@ func (v *ParamValidator) ValidateEnd(htsgetReq *HtsgetRequest, end int) (bool, s
 	return true, ""
 }
 
+// ValidateMinStart validates the 'minStart' query string parameter, set on
+// data urls of multi-region tickets. checks that it is a valid, non-negative
+// integer, and that a region on a true chromosome was requested
+func (v *ParamValidator) ValidateMinStart(htsgetReq *HtsgetRequest, minStart int) (bool, string) {
+	if !htsgetReq.ReferenceNameRequested() || htsgetReq.UnplacedUnmappedReadsRequested() {
+		return false, "'minStart' cannot be set without 'referenceName'"
+	}
+	if !isGreaterThanEqualToZero(minStart) {
+		return false, "'minStart' must be greater than or equal to zero"
+	}
+	return true, ""
+}
+
+// ValidateHeaderBytes validates the 'headerBytes' query string parameter,
+// set on data urls of body blocks. checks that it is a valid, non-negative
+// integer
+func (v *ParamValidator) ValidateHeaderBytes(htsgetReq *HtsgetRequest, headerBytes int) (bool, string) {
+	if !isGreaterThanEqualToZero(headerBytes) {
+		return false, "'headerBytes' must be greater than or equal to zero"
+	}
+	return true, ""
+}
+
 // ValidateFields validates 'fields' parameter. every requested field must
 // have an acceptable BAM/CRAM column name
 func (v *ParamValidator) ValidateFields(htsgetReq *HtsgetRequest, fields []string) (bool, string) {
@@ -346,6 +400,130 @@ func (v *ParamValidator) ValidateNoTags(htsgetReq *HtsgetRequest, notags []strin
 	return true, ""
 }
 
+// ValidateSamples validates the 'samples' parameter. checks that at least one
+// sample was requested, and that every requested sample is in the header of
+// the variant file. as samples are passed to bcftools as a comma-separated
+// list, names containing commas or starting with '^' (excluding the samples
+// instead) can't be requested
+func (v *ParamValidator) ValidateSamples(htsgetReq *HtsgetRequest, samples []string) (bool, string) {
+	if len(samples) == 0 {
+		return false, "'samples' must list at least one sample"
+	}
+	for _, sample := range samples {
+		if strings.Contains(sample, ",") || strings.HasPrefix(sample, "^") {
+			return false, "invalid sample: '" + sample + "', names containing ',' or starting with '^' are not supported"
+		}
+	}
+
+	sampleNames, err := getSampleNamesInVariantsObject(htsgetReq)
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, sample := range samples {
+		if !htsutils.IsItemInArray(sample, sampleNames) {
+			return false, "invalid sample: '" + sample + "'"
+		}
+	}
+	return true, ""
+}
+
+// ValidateMinMappingQuality validates the 'minMappingQuality' parameter.
+// checks that it is a valid MAPQ, between 0 and 255
+func (v *ParamValidator) ValidateMinMappingQuality(htsgetReq *HtsgetRequest, minMappingQuality int) (bool, string) {
+	if htsgetReq.HeaderOnlyRequested() {
+		return false, "'minMappingQuality' incompatible with header-only request"
+	}
+	if !isGreaterThanEqualToZero(minMappingQuality) || minMappingQuality > 255 {
+		return false, "'minMappingQuality' must be between 0 and 255"
+	}
+	return true, ""
+}
+
+// validateFlags checks that FLAG bits are within the 12 bits defined by the
+// SAM specification
+func validateFlags(param string, flags int) (bool, string) {
+	if !isGreaterThanEqualToZero(flags) || flags > 4095 {
+		return false, "'" + param + "' must be between 0 and 4095"
+	}
+	return true, ""
+}
+
+// ValidateRequiredFlags validates the 'requiredFlags' parameter. checks that
+// it holds valid FLAG bits
+func (v *ParamValidator) ValidateRequiredFlags(htsgetReq *HtsgetRequest, requiredFlags int) (bool, string) {
+	if htsgetReq.HeaderOnlyRequested() {
+		return false, "'requiredFlags' incompatible with header-only request"
+	}
+	return validateFlags("requiredFlags", requiredFlags)
+}
+
+// ValidateExcludedFlags validates the 'excludedFlags' parameter. checks that
+// it holds valid FLAG bits, none of which are also required by
+// 'requiredFlags'
+func (v *ParamValidator) ValidateExcludedFlags(htsgetReq *HtsgetRequest, excludedFlags int) (bool, string) {
+	if htsgetReq.HeaderOnlyRequested() {
+		return false, "'excludedFlags' incompatible with header-only request"
+	}
+	if ok, msg := validateFlags("excludedFlags", excludedFlags); !ok {
+		return false, msg
+	}
+	if htsgetReq.RequiredFlagsRequested() && htsgetReq.GetRequiredFlags()&excludedFlags != 0 {
+		return false, "FLAG bits cannot be in both 'requiredFlags' and 'excludedFlags'"
+	}
+	return true, ""
+}
+
+// ValidateReadGroups validates the 'readGroups' parameter. checks that every
+// requested read group is in the header of the alignment file. as read groups
+// are selected by a quoted samtools filter expression, ids containing '"' or
+// '\' can't be requested
+func (v *ParamValidator) ValidateReadGroups(htsgetReq *HtsgetRequest, readGroups []string) (bool, string) {
+	if htsgetReq.HeaderOnlyRequested() {
+		return false, "'readGroups' incompatible with header-only request"
+	}
+	if len(readGroups) == 0 {
+		return false, "'readGroups' must list at least one read group"
+	}
+	for _, readGroup := range readGroups {
+		if strings.ContainsAny(readGroup, "\"\\") {
+			return false, "invalid read group: '" + readGroup + "', ids containing '\"' or '\\' are not supported"
+		}
+	}
+
+	headerReadGroups, err := getReadGroupsInReadsObject(htsgetReq)
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, readGroup := range readGroups {
+		if _, ok := headerReadGroups[readGroup]; !ok {
+			return false, "invalid read group: '" + readGroup + "'"
+		}
+	}
+	return true, ""
+}
+
+// ValidateLibrary validates the 'library' parameter. checks that a read group
+// in the header of the alignment file belongs to the library
+func (v *ParamValidator) ValidateLibrary(htsgetReq *HtsgetRequest, library string) (bool, string) {
+	if htsgetReq.HeaderOnlyRequested() {
+		return false, "'library' incompatible with header-only request"
+	}
+	if library == "" {
+		return false, "'library' must not be empty"
+	}
+
+	headerReadGroups, err := getReadGroupsInReadsObject(htsgetReq)
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, readGroupLibrary := range headerReadGroups {
+		if readGroupLibrary == library {
+			return true, ""
+		}
+	}
+	return false, "invalid library: '" + library + "'"
+}
+
 // ValidateRegions validates whether every region within an array of regions is
 // valid, that is, contains acceptable referenceName, start, and end values
 func (v *ParamValidator) ValidateRegions(htsgetReq *HtsgetRequest, regions []*Region) (bool, string) {
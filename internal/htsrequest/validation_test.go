@@ -6,8 +6,12 @@
 package htsrequest
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
 	"testing"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,8 +37,9 @@ var validateFormatTC = []struct {
 	exp      bool
 }{
 	{htsconstants.APIEndpointReadsTicket, "BAM", true},
-	{htsconstants.APIEndpointReadsTicket, "CRAM", false},
+	{htsconstants.APIEndpointReadsTicket, "CRAM", true},
 	{htsconstants.APIEndpointVariantsTicket, "VCF", true},
+	{htsconstants.APIEndpointVariantsTicket, "BCF", true},
 	{htsconstants.APIEndpointVariantsTicket, "BAM", false},
 }
 
@@ -44,7 +49,7 @@ var validateClassTC = []struct {
 	exp   bool
 }{
 	{"header", true},
-	{"body", false},
+	{"body", true},
 	{"otherclass", false},
 }
 
@@ -120,6 +125,58 @@ var validateEndTC = []struct {
 	{"", "chr1", 100, 500, true},
 }
 
+// validateMinStartTC test cases for ValidateMinStart
+var validateMinStartTC = []struct {
+	referenceName string
+	minStart      int
+	exp           bool
+}{
+	{"", 100, false},
+	{"*", 100, false},
+	{"chr1", -100, false},
+	{"chr1", 0, true},
+	{"chr1", 100, true},
+}
+
+// validateHeaderBytesTC test cases for ValidateHeaderBytes
+var validateHeaderBytesTC = []struct {
+	headerBytes int
+	exp         bool
+}{
+	{-100, false},
+	{0, true},
+	{65536, true},
+}
+
+// validateSamplesTC test cases for ValidateSamples, against the single
+// 'INTEGRATION' sample of the GIAB fixture
+var validateSamplesTC = []struct {
+	samples []string
+	exp     bool
+}{
+	{[]string{"INTEGRATION"}, true},
+	{[]string{"HG003"}, false},
+	{[]string{"INTEGRATION", "HG003"}, false},
+}
+
+// validateReadFiltersTC test cases for ValidateMinMappingQuality,
+// ValidateRequiredFlags and ValidateExcludedFlags
+var validateReadFiltersTC = []struct {
+	class                                         string
+	requiredFlags                                 int
+	minMappingQuality, reqFlags, excludedFlags    int
+	expMinMappingQuality, expReqFlags, expExclude bool
+}{
+	{"", -1, 30, 1, 1024, true, true, true},
+	{"", -1, 0, 0, 0, true, true, true},
+	{"", -1, 255, 4095, 4095, true, true, true},
+	{"", -1, -1, -1, -1, false, false, false},
+	{"", -1, 256, 4096, 4096, false, false, false},
+	{"header", -1, 30, 1, 1024, false, false, false},
+	{"", 3, 30, 3, 2, true, true, false},
+	{"", 3, 30, 3, 12, true, true, true},
+}
+
 // validateFieldsTC test cases for ValidateFields
 var validateFieldsTC = []struct {
 	class  string
@@ -314,6 +371,110 @@ func TestValidateEnd(t *testing.T) {
 	}
 }
 
+// TestValidateMinStart tests ValidateMinStart function
+func TestValidateMinStart(t *testing.T) {
+	for _, tc := range validateMinStartTC {
+		r := NewHtsgetRequest()
+		r.SetReferenceName(tc.referenceName)
+		result, _ := paramValidator.ValidateMinStart(r, tc.minStart)
+		assert.Equal(t, tc.exp, result)
+	}
+}
+
+// TestValidateHeaderBytes tests ValidateHeaderBytes function
+func TestValidateHeaderBytes(t *testing.T) {
+	for _, tc := range validateHeaderBytesTC {
+		result, _ := paramValidator.ValidateHeaderBytes(NewHtsgetRequest(), tc.headerBytes)
+		assert.Equal(t, tc.exp, result)
+	}
+}
+
+// TestValidateSamples tests ValidateSamples function
+func TestValidateSamples(t *testing.T) {
+	r := NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+	r.SetID("HG002_GIAB")
+	result, _ := paramValidator.ValidateSamples(r, []string{})
+	assert.False(t, result)
+
+	if _, err := exec.LookPath("bcftools"); err != nil {
+		t.Skip("bcftools not found")
+	}
+	// resolve the fixture relative to the package directory
+	configJSONBytes, _ := ioutil.ReadFile("../../data/config/integration-tests.config.json")
+	config := new(htsconfig.Configuration)
+	if err := json.Unmarshal(configJSONBytes, config); err != nil {
+		t.Fatal(err)
+	}
+	htsconfig.SetConfigFile(config)
+	htsconfig.LoadConfig()
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+
+	for _, tc := range validateSamplesTC {
+		r := NewHtsgetRequest()
+		r.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+		r.SetID("HG002_GIAB")
+		result, _ := paramValidator.ValidateSamples(r, tc.samples)
+		assert.Equal(t, tc.exp, result)
+	}
+}
+
+// TestValidateReadFilters tests ValidateMinMappingQuality,
+// ValidateRequiredFlags and ValidateExcludedFlags functions
+func TestValidateReadFilters(t *testing.T) {
+	for _, tc := range validateReadFiltersTC {
+		r := NewHtsgetRequest()
+		r.SetClass(tc.class)
+		r.SetRequiredFlags(tc.requiredFlags)
+		result, _ := paramValidator.ValidateMinMappingQuality(r, tc.minMappingQuality)
+		assert.Equal(t, tc.expMinMappingQuality, result)
+		result, _ = paramValidator.ValidateRequiredFlags(r, tc.reqFlags)
+		assert.Equal(t, tc.expReqFlags, result)
+		result, _ = paramValidator.ValidateExcludedFlags(r, tc.excludedFlags)
+		assert.Equal(t, tc.expExclude, result)
+	}
+}
+
+// TestValidateReadGroupsLibrary tests ValidateReadGroups and ValidateLibrary
+// functions. the fixture has no read groups, so any requested read group or
+// library is invalid
+func TestValidateReadGroupsLibrary(t *testing.T) {
+	r := NewHtsgetRequest()
+	r.SetClass("header")
+	result, _ := paramValidator.ValidateReadGroups(r, []string{"rg1"})
+	assert.False(t, result)
+	result, _ = paramValidator.ValidateLibrary(r, "lib1")
+	assert.False(t, result)
+
+	r = NewHtsgetRequest()
+	result, _ = paramValidator.ValidateReadGroups(r, []string{})
+	assert.False(t, result)
+	result, _ = paramValidator.ValidateLibrary(r, "")
+	assert.False(t, result)
+
+	if _, err := exec.LookPath("samtools"); err != nil {
+		t.Skip("samtools not found")
+	}
+	configJSONBytes, _ := ioutil.ReadFile("../../data/config/integration-tests.config.json")
+	config := new(htsconfig.Configuration)
+	if err := json.Unmarshal(configJSONBytes, config); err != nil {
+		t.Fatal(err)
+	}
+	htsconfig.SetConfigFile(config)
+	htsconfig.LoadConfig()
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+
+	r = NewHtsgetRequest()
+	r.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+	r.SetID("tabulamuris.A1-B000168-3_57_F-1-1_R2")
+	result, _ = paramValidator.ValidateReadGroups(r, []string{"rg1"})
+	assert.False(t, result)
+	result, _ = paramValidator.ValidateLibrary(r, "lib1")
+	assert.False(t, result)
+}
+
 // TestValidateFields tests ValidateFields function
 func TestValidateFields(t *testing.T) {
 	for _, tc := range validateFieldsTC {
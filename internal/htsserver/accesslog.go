@@ -0,0 +1,143 @@
+package htsserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader header the request id is read from, if set by a proxy in
+// front of the server, and returned in
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength longest request id accepted from the client
+const maxRequestIDLength = 128
+
+// accessLogWriter records the status, number of bytes, and htsget error
+// type of the response
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	htsgetError string
+}
+
+func (writer *accessLogWriter) RecordHtsgetError(errorName string) {
+	writer.htsgetError = errorName
+}
+
+func (writer *accessLogWriter) WriteHeader(status int) {
+	if writer.status == 0 {
+		writer.status = status
+	}
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *accessLogWriter) Write(b []byte) (int, error) {
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+	n, err := writer.ResponseWriter.Write(b)
+	writer.bytes += int64(n)
+	return n, err
+}
+
+func (writer *accessLogWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// accessLogHandler writes an access log entry for each request once it has
+// been served, and records it in the request metrics. handlers add to the
+// entry through the request context
+func accessLogHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		entry := htslog.NewAccessEntry(requestID)
+		entry.Set("method", r.Method)
+		entry.Set("path", r.URL.Path)
+		writer := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(writer, r.WithContext(htslog.NewContext(r.Context(), entry)))
+
+		if writer.status == 0 {
+			writer.status = http.StatusOK
+		}
+		duration := time.Since(start)
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			entry.Set("endpoint", rctx.RoutePattern())
+			htsmetrics.ObserveRequest(rctx.RoutePattern(), writer.status, writer.htsgetError, duration)
+		}
+		if writer.htsgetError != "" {
+			entry.Set("error", writer.htsgetError)
+		}
+		if entry.Get("id") == nil && chi.URLParam(r, "id") != "" {
+			entry.Set("id", chi.URLParam(r, "id"))
+		}
+		entry.Set("status", writer.status)
+		entry.Set("bytes", writer.bytes)
+		entry.Set("durationMs", float64(duration.Microseconds())/1000)
+
+		level := htslog.LevelInfo
+		if writer.status >= http.StatusInternalServerError {
+			level = htslog.LevelError
+		}
+		htslog.GetLogger().Log(level, "request", entry.Fields())
+	})
+}
+
+// logRequest adds the parsed htsget request to the access log entry, if it
+// is for an object
+func logRequest(handler *requestHandler) {
+	if handler.HtsReq.GetID() == "" {
+		return
+	}
+	entry := htslog.FromContext(handler.Request.Context())
+	entry.Set("id", handler.HtsReq.GetID())
+	entry.Set("regions", len(handler.HtsReq.GetRegions()))
+	if handler.endpoint == htsconstants.APIEndpointReadsData || handler.endpoint == htsconstants.APIEndpointVariantsData {
+		block, _ := strconv.Atoi(handler.HtsReq.GetHtsgetCurrentBlock())
+		entry.Set("block", block)
+	}
+}
+
+// logExitCode adds the exit code of a command run for the request to the
+// access log entry, and records the command in the subprocess metrics
+func logExitCode(handler *requestHandler, command *htscli.Command, exitCode int) {
+	htsmetrics.ObserveSubprocess(command.GetBaseCommand(), exitCode, command.GetDuration())
+	entry := htslog.FromContext(handler.Request.Context())
+	entry.AddExitCode(command.GetBaseCommand(), exitCode)
+	htslog.Debug("command exited", htslog.Fields{
+		"requestId": entry.Get("requestId"),
+		"command":   command.GetBaseCommand(),
+		"args":      redactedArgs(command.GetArgs()),
+		"exitCode":  exitCode,
+	})
+}
+
+// redactedArgs gets the command args without the query strings of urls,
+// which may carry presigned url signatures or tokens
+func redactedArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if j := strings.Index(arg, "?"); j >= 0 && strings.Contains(arg[:j], "://") {
+			redacted[i] = arg[:j] + "?REDACTED"
+		}
+	}
+	return redacted
+}
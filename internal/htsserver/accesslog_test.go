@@ -0,0 +1,118 @@
+package htsserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// accessLogTC test cases for accessLogHandler
+var accessLogTC = []struct {
+	path         string
+	requestID    string
+	expLevel     string
+	expFields    map[string]interface{}
+	expExitCodes bool
+}{
+	{
+		"/reads/object1", "",
+		"info",
+		map[string]interface{}{"method": "GET", "path": "/reads/object1", "endpoint": "/reads/{id}*", "id": "object1", "status": 200.0, "bytes": 5.0},
+		false,
+	},
+	{
+		"/reads/data/object2", "proxy-request-id",
+		"error",
+		map[string]interface{}{"requestId": "proxy-request-id", "endpoint": "/reads/data/{id}*", "id": "object2", "status": 500.0, "error": "InternalServerError", "block": 1.0},
+		true,
+	},
+	{
+		"/missing", strings.Repeat("x", maxRequestIDLength+1),
+		"info",
+		map[string]interface{}{"path": "/missing", "status": 404.0},
+		false,
+	},
+}
+
+func TestAccessLogHandler(t *testing.T) {
+	previous := htslog.GetLogger()
+	defer htslog.SetLogger(previous)
+	out := new(bytes.Buffer)
+	htslog.SetLogger(htslog.NewLogger(out, htslog.LevelInfo))
+
+	router := chi.NewRouter()
+	router.Use(accessLogHandler)
+	router.Get("/reads/{id}*", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	router.Get("/reads/data/{id}*", func(w http.ResponseWriter, r *http.Request) {
+		handler := &requestHandler{Writer: w, Request: r}
+		htslog.FromContext(r.Context()).Set("block", 1)
+		command := htscli.NewCommand()
+		command.SetBaseCommand("samtools")
+		logExitCode(handler, command, 1)
+		htserror.InternalServerError(w, nil)
+	})
+	requests := testutil.ToFloat64(htsmetrics.Requests.WithLabelValues("/reads/data/{id}*", "500"))
+	errors := testutil.ToFloat64(htsmetrics.Errors.WithLabelValues("/reads/data/{id}*", "InternalServerError"))
+	failures := testutil.ToFloat64(htsmetrics.SubprocessFailures.WithLabelValues("samtools"))
+
+	for _, tc := range accessLogTC {
+		out.Reset()
+		request := httptest.NewRequest("GET", tc.path, nil)
+		if tc.requestID != "" {
+			request.Header.Set(requestIDHeader, tc.requestID)
+		}
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &entry), out.String())
+		assert.Equal(t, tc.expLevel, entry["level"])
+		assert.Equal(t, "request", entry["msg"])
+		for key, value := range tc.expFields {
+			assert.Equal(t, value, entry[key], key)
+		}
+		assert.Equal(t, writer.Header().Get(requestIDHeader), entry["requestId"])
+		assert.NotEqual(t, "", entry["requestId"])
+		assert.Contains(t, entry, "durationMs")
+		if tc.expExitCodes {
+			assert.Equal(t, []interface{}{map[string]interface{}{"command": "samtools", "exitCode": 1.0}}, entry["exitCodes"])
+		} else {
+			assert.NotContains(t, entry, "exitCodes")
+		}
+	}
+
+	// requests and commands are recorded in the metrics
+	assert.Equal(t, requests+1, testutil.ToFloat64(htsmetrics.Requests.WithLabelValues("/reads/data/{id}*", "500")))
+	assert.Equal(t, errors+1, testutil.ToFloat64(htsmetrics.Errors.WithLabelValues("/reads/data/{id}*", "InternalServerError")))
+	assert.Equal(t, failures+1, testutil.ToFloat64(htsmetrics.SubprocessFailures.WithLabelValues("samtools")))
+}
+
+// TestRedactedArgs tests url query strings are removed from logged args
+func TestRedactedArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"view",
+		"https://bucket.s3.amazonaws.com/object.bam?REDACTED",
+		"chr1:1-100",
+		"-e",
+		"[RG] == \"a?b\"",
+	}, redactedArgs([]string{
+		"view",
+		"https://bucket.s3.amazonaws.com/object.bam?X-Amz-Signature=secret",
+		"chr1:1-100",
+		"-e",
+		"[RG] == \"a?b\"",
+	}))
+}
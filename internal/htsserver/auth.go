@@ -0,0 +1,129 @@
+package htsserver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/go-chi/chi"
+)
+
+// loadAuthKeySet loads the keys trusted to sign bearer tokens. nil is
+// returned if auth is disabled
+func loadAuthKeySet() (*htsauth.KeySet, error) {
+	if !htsconfig.IsAuthEnabled() {
+		return nil, nil
+	}
+	return htsauth.LoadKeySet(htsconfig.GetAuthJwksFile(), htsconfig.GetAuthIssuerKeyFile())
+}
+
+// authMiddlewares gets the bearer token middleware guarding a ticket
+// endpoint's routes, none if auth is disabled. access is authorized per data
+// source
+func authMiddlewares(keySet *htsauth.KeySet, ep htsconstants.APIEndpoint) chi.Middlewares {
+	if keySet == nil {
+		return nil
+	}
+	return chi.Middlewares{htsauth.Handler(htsauth.Options{
+		KeySet:   keySet,
+		Issuer:   htsconfig.GetAuthIssuer(),
+		Audience: htsconfig.GetAuthAudience(),
+		Authorize: func(request *http.Request, claims *htsauth.Claims) bool {
+			return dataSourceAccessGranted(ep, chi.URLParam(request, "id"), claims, keySet)
+		},
+	})}
+}
+
+// dataSourceAccessGranted checks if the token holds one of the visas
+// required by the data source of the requested id. ids not matching any data
+// source are let through, to be reported as not found by the handler
+func dataSourceAccessGranted(ep htsconstants.APIEndpoint, id string, claims *htsauth.Claims, keySet *htsauth.KeySet) bool {
+	visas, err := htsconfig.GetObjectVisas(ep, id)
+	if err != nil || len(visas) == 0 {
+		return true
+	}
+	for _, grant := range claims.ControlledAccessGrants(keySet, time.Now()) {
+		for _, visa := range visas {
+			if grant == visa {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newURLSigner creates the signer for ticket urls from the configured key
+// and expiry. nil is returned if no key is configured, ticket urls then being
+// unsigned. as unsigned urls can be followed without a token, a key is
+// required if auth is enabled
+func newURLSigner() (*htsauth.URLSigner, error) {
+	key := htsconfig.GetURLSigningKey()
+	if key == "" {
+		if htsconfig.IsAuthEnabled() {
+			return nil, errors.New("urlSigningKey must be set when auth is enabled")
+		}
+		return nil, nil
+	}
+	expiry := time.Duration(htsconfig.GetURLExpiry()) * time.Second
+	return htsauth.NewURLSigner([]byte(key), expiry)
+}
+
+// signedURLMiddlewares gets the middleware only letting through signed urls
+// at the data and file bytes endpoints, none if urls are unsigned
+func signedURLMiddlewares(urlSigner *htsauth.URLSigner) chi.Middlewares {
+	if urlSigner == nil {
+		return nil
+	}
+	return chi.Middlewares{htsauth.SignedURLHandler(urlSigner)}
+}
+
+// ticketURLHeaders converts the headers of a ticket url to those sent by the
+// client following it
+func ticketURLHeaders(headers *htsticket.Headers) http.Header {
+	httpHeaders := http.Header{}
+	if headers == nil {
+		return httpHeaders
+	}
+	for name, value := range map[string]string{
+		"HtsgetBlockClass":   headers.BlockClass,
+		"HtsgetCurrentBlock": headers.CurrentBlock,
+		"HtsgetTotalBlocks":  headers.TotalBlocks,
+		"Range":              headers.Range,
+	} {
+		if value != "" {
+			httpHeaders.Set(name, value)
+		}
+	}
+	return httpHeaders
+}
+
+// signBlockURLs signs the ticket urls pointing back at this server (data and
+// file bytes endpoints), binding them to their id, query, headers and an
+// expiry time. urls to other hosts, or all urls if the signer is nil, are left
+// unchanged
+func signBlockURLs(urlSigner *htsauth.URLSigner, blockURLs []*htsticket.URL) []*htsticket.URL {
+	if urlSigner == nil {
+		return blockURLs
+	}
+	host := htsconfig.GetHost()
+	now := time.Now()
+	for _, blockURL := range blockURLs {
+		if !strings.HasPrefix(blockURL.URL, host) {
+			continue
+		}
+		// the path as routed by the server, without the host's base path
+		routed, err := url.Parse("/" + strings.TrimPrefix(blockURL.URL, host))
+		if err != nil {
+			continue
+		}
+		signature := urlSigner.Sign(routed.EscapedPath(), routed.Query(), ticketURLHeaders(blockURL.Headers), now)
+		blockURL.URL = htsauth.AppendQuery(blockURL.URL, signature)
+	}
+	return blockURLs
+}
@@ -3,19 +3,27 @@ package htsserver
 import (
 	"bufio"
 	"io"
-	"math"
 	"net/http"
-	"os"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
-
+	"github.com/ga4gh/htsget-refserver/internal/htsdao"
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
 	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
-func getFileBytes(writer http.ResponseWriter, request *http.Request) {
+func getReadsFileBytes(writer http.ResponseWriter, request *http.Request) {
+	newRequestHandler(
+		htsconstants.GetMethod,
+		htsconstants.APIEndpointReadsFileBytes,
+		noAfterSetup,
+		getFileBytesHandler,
+	).handleRequest(writer, request)
+}
+
+func getVariantsFileBytes(writer http.ResponseWriter, request *http.Request) {
 	newRequestHandler(
 		htsconstants.GetMethod,
-		htsconstants.APIEndpointFileBytes,
+		htsconstants.APIEndpointVariantsFileBytes,
 		noAfterSetup,
 		getFileBytesHandler,
 	).handleRequest(writer, request)
@@ -24,34 +32,27 @@ func getFileBytes(writer http.ResponseWriter, request *http.Request) {
 func getFileBytesHandler(handler *requestHandler) {
 	start, end, err := htsutils.ParseRangeHeader(handler.HtsReq.GetHtsgetRange())
 	if err != nil {
+		msg := "Invalid Range header"
+		htserror.InvalidRange(handler.Writer, &msg)
 		return
 	}
 
-	file, err := os.Open(handler.HtsReq.GetHtsgetFilePath())
-	reader := bufio.NewReader(file)
-	reader.Discard(int(start))
-
-	chunkSize := 8192
-	nBytes := end - start + 1
-	nChunks := int(math.Ceil(float64(nBytes) / float64(chunkSize)))
-	nBytesRead := int64(0)
-	buffer := make([]byte, chunkSize)
-
-	for i := 0; i < nChunks; i++ {
-
-		n, err := io.ReadFull(reader, buffer)
-		if err != nil {
-
-		}
-		handler.Writer.Write(buffer)
-		// at the second last chunk, create a buffer that will only read bytes
-		// up to the exact remaining needed to fulfill the range request
-		nBytesRead += int64(n)
-		if i == nChunks-2 {
-			bytesRemaining := nBytes - nBytesRead
-			buffer = make([]byte, bytesRemaining)
-		} else {
-			buffer = make([]byte, chunkSize)
-		}
+	// only files within the root of the data source matching the id are
+	// served, gs:// objects are proxied
+	dao, err := htsdao.GetDao(handler.HtsReq)
+	if err != nil {
+		msg := "The requested resource could not be associated with a registered data source"
+		htserror.NotFound(handler.Writer, &msg)
+		return
 	}
+
+	file, err := dao.ReadByteRange(start, end)
+	if err != nil {
+		msg := "Could not read the requested file"
+		htserror.NotFound(handler.Writer, &msg)
+		return
+	}
+	defer file.Close()
+
+	io.Copy(handler.Writer, bufio.NewReader(file))
 }
@@ -2,15 +2,17 @@ package htsserver
 
 import (
 	"bufio"
+	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
-	"os/exec"
+	"strconv"
 
 	"github.com/ga4gh/htsget-refserver/internal/htscli"
-	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
-	"github.com/google/uuid"
 )
 
 func getReadsData(writer http.ResponseWriter, request *http.Request) {
@@ -23,108 +25,285 @@ func getReadsData(writer http.ResponseWriter, request *http.Request) {
 }
 
 func getReadsDataHandler(handler *requestHandler) {
-	fileURL, err := htsconfig.GetObjectPath(handler.HtsReq.GetEndpoint(), handler.HtsReq.GetID())
+	fileURL, err := handler.HtsReq.GetObjectPath()
 	if err != nil {
+		msg := "Could not get the path of " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
 		return
 	}
+	referencePath, err := handler.HtsReq.GetObjectReferencePath()
+	if err != nil {
+		msg := "Could not get the reference path of " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
+		return
+	}
+
+	source, err := newObjectSource(handler.HtsReq, fileURL)
+	if err != nil {
+		msg := "Could not read " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
+		return
+	}
+	defer source.close()
 
+	format := handler.HtsReq.GetFormat()
 	commandChain := htscli.NewCommandChain()
 	removedHeadBytes := 0
-	removedTailBytes := htsconstants.BamEOFLen
+	removedTailBytes := len(readsEOF(format))
 
 	if handler.HtsReq.IsHeaderBlock() {
 		// only get the header for header blocks
-		commandChain.AddCommand(samtoolsViewHeaderOnlyBAM(fileURL))
+		commandChain.AddCommand(samtoolsViewHeaderOnly(source, format, referencePath))
 	} else {
-		// body-based requests will remove header bytes, as they are streamed
-		// in a different block
-		headerByteSize, _ := getHeaderByteSize(fileURL)
-		removedHeadBytes = headerByteSize
 		var region *htsrequest.Region = nil
+		minStart := -1
 		if !handler.HtsReq.AllRegionsRequested() {
 			region = handler.HtsReq.GetRegions()[0]
+			// alignments overlapping the previous region of the ticket were
+			// served by its block
+			if handler.HtsReq.MinStartRequested() {
+				minStart = handler.HtsReq.GetMinStart()
+			}
+			// unplaced reads can't be selected without an index
+			if source.isStreamed() && region.GetReferenceName() == "*" {
+				msg := "Unplaced reads cannot be served from object storage data sources"
+				htserror.InternalServerError(handler.Writer, &msg)
+				return
+			}
 		}
 
 		if handler.HtsReq.AllFieldsRequested() && handler.HtsReq.AllTagsRequested() {
 			// simple streaming of single block without field/tag modification
-			commandChain.AddCommand(samtoolsViewHeaderExcludedBAM(fileURL, region))
+			command, err := samtoolsViewHeaderExcluded(handler.HtsReq, source, region, minStart, format, referencePath)
+			if err != nil {
+				msg := "Could not select the requested region of " + handler.HtsReq.GetID() + ": " + err.Error()
+				htserror.InternalServerError(handler.Writer, &msg)
+				return
+			}
+			commandChain.AddCommand(command)
 
 		} else {
 			// specific fields/tags requested, requires chaining of samtools
 			// with htsget-refserver-utils modify sam commands
-			commandChain.AddCommand(samtoolsViewHeaderIncludedSAM(fileURL, region))
+			command, err := samtoolsViewHeaderIncludedSAM(handler.HtsReq, source, region, minStart, referencePath)
+			if err != nil {
+				msg := "Could not select the requested region of " + handler.HtsReq.GetID() + ": " + err.Error()
+				htserror.InternalServerError(handler.Writer, &msg)
+				return
+			}
+			commandChain.AddCommand(command)
 			commandChain.AddCommand(modifySam(handler.HtsReq))
-			commandChain.AddCommand(samtoolsViewSamToBamStream())
+			commandChain.AddCommand(samtoolsViewSamToStream(format, referencePath))
+		}
+
+		// body-based requests will remove header bytes, as they are streamed
+		// in a different block. the header is measured once when the ticket
+		// is issued, and only measured here for urls without its size
+		removedHeadBytes = handler.HtsReq.GetHeaderBytes()
+		if !handler.HtsReq.HeaderBytesRequested() {
+			headerByteSize, err := readsHeaderByteSize(handler, source, format, referencePath)
+			if err != nil {
+				msg := "Could not read the header of " + handler.HtsReq.GetID() + ": " + err.Error()
+				htserror.InternalServerError(handler.Writer, &msg)
+				return
+			}
+			removedHeadBytes = headerByteSize
 		}
 	}
 
 	// execute command chain and stream output
-	commandWriteStream(commandChain, removedHeadBytes, removedTailBytes, handler.Writer)
+	commandChain.SetEnv(source.env)
+	if err := commandWriteStream(handler, commandChain, removedHeadBytes, removedTailBytes); err != nil {
+		msg := "Could not stream " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
+		return
+	}
 
 	// write EOF on the last block
 	if handler.HtsReq.IsFinalBlock() {
-		writeBamEOF(handler.Writer)
+		writeReadsEOF(format, handler.Writer)
 	}
 }
 
-func commandWriteStream(commandChain *htscli.CommandChain, removeHeadBytes int, removeTailBytes int, writer http.ResponseWriter) error {
+// commandWriteStream runs the command chain, streaming its output to the
+// client without its first removeHeadBytes and last removeTailBytes bytes. the
+// bytes which may be the tail are held back until the output ends. fails if
+// the output was shorter than the bytes to remove, or a command exited with a
+// non-zero code, before anything was written
+func commandWriteStream(handler *requestHandler, commandChain *htscli.CommandChain, removeHeadBytes int, removeTailBytes int) error {
 
 	commandChain.SetupCommandChain()
 	pipe := commandChain.ExecuteCommandChain()
 	reader := bufio.NewReader(pipe)
 	bufferSize := 65536
-	firstLoop := true
-	eofNotReached := true
-
-	for ok := true; ok; ok = eofNotReached {
-		bufferBytes := make([]byte, bufferSize)
-		nBytesRead, _ := io.ReadFull(reader, bufferBytes)
-
-		// indicates this is the last loop
-		if nBytesRead != bufferSize {
-			// remove all unread bytes after EOF,
-			// then remove bytes specified by removeTailBytes
-			bufferBytes = bufferBytes[:nBytesRead]
-			bufferBytes = bufferBytes[:len(bufferBytes)-removeTailBytes]
-			eofNotReached = false
+	bufferBytes := make([]byte, bufferSize)
+	var pending []byte
+	nBytesStreamed := 0
+
+	for eofNotReached := true; eofNotReached; {
+		nBytesRead, err := io.ReadFull(reader, bufferBytes)
+		eofNotReached = err == nil
+		readBytes := bufferBytes[:nBytesRead]
+
+		// the header may span several reads
+		if removeHeadBytes > 0 {
+			nSkipped := removeHeadBytes
+			if nSkipped > len(readBytes) {
+				nSkipped = len(readBytes)
+			}
+			readBytes = readBytes[nSkipped:]
+			removeHeadBytes -= nSkipped
 		}
 
-		// if first loop, remove bytes specified by removeHeadBytes
-		if firstLoop {
-			firstLoop = false
-			bufferBytes = bufferBytes[removeHeadBytes:]
+		// write all but the last removeTailBytes bytes read so far
+		pending = append(pending, readBytes...)
+		if nWritable := len(pending) - removeTailBytes; nWritable > 0 {
+			nBytesWritten, _ := handler.Writer.Write(pending[:nWritable])
+			htsmetrics.StreamedBytes.WithLabelValues(handler.endpoint.String()).Add(float64(nBytesWritten))
+			nBytesStreamed += nBytesWritten
+			pending = append(pending[:0], pending[nWritable:]...)
 		}
+	}
+
+	// all output has been read, collect the exit codes of the chain
+	var exitErr error
+	commands := commandChain.GetCommands()
+	for i, exitCode := range commandChain.WaitCommandChain() {
+		logExitCode(handler, commands[i], exitCode)
+		if exitCode != 0 && exitErr == nil {
+			exitErr = errors.New(commands[i].GetBaseCommand() + " exited with code " + strconv.Itoa(exitCode))
+		}
+	}
 
-		writer.Write(bufferBytes)
+	// once output was written, the response can't be replaced by an error
+	if nBytesStreamed > 0 {
+		return nil
+	}
+	if exitErr != nil {
+		return exitErr
+	}
+	if removeHeadBytes > 0 || len(pending) < removeTailBytes {
+		return errors.New("output ended before the expected header and end of file marker")
 	}
 	return nil
 }
 
-func writeBamEOF(writer http.ResponseWriter) {
-	writer.Write(htsconstants.BamEOF)
+// readsEOF gets the end of file marker of the requested alignment format, the
+// BGZF EOF block for BAM and the EOF container for CRAM
+func readsEOF(format string) []byte {
+	if format == htsconstants.FormatCram {
+		return htsconstants.CramEOF
+	}
+	return htsconstants.BamEOF
+}
+
+func writeReadsEOF(format string, writer http.ResponseWriter) {
+	writer.Write(readsEOF(format))
+}
+
+// samtoolsViewOutput sets the binary output format of a samtools view command
+// based on the requested format, and adds the data source reference (used
+// for decoding and encoding CRAM) if one is configured
+func samtoolsViewOutput(samtoolsView *htscli.SamtoolsViewCommand, format string, referencePath string) *htscli.SamtoolsViewCommand {
+	if format == htsconstants.FormatCram {
+		samtoolsView.OutputCRAM()
+	} else {
+		samtoolsView.OutputBAM()
+	}
+	return samtoolsViewReference(samtoolsView, referencePath)
+}
+
+// samtoolsViewReference adds the data source reference to a samtools view
+// command, if one is configured
+func samtoolsViewReference(samtoolsView *htscli.SamtoolsViewCommand, referencePath string) *htscli.SamtoolsViewCommand {
+	if referencePath != "" {
+		samtoolsView.AddReference(referencePath)
+	}
+	return samtoolsView
+}
+
+// samtoolsViewRegion restricts a samtools view command to the region. as
+// streamed objects can't be queried by region, the region is instead passed
+// as a BED file, filtering the alignments read from stdin. alignments starting
+// before minStart, if not -1, are excluded. an error is returned if the BED
+// file can't be written, as the command would otherwise stream all alignments
+func samtoolsViewRegion(samtoolsView *htscli.SamtoolsViewCommand, source *objectSource, region *htsrequest.Region, minStart int) (*htscli.SamtoolsViewCommand, error) {
+	if region == nil {
+		return samtoolsView, nil
+	}
+	if minStart != -1 {
+		samtoolsView.AddMinStart(minStart)
+	}
+	if source.isStreamed() {
+		regionsFile, err := source.regionsFile(region)
+		if err != nil {
+			return nil, err
+		}
+		return samtoolsView.AddRegionsFile(regionsFile), nil
+	}
+	return samtoolsView.AddRegion(region), nil
+}
+
+// samtoolsViewReadFilters adds the requested read filters (mapping quality,
+// flags, read groups, library) to a samtools view command
+func samtoolsViewReadFilters(samtoolsView *htscli.SamtoolsViewCommand, htsgetReq *htsrequest.HtsgetRequest) *htscli.SamtoolsViewCommand {
+	if htsgetReq.MinMappingQualityRequested() {
+		samtoolsView.AddMinMappingQuality(htsgetReq.GetMinMappingQuality())
+	}
+	if htsgetReq.RequiredFlagsRequested() {
+		samtoolsView.AddRequiredFlags(htsgetReq.GetRequiredFlags())
+	}
+	if htsgetReq.ExcludedFlagsRequested() {
+		samtoolsView.AddExcludedFlags(htsgetReq.GetExcludedFlags())
+	}
+	if !htsgetReq.AllReadGroupsRequested() {
+		samtoolsView.AddReadGroups(htsgetReq.GetReadGroups())
+	}
+	if htsgetReq.LibraryRequested() {
+		samtoolsView.AddLibrary(htsgetReq.GetLibrary())
+	}
+	return samtoolsView
+}
+
+// samtoolsViewCommand exports a samtools view command reading from the
+// source, feeding it a new stream of the object if streamed, and the
+// request's credentials
+func samtoolsViewCommand(samtoolsView *htscli.SamtoolsViewCommand, source *objectSource) *htscli.Command {
+	command := samtoolsView.GetCommand()
+	command.SetEnv(source.env)
+	if stdin := source.stdin(); stdin != nil {
+		command.SetStdin(stdin)
+	}
+	return command
 }
 
 // for header requests
-func samtoolsViewHeaderOnlyBAM(fileURL string) *htscli.Command {
-	return htscli.SamtoolsView().AddFilePath(fileURL).HeaderOnly().OutputBAM().GetCommand()
+func samtoolsViewHeaderOnly(source *objectSource, format string, referencePath string) *htscli.Command {
+	samtoolsView := htscli.SamtoolsView().AddFilePath(source.path).HeaderOnly()
+	return samtoolsViewCommand(samtoolsViewOutput(samtoolsView, format, referencePath), source)
 }
 
 // requests for all fields/tags
-func samtoolsViewHeaderExcludedBAM(fileURL string, region *htsrequest.Region) *htscli.Command {
-	samtoolsView := htscli.SamtoolsView().AddFilePath(fileURL).OutputBAM()
-	if region != nil {
-		samtoolsView.AddRegion(region)
+func samtoolsViewHeaderExcluded(htsgetReq *htsrequest.HtsgetRequest, source *objectSource, region *htsrequest.Region, minStart int, format string, referencePath string) (*htscli.Command, error) {
+	samtoolsView := samtoolsViewOutput(htscli.SamtoolsView().AddFilePath(source.path), format, referencePath)
+	samtoolsView = samtoolsViewReadFilters(samtoolsView, htsgetReq)
+	samtoolsView, err := samtoolsViewRegion(samtoolsView, source, region, minStart)
+	if err != nil {
+		return nil, err
 	}
-	return samtoolsView.GetCommand()
+	return samtoolsViewCommand(samtoolsView, source), nil
 }
 
 // commands used when custom fields/tags are requested
-func samtoolsViewHeaderIncludedSAM(fileURL string, region *htsrequest.Region) *htscli.Command {
-	samtoolsView := htscli.SamtoolsView().AddFilePath(fileURL).HeaderIncluded()
-	if region != nil {
-		samtoolsView.AddRegion(region)
+func samtoolsViewHeaderIncludedSAM(htsgetReq *htsrequest.HtsgetRequest, source *objectSource, region *htsrequest.Region, minStart int, referencePath string) (*htscli.Command, error) {
+	samtoolsView := htscli.SamtoolsView().AddFilePath(source.path).HeaderIncluded()
+	samtoolsView = samtoolsViewReference(samtoolsView, referencePath)
+	samtoolsView = samtoolsViewReadFilters(samtoolsView, htsgetReq)
+	samtoolsView, err := samtoolsViewRegion(samtoolsView, source, region, minStart)
+	if err != nil {
+		return nil, err
 	}
-	return samtoolsView.GetCommand()
+	return samtoolsViewCommand(samtoolsView, source), nil
 }
 
 func modifySam(htsgetReq *htsrequest.HtsgetRequest) *htscli.Command {
@@ -146,27 +325,40 @@ func modifySam(htsgetReq *htsrequest.HtsgetRequest) *htscli.Command {
 	return modifySam.GetCommand()
 }
 
-func samtoolsViewSamToBamStream() *htscli.Command {
-	return htscli.SamtoolsView().OutputBAM().StreamFromStdin().GetCommand()
+func samtoolsViewSamToStream(format string, referencePath string) *htscli.Command {
+	return samtoolsViewOutput(htscli.SamtoolsView(), format, referencePath).StreamFromStdin().GetCommand()
 }
 
-func getHeaderByteSize(fileURL string) (int, error) {
-	cmd := exec.Command("samtools", "view", "-H", "-b", fileURL)
-	tmpHeader, err := htsconfig.CreateTempFile(uuid.New().String() + "_header")
-	if err != nil {
-		return 0, err
-	}
+// readsHeaderByteSize gets the number of bytes the header occupies at the
+// start of the output of body blocks
+func readsHeaderByteSize(handler *requestHandler, source *objectSource, format string, referencePath string) (int, error) {
+	headerOnly := htscli.NewCommandChain()
+	headerOnly.AddCommand(samtoolsViewHeaderOnly(source, format, referencePath))
+	return getHeaderByteSize(handler, headerOnly, len(readsEOF(format)))
+}
 
-	cmd.Stdout = tmpHeader
-	cmd.Run()
+// getHeaderByteSize runs a header-only command chain to completion, getting
+// the number of bytes the header occupies at the start of a full output
+// stream (ie. the header-only output, excluding the trailing end of file
+// marker). fails if any command in the chain exits with a non-zero code, as
+// the output may then be incomplete
+func getHeaderByteSize(handler *requestHandler, headerOnly *htscli.CommandChain, eofLen int) (int, error) {
+	headerOnly.SetupCommandChain()
+	nBytes, err := io.Copy(ioutil.Discard, headerOnly.ExecuteCommandChain())
 
-	fi, err := tmpHeader.Stat()
+	var exitErr error
+	commands := headerOnly.GetCommands()
+	for i, exitCode := range headerOnly.WaitCommandChain() {
+		logExitCode(handler, commands[i], exitCode)
+		if exitCode != 0 && exitErr == nil {
+			exitErr = errors.New(commands[i].GetBaseCommand() + " exited with code " + strconv.Itoa(exitCode))
+		}
+	}
 	if err != nil {
 		return 0, err
 	}
-
-	size := fi.Size() - 28
-	tmpHeader.Close()
-	htsconfig.RemoveTempfile(tmpHeader)
-	return int(size), nil
+	if exitErr != nil {
+		return 0, exitErr
+	}
+	return int(nBytes) - eofLen, nil
 }
@@ -0,0 +1,120 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/stretchr/testify/assert"
+)
+
+// getHeaderByteSizeTC test cases for getHeaderByteSize, scripts standing in
+// for the header-only commands
+var getHeaderByteSizeTC = []struct {
+	scripts   []string
+	expSize   int
+	expErrMsg string
+}{
+	{[]string{"printf 'headerEOF'"}, 6, ""},
+	{[]string{"printf 'headerEOF'", "cat"}, 6, ""},
+	{[]string{"printf 'head'; exit 1"}, 0, "sh exited with code 1"},
+	{[]string{"printf 'headerEOF'", "cat; exit 2"}, 0, "sh exited with code 2"},
+}
+
+// TestGetHeaderByteSize tests getHeaderByteSize function
+func TestGetHeaderByteSize(t *testing.T) {
+	handler := &requestHandler{Request: httptest.NewRequest("GET", "/reads/data/object", nil)}
+	for _, tc := range getHeaderByteSizeTC {
+		headerOnly := htscli.NewCommandChain()
+		for _, script := range tc.scripts {
+			command := htscli.NewCommand()
+			command.SetBaseCommand("sh")
+			command.SetArgs([]string{"-c", script})
+			headerOnly.AddCommand(command)
+		}
+		size, err := getHeaderByteSize(handler, headerOnly, 3)
+		assert.Equal(t, tc.expSize, size)
+		if tc.expErrMsg == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.EqualError(t, err, tc.expErrMsg)
+		}
+	}
+}
+
+// TestDataHandlersPathError tests data handlers respond with an htsget error
+// when the object path can't be resolved, rather than an empty block
+func TestDataHandlersPathError(t *testing.T) {
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	// nothing listens on port 1, so the DRS uris can't be resolved
+	sources := map[string]interface{}{
+		"sources": []map[string]string{{"pattern": "^(?P<name>.*)$", "path": "drs://127.0.0.1:1/{name}"}},
+	}
+	loadTestConfig(t, func(container map[string]interface{}) {
+		container["reads"].(map[string]interface{})["dataSourceRegistry"] = sources
+		container["variants"].(map[string]interface{})["dataSourceRegistry"] = sources
+	})
+
+	dataHandlers := map[htsconstants.APIEndpoint]func(*requestHandler){
+		htsconstants.APIEndpointReadsData:    getReadsDataHandler,
+		htsconstants.APIEndpointVariantsData: getVariantsDataHandler,
+	}
+	for endpoint, dataHandler := range dataHandlers {
+		htsReq := htsrequest.NewHtsgetRequest()
+		htsReq.SetEndpoint(endpoint)
+		htsReq.SetID("object")
+		writer := httptest.NewRecorder()
+		dataHandler(&requestHandler{Writer: writer, Request: httptest.NewRequest("GET", "/data/object", nil), HtsReq: htsReq})
+		assert.Equal(t, http.StatusInternalServerError, writer.Code, endpoint.String())
+		var response map[string]map[string]string
+		assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &response))
+		assert.Equal(t, "InternalServerError", response["htsget"]["error"])
+		assert.Contains(t, response["htsget"]["message"], "Could not get the path of object")
+	}
+}
+
+// commandWriteStreamTC test cases for commandWriteStream, scripts standing
+// in for the commands of a data block
+var commandWriteStreamTC = []struct {
+	scripts         []string
+	removeHeadBytes int
+	removeTailBytes int
+	expError        bool
+	expBody         string
+}{
+	// headers larger than the read buffer
+	{[]string{"head -c 70000 /dev/zero; printf 'bodyEOF'"}, 70000, 3, false, "body"},
+	{[]string{"head -c 200000 /dev/zero; printf 'bodyEOF'", "cat"}, 200000, 3, false, "body"},
+	// an end of file marker split across reads
+	{[]string{"head -c 65534 /dev/zero | tr '\\0' 'a'; printf 'EOF'"}, 0, 3, false, strings.Repeat("a", 65534)},
+	{[]string{"printf 'headerEOF'"}, 6, 3, false, ""},
+	// output shorter than the header and end of file marker
+	{[]string{"printf 'head'"}, 6, 3, true, ""},
+	{[]string{"printf 'headerEO'"}, 6, 3, true, ""},
+	{[]string{"exit 1"}, 0, 0, true, ""},
+}
+
+// TestCommandWriteStream tests commandWriteStream function
+func TestCommandWriteStream(t *testing.T) {
+	for _, tc := range commandWriteStreamTC {
+		commandChain := htscli.NewCommandChain()
+		for _, script := range tc.scripts {
+			command := htscli.NewCommand()
+			command.SetBaseCommand("sh")
+			command.SetArgs([]string{"-c", script})
+			commandChain.AddCommand(command)
+		}
+		writer := httptest.NewRecorder()
+		handler := &requestHandler{Writer: writer, Request: httptest.NewRequest("GET", "/reads/data/object", nil)}
+		err := commandWriteStream(handler, commandChain, tc.removeHeadBytes, tc.removeTailBytes)
+		assert.Equal(t, tc.expError, err != nil, tc.scripts)
+		assert.Equal(t, tc.expBody, writer.Body.String(), tc.scripts)
+	}
+}
@@ -3,14 +3,17 @@ package htsserver
 import (
 	"net/http"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 )
 
-func getReadsTicket(writer http.ResponseWriter, request *http.Request) {
-	newRequestHandler(
-		htsconstants.GetMethod,
-		htsconstants.APIEndpointReadsTicket,
-		addRegionFromQueryString,
-		ticketRequestHandler,
-	).handleRequest(writer, request)
+func getReadsTicket(urlSigner *htsauth.URLSigner) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		newRequestHandler(
+			htsconstants.GetMethod,
+			htsconstants.APIEndpointReadsTicket,
+			addRegionFromQueryString,
+			ticketRequestHandler,
+		).setURLSigner(urlSigner).handleRequest(writer, request)
+	}
 }
@@ -5,8 +5,8 @@ import (
 
 	"github.com/ga4gh/htsget-refserver/internal/htscli"
 
-	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htserror"
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 )
 
@@ -21,40 +21,133 @@ func getVariantsData(writer http.ResponseWriter, request *http.Request) {
 
 // getVariantsData serves the actual data from AWS back to client
 func getVariantsDataHandler(handler *requestHandler) {
-	fileURL, err := htsconfig.GetObjectPath(handler.HtsReq.GetEndpoint(), handler.HtsReq.GetID())
+	fileURL, err := handler.HtsReq.GetObjectPath()
 	if err != nil {
+		msg := "Could not get the path of " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
 		return
 	}
 
-	commandChain := htscli.NewCommandChain()
+	source, err := newObjectSource(handler.HtsReq, fileURL)
+	if err != nil {
+		msg := "Could not read " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
+		return
+	}
+	defer source.close()
+
+	format := handler.HtsReq.GetFormat()
+	outputBCF := format == htsconstants.FormatBcf
+	var commandChain *htscli.CommandChain
 	removedHeadBytes := 0
-	removedTailBytes := 0
+	// BGZF-compressed VCF/BCF streams end with an EOF block, which is only
+	// written once, on the last block
+	removedTailBytes := htsconstants.BamEOFLen
 
 	if handler.HtsReq.IsHeaderBlock() {
 		// only get the header for header blocks
-		commandChain.AddCommand(bcftoolsViewHeaderOnlyVCF(fileURL))
+		commandChain = bcftoolsViewHeaderOnly(handler.HtsReq, source, outputBCF)
 	} else {
+		// BCF body streams always contain the header, which is removed as it
+		// is streamed in a different block. the header is measured once when
+		// the ticket is issued, and only measured here for urls without its
+		// size
+		if outputBCF {
+			removedHeadBytes = handler.HtsReq.GetHeaderBytes()
+			if !handler.HtsReq.HeaderBytesRequested() {
+				headerByteSize, err := variantsHeaderByteSize(handler, source)
+				if err != nil {
+					msg := "Could not read the header of " + handler.HtsReq.GetID() + ": " + err.Error()
+					htserror.InternalServerError(handler.Writer, &msg)
+					return
+				}
+				removedHeadBytes = headerByteSize
+			}
+		}
 		// body-based requests
-		commandChain.AddCommand(bcftoolsViewBodyVCF(handler.HtsReq, fileURL))
+		commandChain = bcftoolsViewBody(handler.HtsReq, source, outputBCF)
 	}
 
 	// execute command chain and stream output
-	commandWriteStream(commandChain, removedHeadBytes, removedTailBytes, handler.Writer)
+	if err := commandWriteStream(handler, commandChain, removedHeadBytes, removedTailBytes); err != nil {
+		msg := "Could not stream " + handler.HtsReq.GetID() + ": " + err.Error()
+		htserror.InternalServerError(handler.Writer, &msg)
+		return
+	}
+
+	// write EOF on the last block
+	if handler.HtsReq.IsFinalBlock() {
+		handler.Writer.Write(htsconstants.BamEOF)
+	}
+}
+
+// bcftoolsViewSource sets the input of a bcftools view command, streamed
+// objects being fed to stdin
+func bcftoolsViewSource(cmd *htscli.BcftoolsViewCommand, source *objectSource) {
+	cmd.SetFilePath(source.path)
+	if stdin := source.stdin(); stdin != nil {
+		cmd.SetStdin(stdin)
+	}
 }
 
-func bcftoolsViewHeaderOnlyVCF(fileURL string) *htscli.Command {
+// bcftoolsViewChain sets the requested samples and output of a bcftools view
+// command. if INFO/FORMAT keys are selected, its output is piped through
+// bcftools annotate to remove the others, and a final bcftools view writes
+// the requested output
+func bcftoolsViewChain(htsgetReq *htsrequest.HtsgetRequest, cmd *htscli.BcftoolsViewCommand, source *objectSource, headerOnly bool, outputBCF bool) *htscli.CommandChain {
+	commandChain := htscli.NewCommandChain()
+	if !htsgetReq.AllSamplesRequested() {
+		cmd.SetSamples(htsgetReq.GetSamples())
+	}
+	cmd.SetHeaderOnly(headerOnly)
+
+	if htsgetReq.AllTagsRequested() {
+		cmd.SetOutputBCF(outputBCF)
+		commandChain.AddCommand(cmd.GetCommand())
+	} else {
+		cmd.SetPiped(true)
+		annotate := htscli.BcftoolsAnnotate()
+		if !htsgetReq.TagsNotSpecified() {
+			annotate.SetTags(htsgetReq.GetTags())
+		}
+		if !htsgetReq.NoTagsNotSpecified() {
+			annotate.SetNoTags(htsgetReq.GetNoTags())
+		}
+		output := htscli.BcftoolsView()
+		output.SetFilePath("-")
+		output.SetHeaderOnly(headerOnly)
+		output.SetOutputBCF(outputBCF)
+
+		commandChain.AddCommand(cmd.GetCommand())
+		commandChain.AddCommand(annotate.GetCommand())
+		commandChain.AddCommand(output.GetCommand())
+	}
+	commandChain.SetEnv(source.env)
+	return commandChain
+}
+
+func bcftoolsViewHeaderOnly(htsgetReq *htsrequest.HtsgetRequest, source *objectSource, outputBCF bool) *htscli.CommandChain {
 	cmd := htscli.BcftoolsView()
-	cmd.SetFilePath(fileURL)
-	cmd.SetHeaderOnly(true)
-	return cmd.GetCommand()
+	bcftoolsViewSource(cmd, source)
+	return bcftoolsViewChain(htsgetReq, cmd, source, true, outputBCF)
+}
+
+// variantsHeaderByteSize gets the number of bytes the header occupies at the
+// start of the output of BCF body blocks
+func variantsHeaderByteSize(handler *requestHandler, source *objectSource) (int, error) {
+	return getHeaderByteSize(handler, bcftoolsViewHeaderOnly(handler.HtsReq, source, true), htsconstants.BamEOFLen)
 }
 
-func bcftoolsViewBodyVCF(htsgetReq *htsrequest.HtsgetRequest, fileURL string) *htscli.Command {
+func bcftoolsViewBody(htsgetReq *htsrequest.HtsgetRequest, source *objectSource, outputBCF bool) *htscli.CommandChain {
 	cmd := htscli.BcftoolsView()
-	cmd.SetFilePath(fileURL)
-	cmd.SetHeaderOnly(false)
+	bcftoolsViewSource(cmd, source)
 	if !htsgetReq.AllRegionsRequested() {
 		cmd.SetRegion(htsgetReq.GetRegions()[0])
+		// variants overlapping the previous region of the ticket were served
+		// by its block
+		if htsgetReq.MinStartRequested() {
+			cmd.SetMinStart(htsgetReq.GetMinStart())
+		}
 	}
-	return cmd.GetCommand()
+	return bcftoolsViewChain(htsgetReq, cmd, source, false, outputBCF)
 }
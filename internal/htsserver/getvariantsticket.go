@@ -3,14 +3,17 @@ package htsserver
 import (
 	"net/http"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 )
 
-func getVariantsTicket(writer http.ResponseWriter, request *http.Request) {
-	newRequestHandler(
-		htsconstants.GetMethod,
-		htsconstants.APIEndpointVariantsTicket,
-		addRegionFromQueryString,
-		ticketRequestHandler,
-	).handleRequest(writer, request)
+func getVariantsTicket(urlSigner *htsauth.URLSigner) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		newRequestHandler(
+			htsconstants.GetMethod,
+			htsconstants.APIEndpointVariantsTicket,
+			addRegionFromQueryString,
+			ticketRequestHandler,
+		).setURLSigner(urlSigner).handleRequest(writer, request)
+	}
 }
@@ -1,12 +1,17 @@
 package htsserver
 
 import (
+	"net/url"
 	"strconv"
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 
 	"github.com/ga4gh/htsget-refserver/internal/htsdao"
 	"github.com/ga4gh/htsget-refserver/internal/htserror"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
 	"github.com/ga4gh/htsget-refserver/internal/htsticket"
 )
 
@@ -27,11 +32,21 @@ func addHeaderBlockURL(blockURLs []*htsticket.URL, request *htsrequest.HtsgetReq
 	return addBlockURL(blockURLs, blockURL)
 }
 
-func addBodyBlockURL(blockURLs []*htsticket.URL, request *htsrequest.HtsgetRequest, currentBlock int, totalBlocks int, useRegion bool, regionI int) []*htsticket.URL {
+func addBodyBlockURL(blockURLs []*htsticket.URL, request *htsrequest.HtsgetRequest, currentBlock int, totalBlocks int, useRegion bool, regionI int, headerBytes int) []*htsticket.URL {
 	blockHeaders := htsticket.NewHeaders().
 		SetCurrentBlock(strconv.Itoa(currentBlock)).
 		SetTotalBlocks(strconv.Itoa(totalBlocks))
+	// without a header block, the data endpoint can't tell the first block
+	// is a body block from its number
+	if request.BodyOnlyRequested() {
+		blockHeaders.SetClassBody()
+	}
 	dataEndpoint, _ := request.ConstructDataEndpointURL(useRegion, regionI)
+	// the header removed from the block's output, measured once for all
+	// blocks of the ticket
+	if headerBytes != -1 {
+		dataEndpoint = setQueryParam(dataEndpoint, "headerBytes", strconv.Itoa(headerBytes))
+	}
 	blockURL := htsticket.NewURL().
 		SetURL(dataEndpoint).
 		SetHeaders(blockHeaders).
@@ -39,6 +54,89 @@ func addBodyBlockURL(blockURLs []*htsticket.URL, request *htsrequest.HtsgetReque
 	return addBlockURL(blockURLs, blockURL)
 }
 
+// setQueryParam sets a query string parameter of the url
+func setQueryParam(rawURL string, key string, value string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := parsedURL.Query()
+	query.Set(key, value)
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
+
+// bodyHeaderByteSize measures the header the data endpoint removes from the
+// output of body blocks, so that the header-only command runs once per
+// ticket rather than once per block. -1 if body blocks are output without a
+// header
+func bodyHeaderByteSize(handler *requestHandler, objPath string) (int, error) {
+	isReads := htsrequest.IsReadsEndpoint(handler.HtsReq.GetEndpoint())
+	if !isReads && handler.HtsReq.GetFormat() != htsconstants.FormatBcf {
+		return -1, nil
+	}
+	source, err := newWholeObjectSource(handler.HtsReq, objPath)
+	if err != nil {
+		return 0, err
+	}
+	defer source.close()
+
+	if isReads {
+		referencePath, err := handler.HtsReq.GetObjectReferencePath()
+		if err != nil {
+			return 0, err
+		}
+		return readsHeaderByteSize(handler, source, handler.HtsReq.GetFormat(), referencePath)
+	}
+	return variantsHeaderByteSize(handler, source)
+}
+
+// sourcePath normalizes the path/url of the underlying data source file for
+// extension checks, ignoring query strings on urls (e.g. presigned urls)
+func sourcePath(path string) string {
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	return strings.ToLower(path)
+}
+
+// formatUncompressedVcf source format of plain text VCF files, which can't be
+// returned as-is as VCF is always served BGZF-compressed
+const formatUncompressedVcf = "uncompressed VCF"
+
+// sourceFormat infers the format of the underlying data source file from its
+// extension. an empty string is returned if the format can't be determined
+func sourceFormat(path string) string {
+	path = sourcePath(path)
+	switch {
+	case strings.HasSuffix(path, ".bam"):
+		return htsconstants.FormatBam
+	case strings.HasSuffix(path, ".cram"):
+		return htsconstants.FormatCram
+	case strings.HasSuffix(path, ".bcf"):
+		return htsconstants.FormatBcf
+	case strings.HasSuffix(path, ".vcf.gz"):
+		return htsconstants.FormatVcf
+	case strings.HasSuffix(path, ".vcf"):
+		return formatUncompressedVcf
+	}
+	return ""
+}
+
+// sourceFormatMatches checks whether the source file can be returned as-is
+// in the requested format. unrecognized source formats are assumed to match
+func sourceFormatMatches(path string, format string) bool {
+	fileFormat := sourceFormat(path)
+	return fileFormat == "" || fileFormat == format
+}
+
+// finalizeTicket writes the ticket of the block urls, recording its number
+// of blocks
+func finalizeTicket(handler *requestHandler, blockURLs []*htsticket.URL) {
+	htsmetrics.TicketBlocks.WithLabelValues(handler.endpoint.String()).Observe(float64(len(blockURLs)))
+	htsticket.FinalizeTicket(handler.HtsReq.GetFormat(), signBlockURLs(handler.urlSigner, blockURLs), handler.Writer)
+}
+
 func ticketRequestHandler(handler *requestHandler) {
 
 	dao, err := htsdao.GetDao(handler.HtsReq)
@@ -48,29 +146,61 @@ func ticketRequestHandler(handler *requestHandler) {
 		return
 	}
 
+	objPath, _ := handler.HtsReq.GetObjectPath()
+
+	// sort and merge the requested regions, so that each record is served
+	// once
+	handler.HtsReq.NormalizeRegions()
+
 	var blockURLs []*htsticket.URL
 
+	// requests that can be resolved to slices of the source file through its
+	// index. if the index can't be used, fall back on the data endpoint
+	if indexedURLs, err := indexedTicketBlockURLs(handler.HtsReq, objPath, dao); err == nil {
+		finalizeTicket(handler, indexedURLs)
+		return
+	}
+
 	// only header is requested, requires one URL block
 	if handler.HtsReq.HeaderOnlyRequested() {
 		blockURLs = addHeaderBlockURL(blockURLs, handler.HtsReq, 1)
 		// pure byte range URLs, requires one block per every x bytes
-	} else if handler.HtsReq.AllFieldsRequested() && handler.HtsReq.AllTagsRequested() && handler.HtsReq.AllRegionsRequested() {
-		blockURLs = dao.GetByteRangeUrls()
+	} else if !handler.HtsReq.BodyOnlyRequested() && handler.HtsReq.AllFieldsRequested() && handler.HtsReq.AllTagsRequested() && handler.HtsReq.AllSamplesRequested() && !handler.HtsReq.ReadFiltersRequested() && handler.HtsReq.AllRegionsRequested() && sourceFormatMatches(objPath, handler.HtsReq.GetFormat()) {
+		blockURLs, err = dao.GetByteRangeUrls()
+		if err != nil {
+			msg := "Could not create byte range urls for " + handler.HtsReq.GetID() + ": " + err.Error()
+			htserror.InternalServerError(handler.Writer, &msg)
+			return
+		}
 	} else {
+		// one header block, omitted if only the body is requested
+		nHeaderBlocks := 1
+		if handler.HtsReq.BodyOnlyRequested() {
+			nHeaderBlocks = 0
+		}
+		// if the header can't be measured, the data endpoint measures it for
+		// each block
+		headerBytes, err := bodyHeaderByteSize(handler, objPath)
+		if err != nil {
+			headerBytes = -1
+		}
 		if handler.HtsReq.AllRegionsRequested() {
-			// the entire file was requested, requires 2 blocks: one for header
-			// and one for body
-			blockURLs = addHeaderBlockURL(blockURLs, handler.HtsReq, 2)
-			blockURLs = addBodyBlockURL(blockURLs, handler.HtsReq, 1, 2, false, 0)
+			// the entire file was requested, requires one block for the body
+			nBlocks := nHeaderBlocks + 1
+			if nHeaderBlocks > 0 {
+				blockURLs = addHeaderBlockURL(blockURLs, handler.HtsReq, nBlocks)
+			}
+			blockURLs = addBodyBlockURL(blockURLs, handler.HtsReq, nHeaderBlocks, nBlocks, false, 0, headerBytes)
 		} else {
-			// one or more regions requested, requires one header block, and one
-			// block for per region
-			nBlocks := handler.HtsReq.NRegions() + 1
-			blockURLs = addHeaderBlockURL(blockURLs, handler.HtsReq, nBlocks)
+			// one or more regions requested, requires one block per region
+			nBlocks := handler.HtsReq.NRegions() + nHeaderBlocks
+			if nHeaderBlocks > 0 {
+				blockURLs = addHeaderBlockURL(blockURLs, handler.HtsReq, nBlocks)
+			}
 			for i := range handler.HtsReq.GetRegions() {
-				blockURLs = addBodyBlockURL(blockURLs, handler.HtsReq, i+1, nBlocks, true, i)
+				blockURLs = addBodyBlockURL(blockURLs, handler.HtsReq, i+nHeaderBlocks, nBlocks, true, i, headerBytes)
 			}
 		}
 	}
-	htsticket.FinalizeTicket(handler.HtsReq.GetFormat(), blockURLs, handler.Writer)
+	finalizeTicket(handler, blockURLs)
 }
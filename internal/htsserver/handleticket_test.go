@@ -0,0 +1,29 @@
+package htsserver
+
+import (
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// sourceFormatMatchesTC test cases for sourceFormatMatches, VCF being served
+// BGZF-compressed whatever the source file
+var sourceFormatMatchesTC = []struct {
+	path, format string
+	exp          bool
+}{
+	{"data/object0001.bam", htsconstants.FormatBam, true},
+	{"data/object0001.bam", htsconstants.FormatCram, false},
+	{"data/object0001.vcf.gz", htsconstants.FormatVcf, true},
+	{"https://example.com/object0001.VCF.GZ?sig=abc", htsconstants.FormatVcf, true},
+	{"data/object0001.vcf", htsconstants.FormatVcf, false},
+	{"data/object0001.vcf.gz", htsconstants.FormatBcf, false},
+	{"data/object0001", htsconstants.FormatVcf, true},
+}
+
+func TestSourceFormatMatches(t *testing.T) {
+	for _, tc := range sourceFormatMatchesTC {
+		assert.Equal(t, tc.exp, sourceFormatMatches(tc.path, tc.format), tc.path)
+	}
+}
@@ -0,0 +1,249 @@
+package htsserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/awsutils"
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/drsutils"
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htslog"
+)
+
+// readiness check types and statuses
+const (
+	checkTypeCommand    = "command"
+	checkTypeDataSource = "dataSource"
+	checkStatusOK       = "ok"
+	checkStatusFail     = "fail"
+	checkStatusSkipped  = "skipped"
+)
+
+// readinessTimeout time each readiness check may take
+var readinessTimeout = 5 * time.Second
+
+// readinessCacheTTL time the readiness check results are reused for, so
+// frequent probes don't each run the commands and request the data sources
+var readinessCacheTTL = 10 * time.Second
+
+// readinessCommands commands that must be installed for the server to be
+// ready
+var readinessCommands = htscli.RequiredCommands
+
+// readinessCheck result of a single readiness check. only the status is
+// reported, the root and error are logged so data source locations aren't
+// exposed to unauthenticated callers
+type readinessCheck struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	root   string
+	err    error
+}
+
+// readinessCache results of the last readiness checks, and when they ran
+var readinessCache struct {
+	sync.Mutex
+	checks  []*readinessCheck
+	checked time.Time
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks []*readinessCheck `json:"checks"`
+}
+
+func writeHealthJSON(writer http.ResponseWriter, code int, v interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(code)
+	json.NewEncoder(writer).Encode(v)
+}
+
+// getHealthz reports the server is alive, without checking its dependencies
+func getHealthz(writer http.ResponseWriter, request *http.Request) {
+	writeHealthJSON(writer, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// getReadyz reports whether the server can serve requests, checking the
+// command-line tools are installed and the data sources are reachable.
+// responds 503 if any check fails
+func getReadyz(writer http.ResponseWriter, request *http.Request) {
+	response := &readinessResponse{Status: "ready", Checks: cachedReadinessChecks()}
+	code := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status == checkStatusFail {
+			response.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+	}
+	writeHealthJSON(writer, code, response)
+}
+
+// cachedReadinessChecks gets the results of the last readiness checks, running
+// them again if they are older than readinessCacheTTL
+func cachedReadinessChecks() []*readinessCheck {
+	readinessCache.Lock()
+	defer readinessCache.Unlock()
+	if readinessCache.checks == nil || time.Since(readinessCache.checked) >= readinessCacheTTL {
+		readinessCache.checks = readinessChecks()
+		readinessCache.checked = time.Now()
+	}
+	return readinessCache.checks
+}
+
+// readinessChecks runs a check for each required command, and each data
+// source of the enabled endpoints. data sources sharing a root are probed
+// once
+func readinessChecks() []*readinessCheck {
+	var checks []*readinessCheck
+	var wg sync.WaitGroup
+	for _, command := range readinessCommands {
+		check := &readinessCheck{Name: command, Type: checkTypeCommand}
+		checks = append(checks, check)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCommand(check)
+		}()
+	}
+
+	rootChecks := map[string][]*readinessCheck{}
+	endpoints := map[string]htsconstants.APIEndpoint{
+		"reads":    htsconstants.APIEndpointReadsTicket,
+		"variants": htsconstants.APIEndpointVariantsTicket,
+	}
+	for _, name := range []string{"reads", "variants"} {
+		registry := htsconfig.GetDataSourceRegistry(endpoints[name])
+		if !htsconfig.IsEndpointEnabled(endpoints[name]) || registry == nil {
+			continue
+		}
+		for i, dataSource := range registry.Sources {
+			root := dataSourceRoot(dataSource.Path)
+			check := &readinessCheck{Name: name + " data source " + strconv.Itoa(i), Type: checkTypeDataSource, root: root}
+			checks = append(checks, check)
+			rootChecks[root] = append(rootChecks[root], check)
+		}
+	}
+	for root, sameRoot := range rootChecks {
+		wg.Add(1)
+		go func(root string, sameRoot []*readinessCheck) {
+			defer wg.Done()
+			status, err := probeDataSourceRoot(root)
+			for _, check := range sameRoot {
+				check.Status = status
+				check.err = err
+			}
+		}(root, sameRoot)
+	}
+	wg.Wait()
+
+	for _, check := range checks {
+		if check.Status == checkStatusFail {
+			htslog.Warn("readiness check failed", htslog.Fields{"check": check.Name, "root": check.root, "error": check.err.Error()})
+		}
+	}
+	return checks
+}
+
+// checkCommand checks the command is on the PATH, and prints its version
+func checkCommand(check *readinessCheck) {
+	if _, err := htscli.GetVersion(check.Name); err != nil {
+		check.Status = checkStatusFail
+		check.err = err
+		return
+	}
+	check.Status = checkStatusOK
+}
+
+// dataSourceRoot gets the part of the data source path shared by all its
+// objects, up to the last "/" before the first placeholder
+func dataSourceRoot(path string) string {
+	if i := strings.Index(path, "{"); i >= 0 {
+		path = path[:i]
+	}
+	return path[:strings.LastIndex(path, "/")+1]
+}
+
+// probeDataSourceRoot checks the data source root is reachable. local roots
+// must be existing directories. remote roots must respond to an HTTP request
+// with a 2xx or 3xx status. for object storage and DRS roots, the bucket,
+// container or DRS API is requested
+//
+// Arguments
+//	root (string): data source root, as given by dataSourceRoot
+// Returns
+//	(string): check status, skipped if the root depends on the object id
+//	(error): if not nil, the reason the root is unreachable
+func probeDataSourceRoot(root string) (string, error) {
+	if strings.HasSuffix(root, "://") {
+		return checkStatusSkipped, errors.New("the host depends on the object id")
+	}
+	var probeURL string
+	var err error
+	switch {
+	case strings.HasPrefix(root, awsutils.S3Proto):
+		probeURL, err = awsutils.S3BucketURL(awsutils.S3Dto{ObjPath: root})
+	case strings.HasPrefix(root, gcsutils.GCSProto):
+		probeURL = gcsutils.BucketURL(gcsutils.GCSDto{ObjPath: root})
+	case azureutils.IsAzurePath(root):
+		probeURL, err = azureutils.ContainerURL(azureutils.AzureDto{ObjPath: root})
+	case drsutils.IsDRSURI(root):
+		probeURL, err = drsutils.ServiceInfoURL(root)
+	case strings.HasPrefix(root, "http://"), strings.HasPrefix(root, "https://"):
+		probeURL = root
+	default:
+		err = probeDirectory(root)
+	}
+	if err == nil && probeURL != "" {
+		err = probeHTTP(probeURL)
+	}
+	if err != nil {
+		return checkStatusFail, err
+	}
+	return checkStatusOK, nil
+}
+
+// probeDirectory checks the local root is an existing directory
+func probeDirectory(root string) error {
+	if root == "" {
+		root = "."
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New(root + " is not a directory")
+	}
+	return nil
+}
+
+// probeHTTP checks the url responds to a HEAD request with a 2xx or 3xx status
+func probeHTTP(probeURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodHead, probeURL, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return errors.New(probeURL + " responded " + res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// dataSourceRootTC test cases for dataSourceRoot
+var dataSourceRootTC = []struct {
+	path    string
+	expRoot string
+}{
+	{"./data/gcp/gatk-test-data/wgs_bam/{accession}.bam", "./data/gcp/gatk-test-data/wgs_bam/"},
+	{"https://example.org/bucket/prefix-{id}.bam", "https://example.org/bucket/"},
+	{"s3://{bucket}/{key}", "s3://"},
+	{"{accession}.bam", ""},
+	{"/data/object.bam", "/data/"},
+}
+
+func TestDataSourceRoot(t *testing.T) {
+	for _, tc := range dataSourceRootTC {
+		assert.Equal(t, tc.expRoot, dataSourceRoot(tc.path))
+	}
+}
+
+func setHealthTestConfig(t *testing.T, sources []map[string]string) {
+	loadTestConfig(t, func(container map[string]interface{}) {
+		reads := container["reads"].(map[string]interface{})
+		reads["dataSourceRegistry"] = map[string]interface{}{"sources": sources}
+		container["variants"] = map[string]interface{}{"enabled": false}
+	})
+}
+
+func TestHealthz(t *testing.T) {
+	writer := httptest.NewRecorder()
+	getHealthz(writer, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status": "ok"}`, writer.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "readyz")
+	defer os.RemoveAll(tempDir)
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	previousCommands := readinessCommands
+	defer func() { readinessCommands = previousCommands }()
+	previousTTL := readinessCacheTTL
+	defer func() { readinessCacheTTL = previousTTL }()
+	readinessCacheTTL = 0
+
+	tool := "htsget-refserver-test-tool"
+	ioutil.WriteFile(filepath.Join(tempDir, tool), []byte("#!/bin/sh\necho 'tool 1.0'\n"), 0755)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", tempDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer okServer.Close()
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failServer.Close()
+	forbiddenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbiddenServer.Close()
+
+	pattern := "^(?P<name>.*)$"
+	readyzTC := []struct {
+		commands  []string
+		sources   []map[string]string
+		expCode   int
+		expStatus string
+		expChecks map[string]string
+	}{
+		{
+			[]string{tool},
+			[]map[string]string{
+				{"pattern": pattern, "path": tempDir + "/{name}.bam"},
+				{"pattern": pattern, "path": tempDir + "/{name}.cram"},
+				{"pattern": pattern, "path": okServer.URL + "/bucket/{name}.bam"},
+				{"pattern": pattern, "path": "s3://{name}/object.bam"},
+			},
+			http.StatusOK, "ready",
+			map[string]string{tool: "ok", "reads data source 0": "ok", "reads data source 1": "ok", "reads data source 2": "ok", "reads data source 3": "skipped"},
+		},
+		{
+			[]string{tool, "htsget-refserver-missing-command"},
+			[]map[string]string{
+				{"pattern": pattern, "path": filepath.Join(tempDir, "missing") + "/{name}.bam"},
+				{"pattern": pattern, "path": failServer.URL + "/{name}.bam"},
+				{"pattern": pattern, "path": forbiddenServer.URL + "/bucket/{name}.bam"},
+			},
+			http.StatusServiceUnavailable, "unavailable",
+			map[string]string{tool: "ok", "htsget-refserver-missing-command": "fail", "reads data source 0": "fail", "reads data source 1": "fail", "reads data source 2": "fail"},
+		},
+	}
+
+	for _, tc := range readyzTC {
+		readinessCommands = tc.commands
+		setHealthTestConfig(t, tc.sources)
+		router, _ := SetRouter()
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, httptest.NewRequest("GET", "/readyz", nil))
+		assert.Equal(t, tc.expCode, writer.Code)
+
+		var response readinessResponse
+		assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &response))
+		assert.Equal(t, tc.expStatus, response.Status)
+		assert.Equal(t, len(tc.expChecks), len(response.Checks))
+		for _, check := range response.Checks {
+			assert.Equal(t, tc.expChecks[check.Name], check.Status, check.Name)
+		}
+
+		// data source locations are not exposed
+		for _, source := range tc.sources {
+			assert.NotContains(t, writer.Body.String(), dataSourceRoot(source["path"]))
+		}
+		assert.NotContains(t, writer.Body.String(), "error")
+	}
+}
+
+// TestReadyzCache tests readiness check results are reused until they expire
+func TestReadyzCache(t *testing.T) {
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	previousCommands := readinessCommands
+	defer func() { readinessCommands = previousCommands }()
+	previousTTL := readinessCacheTTL
+	defer func() { readinessCacheTTL = previousTTL }()
+	readinessCacheTTL = time.Hour
+	readinessCache.checks = nil
+
+	setHealthTestConfig(t, []map[string]string{})
+	router, _ := SetRouter()
+	readyz := func() int {
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, httptest.NewRequest("GET", "/readyz", nil))
+		return writer.Code
+	}
+	readinessCommands = []string{"htsget-refserver-missing-command"}
+	assert.Equal(t, http.StatusServiceUnavailable, readyz())
+	readinessCommands = []string{}
+	assert.Equal(t, http.StatusServiceUnavailable, readyz())
+	readinessCache.checked = time.Now().Add(-readinessCacheTTL)
+	assert.Equal(t, http.StatusOK, readyz())
+}
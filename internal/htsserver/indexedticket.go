@@ -0,0 +1,232 @@
+package htsserver
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsdao"
+	"github.com/ga4gh/htsget-refserver/internal/htsindex"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+)
+
+// index files looked up alongside a BAM / bgzipped VCF file, in order of
+// preference
+var bamIndexSuffixes = []string{".bai", ".csi"}
+var vcfIndexSuffixes = []string{".tbi", ".csi"}
+
+// indexedTicketBlockURLs resolves the ticket to slices of the source file
+// via its index, for requests where this is possible. an error is returned
+// if the request does not qualify, or the source file/index can't be used
+func indexedTicketBlockURLs(request *htsrequest.HtsgetRequest, objPath string, dao htsdao.DataAccessObject) ([]*htsticket.URL, error) {
+	if indexedReadsRequested(request, objPath) {
+		return indexedReadsBlockURLs(request, dao)
+	}
+	if indexedVariantsRequested(request, objPath) {
+		return indexedVariantsBlockURLs(request, dao)
+	}
+	return nil, errors.New("request cannot be resolved from the index")
+}
+
+// indexedReadsRequested checks if a reads ticket can be served as slices of
+// the source BAM file, rather than through the data endpoint. only requests
+// for all, unmodified records in the file's own format qualify
+func indexedReadsRequested(request *htsrequest.HtsgetRequest, objPath string) bool {
+	return request.GetFormat() == htsconstants.FormatBam &&
+		sourceFormat(objPath) == htsconstants.FormatBam &&
+		request.AllFieldsRequested() &&
+		request.AllTagsRequested() &&
+		!request.ReadFiltersRequested() &&
+		(request.HeaderOnlyRequested() || !request.AllRegionsRequested())
+}
+
+// indexedVariantsRequested checks if a variants ticket can be served as
+// slices of the source file, which must be a bgzipped VCF. only requests for
+// all samples and INFO/FORMAT keys qualify
+func indexedVariantsRequested(request *htsrequest.HtsgetRequest, objPath string) bool {
+	return request.GetFormat() == htsconstants.FormatVcf &&
+		strings.HasSuffix(sourcePath(objPath), ".vcf.gz") &&
+		request.AllSamplesRequested() &&
+		request.AllTagsRequested() &&
+		(request.HeaderOnlyRequested() || !request.AllRegionsRequested())
+}
+
+// indexedReadsBlockURLs resolves the requested header/regions to slices of
+// the source BAM file via its BAI/CSI index. whole BGZF blocks are served as
+// byte ranges of the file, partial blocks at region boundaries as inline data
+func indexedReadsBlockURLs(request *htsrequest.HtsgetRequest, dao htsdao.DataAccessObject) ([]*htsticket.URL, error) {
+	header, err := readBAMHeader(dao)
+	if err != nil {
+		return nil, err
+	}
+	headerSegments, err := indexedHeaderSegments(request, dao, header.End)
+	if err != nil {
+		return nil, err
+	}
+
+	bodySegments := []*htsindex.Segment{}
+	if !request.HeaderOnlyRequested() {
+		index, err := readIndex(dao, bamIndexSuffixes)
+		if err != nil {
+			return nil, err
+		}
+		bodySegments, err = indexedRegionSegments(request, dao, index, header.ReferenceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexedSegmentURLs(request, dao, headerSegments, bodySegments)
+}
+
+// indexedVariantsBlockURLs resolves the requested header/regions to slices
+// of the source bgzipped VCF via its tabix/CSI index
+func indexedVariantsBlockURLs(request *htsrequest.HtsgetRequest, dao htsdao.DataAccessObject) ([]*htsticket.URL, error) {
+	headerEnd, err := readVCFHeaderEnd(dao)
+	if err != nil {
+		return nil, err
+	}
+	headerSegments, err := indexedHeaderSegments(request, dao, headerEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	bodySegments := []*htsindex.Segment{}
+	if !request.HeaderOnlyRequested() {
+		index, err := readIndex(dao, vcfIndexSuffixes)
+		if err != nil {
+			return nil, err
+		}
+		bodySegments, err = indexedRegionSegments(request, dao, index, index.ReferenceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexedSegmentURLs(request, dao, headerSegments, bodySegments)
+}
+
+// indexedHeaderSegments gets the segments of the source file holding the
+// header, none if only the body is requested
+func indexedHeaderSegments(request *htsrequest.HtsgetRequest, dao htsdao.DataAccessObject, headerEnd htsbgzf.VirtualOffset) ([]*htsindex.Segment, error) {
+	if request.BodyOnlyRequested() {
+		return []*htsindex.Segment{}, nil
+	}
+	return htsindex.HeaderSegments(dao, headerEnd)
+}
+
+// indexedRegionSegments gets the segments of the source file holding the
+// records overlapping all requested regions
+func indexedRegionSegments(request *htsrequest.HtsgetRequest, dao htsdao.DataAccessObject, index *htsindex.Index, referenceID func(string) int) ([]*htsindex.Segment, error) {
+	chunks := []htsindex.Chunk{}
+	for _, region := range request.GetRegions() {
+		// unplaced reads are not covered by the binning index
+		if region.GetReferenceName() == "*" {
+			return nil, errors.New("unplaced reads cannot be resolved from the index")
+		}
+		beg, end := int64(0), index.MaxPosition()
+		if region.StartRequested() {
+			beg = int64(region.GetStart())
+		}
+		if region.EndRequested() {
+			end = int64(region.GetEnd())
+		}
+		chunks = append(chunks, index.Query(referenceID(region.GetReferenceName()), beg, end)...)
+	}
+	return htsindex.ChunkSegments(dao, htsindex.MergeChunks(chunks))
+}
+
+// indexedSegmentURLs gets the ticket urls of header and body segments. unless
+// only the header is requested, the body is terminated by the BGZF EOF marker
+func indexedSegmentURLs(request *htsrequest.HtsgetRequest, dao htsdao.DataAccessObject, headerSegments []*htsindex.Segment, bodySegments []*htsindex.Segment) ([]*htsticket.URL, error) {
+	blockURLs := []*htsticket.URL{}
+	for _, segment := range headerSegments {
+		urls, err := segmentURLs(dao, segment)
+		if err != nil {
+			return nil, err
+		}
+		for _, blockURL := range urls {
+			blockURLs = addBlockURL(blockURLs, blockURL.SetClassHeader())
+		}
+	}
+	for _, segment := range bodySegments {
+		urls, err := segmentURLs(dao, segment)
+		if err != nil {
+			return nil, err
+		}
+		for _, blockURL := range urls {
+			blockURLs = addBlockURL(blockURLs, blockURL.SetClassBody())
+		}
+	}
+	if request.HeaderOnlyRequested() {
+		return blockURLs, nil
+	}
+	eofURL := htsticket.NewURL().SetDataURI(htsconstants.BamEOF).SetClassBody()
+	return addBlockURL(blockURLs, eofURL), nil
+}
+
+// segmentURLs gets the url(s) for a single segment, either inline data, or
+// byte ranges of at most SingleBlockByteSize bytes. an error is returned if a
+// byte range url can't be created, e.g. presigning fails
+func segmentURLs(dao htsdao.DataAccessObject, segment *htsindex.Segment) ([]*htsticket.URL, error) {
+	if segment.IsInline() {
+		return []*htsticket.URL{htsticket.NewURL().SetDataURI(segment.Data)}, nil
+	}
+	urls := []*htsticket.URL{}
+	for start := segment.Start; start <= segment.End; start += htsconstants.SingleBlockByteSize {
+		end := start + htsconstants.SingleBlockByteSize - 1
+		if end > segment.End {
+			end = segment.End
+		}
+		url, err := dao.GetByteRangeURL(start, end)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+func readBAMHeader(dao htsdao.DataAccessObject) (*htsindex.BAMHeader, error) {
+	source, err := dao.ReadByteRange(0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+	return htsindex.ReadBAMHeader(htsbgzf.NewReader(source, 0))
+}
+
+func readVCFHeaderEnd(dao htsdao.DataAccessObject) (htsbgzf.VirtualOffset, error) {
+	source, err := dao.ReadByteRange(0, -1)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+	return htsindex.ReadVCFHeaderEnd(htsbgzf.NewReader(source, 0))
+}
+
+func readIndex(dao htsdao.DataAccessObject, suffixes []string) (*htsindex.Index, error) {
+	for _, suffix := range suffixes {
+		indexFile, err := dao.ReadAssociatedFile(suffix)
+		if err != nil {
+			continue
+		}
+		index, err := parseIndex(indexFile, suffix)
+		indexFile.Close()
+		if err == nil {
+			return index, nil
+		}
+	}
+	return nil, errors.New("no usable index found for " + dao.String())
+}
+
+func parseIndex(indexFile io.Reader, suffix string) (*htsindex.Index, error) {
+	switch suffix {
+	case ".csi":
+		return htsindex.ReadCSI(indexFile)
+	case ".tbi":
+		return htsindex.ReadTabix(indexFile)
+	}
+	return htsindex.ReadBAI(indexFile)
+}
@@ -0,0 +1,97 @@
+package htsserver
+
+import (
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsindex"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/stretchr/testify/assert"
+)
+
+// indexedVariantsRequestedTC test cases for indexedVariantsRequested, requests
+// for some samples or INFO/FORMAT keys going through the data endpoint
+var indexedVariantsRequestedTC = []struct {
+	samples, tags, notags []string
+	exp                   bool
+}{
+	{[]string{"ALL"}, []string{"ALL"}, []string{"NONE"}, true},
+	{[]string{"HG002"}, []string{"ALL"}, []string{"NONE"}, false},
+	{[]string{"ALL"}, []string{"DP"}, []string{"NONE"}, false},
+	{[]string{"ALL"}, []string{"ALL"}, []string{"AC"}, false},
+}
+
+func TestIndexedVariantsRequested(t *testing.T) {
+	for _, tc := range indexedVariantsRequestedTC {
+		request := htsrequest.NewHtsgetRequest()
+		request.SetEndpoint(htsconstants.APIEndpointVariantsTicket)
+		request.SetFormat(htsconstants.FormatVcf)
+		request.SetRegions([]*htsrequest.Region{{ReferenceName: "1"}})
+		request.SetSamples(tc.samples)
+		request.SetTags(tc.tags)
+		request.SetNoTags(tc.notags)
+		assert.Equal(t, tc.exp, indexedVariantsRequested(request, "data/object0001.vcf.gz"))
+	}
+}
+
+// indexedReadsRequestedTC test cases for indexedReadsRequested, requests
+// filtering reads going through the data endpoint
+var indexedReadsRequestedTC = []struct {
+	setFilter func(request *htsrequest.HtsgetRequest)
+	exp       bool
+}{
+	{func(request *htsrequest.HtsgetRequest) {}, true},
+	{func(request *htsrequest.HtsgetRequest) { request.SetMinMappingQuality(30) }, false},
+	{func(request *htsrequest.HtsgetRequest) { request.SetRequiredFlags(0) }, false},
+	{func(request *htsrequest.HtsgetRequest) { request.SetExcludedFlags(1024) }, false},
+	{func(request *htsrequest.HtsgetRequest) { request.SetReadGroups([]string{"rg1"}) }, false},
+	{func(request *htsrequest.HtsgetRequest) { request.SetLibrary("lib1") }, false},
+}
+
+func TestIndexedReadsRequested(t *testing.T) {
+	for _, tc := range indexedReadsRequestedTC {
+		request := htsrequest.NewHtsgetRequest()
+		request.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+		request.SetFormat(htsconstants.FormatBam)
+		request.SetFields([]string{"ALL"})
+		request.SetTags([]string{"ALL"})
+		request.SetNoTags([]string{"NONE"})
+		request.SetRegions([]*htsrequest.Region{{ReferenceName: "chr1"}})
+		tc.setFilter(request)
+		assert.Equal(t, tc.exp, indexedReadsRequested(request, "data/object0001.bam"))
+	}
+}
+
+// indexedSegmentURLsTC test cases for indexedSegmentURLs, the expected
+// classes of the ticket urls. the EOF marker ends body and whole-file tickets
+var indexedSegmentURLsTC = []struct {
+	class    string
+	expClass []string
+}{
+	{"", []string{"header", "body", "body"}},
+	{"header", []string{"header"}},
+	{"body", []string{"body", "body"}},
+}
+
+func TestIndexedSegmentURLs(t *testing.T) {
+	for _, tc := range indexedSegmentURLsTC {
+		request := htsrequest.NewHtsgetRequest()
+		request.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+		request.SetClass(tc.class)
+		headerSegments := []*htsindex.Segment{}
+		if tc.class != "body" {
+			headerSegments = append(headerSegments, &htsindex.Segment{Data: []byte("header")})
+		}
+		bodySegments := []*htsindex.Segment{}
+		if tc.class != "header" {
+			bodySegments = append(bodySegments, &htsindex.Segment{Data: []byte("body")})
+		}
+		classes := []string{}
+		blockURLs, err := indexedSegmentURLs(request, nil, headerSegments, bodySegments)
+		assert.Nil(t, err)
+		for _, blockURL := range blockURLs {
+			classes = append(classes, blockURL.Class)
+		}
+		assert.Equal(t, tc.expClass, classes)
+	}
+}
@@ -0,0 +1,47 @@
+package htsserver
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+)
+
+// readObjectHeader reads the header of the requested object as text. objects
+// in storage are streamed to samtools/bcftools in the same way as for the
+// data endpoints, rather than passing their s3://, gs:// or az:// path
+func readObjectHeader(htsgetReq *htsrequest.HtsgetRequest) ([]byte, error) {
+	objPath, err := htsgetReq.GetObjectPath()
+	if err != nil {
+		return nil, err
+	}
+	source, err := newWholeObjectSource(htsgetReq, objPath)
+	if err != nil {
+		return nil, err
+	}
+	defer source.close()
+
+	var command *htscli.Command
+	if htsrequest.IsReadsEndpoint(htsgetReq.GetEndpoint()) {
+		command = samtoolsViewCommand(htscli.SamtoolsView().AddFilePath(source.path).HeaderOnly(), source)
+	} else {
+		bcftoolsView := htscli.BcftoolsView()
+		bcftoolsViewSource(bcftoolsView, source)
+		bcftoolsView.SetHeaderOnly(true)
+		bcftoolsView.SetOutputVCF(true)
+		command = bcftoolsView.GetCommand()
+		command.SetEnv(source.env)
+	}
+
+	header := new(bytes.Buffer)
+	command.SetupCmd()
+	exitCode := command.RunCmd(header)
+	htsmetrics.ObserveSubprocess(command.GetBaseCommand(), exitCode, command.GetDuration())
+	if exitCode != 0 {
+		return nil, errors.New(command.GetBaseCommand() + " exited with code " + strconv.Itoa(exitCode))
+	}
+	return header.Bytes(), nil
+}
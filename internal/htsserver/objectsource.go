@@ -0,0 +1,229 @@
+package htsserver
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ga4gh/htsget-refserver/internal/awsutils"
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsbgzf"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsdao"
+	"github.com/ga4gh/htsget-refserver/internal/htsindex"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/google/uuid"
+)
+
+// stdinPath path samtools/bcftools read their input from stdin with
+const stdinPath = "-"
+
+// objectSource the input of the samtools/bcftools commands serving a data
+// request. most sources are read by the tools themselves, from their path or
+// url. s3://, gs:// and Azure blob objects are instead read by the server,
+// so credentials stay in-process and htslib needs no cloud storage support,
+// and streamed to the tools' stdin. the request's AWS credentials, if any,
+// are exported to the tools' environment, for other s3:// files they read
+// (e.g. references)
+type objectSource struct {
+	path      string
+	env       []string
+	dao       htsdao.DataAccessObject
+	segments  []*htsindex.Segment
+	streams   []io.Closer
+	tempFiles []*os.File
+}
+
+// newObjectSource gets the input for a data request on the object. streamed
+// objects are restricted to the segments overlapping the requested region
+// when the object is indexed, otherwise the whole object is streamed
+func newObjectSource(request *htsrequest.HtsgetRequest, objPath string) (*objectSource, error) {
+	source, err := newWholeObjectSource(request, objPath)
+	if err != nil {
+		return nil, err
+	}
+	if source.isStreamed() && !request.AllRegionsRequested() {
+		source.segments = streamedRegionSegments(request, objPath, source.dao)
+	}
+	return source, nil
+}
+
+// newWholeObjectSource gets an input reading the object from its start,
+// whatever region is requested
+func newWholeObjectSource(request *htsrequest.HtsgetRequest, objPath string) (*objectSource, error) {
+	source := new(objectSource)
+	source.path = objPath
+	if provider := awsutils.CredentialsProviderFromContext(request.GetContext()); provider != nil {
+		if cred, err := provider.Retrieve(request.GetContext()); err == nil {
+			source.env = awsutils.CredentialsEnv(cred)
+		}
+	}
+	if !isObjectStoragePath(objPath) {
+		return source, nil
+	}
+
+	dao, err := htsdao.GetDao(request)
+	if err != nil {
+		return nil, err
+	}
+	source.path = stdinPath
+	source.dao = dao
+	return source, nil
+}
+
+// isObjectStoragePath checks if the path is an s3://, gs:// or Azure blob
+// object, read by the server rather than samtools/bcftools
+func isObjectStoragePath(objPath string) bool {
+	return strings.HasPrefix(objPath, awsutils.S3Proto) ||
+		strings.HasPrefix(objPath, gcsutils.GCSProto) ||
+		azureutils.IsAzurePath(objPath)
+}
+
+// streamedRegionSegments gets the header and region segments of an indexed
+// BAM or bgzipped VCF, nil if the object can't be resolved through an index
+func streamedRegionSegments(request *htsrequest.HtsgetRequest, objPath string, dao htsdao.DataAccessObject) []*htsindex.Segment {
+	var headerEnd htsbgzf.VirtualOffset
+	var index *htsindex.Index
+	var referenceID func(string) int
+	var err error
+
+	switch {
+	case sourceFormat(objPath) == htsconstants.FormatBam:
+		header, err := readBAMHeader(dao)
+		if err != nil {
+			return nil
+		}
+		headerEnd, referenceID = header.End, header.ReferenceID
+		if index, err = readIndex(dao, bamIndexSuffixes); err != nil {
+			return nil
+		}
+	case strings.HasSuffix(sourcePath(objPath), ".vcf.gz"):
+		if headerEnd, err = readVCFHeaderEnd(dao); err != nil {
+			return nil
+		}
+		if index, err = readIndex(dao, vcfIndexSuffixes); err != nil {
+			return nil
+		}
+		referenceID = index.ReferenceID
+	default:
+		return nil
+	}
+
+	headerSegments, err := htsindex.HeaderSegments(dao, headerEnd)
+	if err != nil {
+		return nil
+	}
+	bodySegments, err := indexedRegionSegments(request, dao, index, referenceID)
+	if err != nil {
+		return nil
+	}
+	return append(headerSegments, bodySegments...)
+}
+
+// isStreamed checks if the object is streamed to stdin by the server
+func (source *objectSource) isStreamed() bool {
+	return source.dao != nil
+}
+
+// stdin opens a new stream of the object, to be fed to a single command's
+// stdin. nil is returned if the object is not streamed
+func (source *objectSource) stdin() io.Reader {
+	if !source.isStreamed() {
+		return nil
+	}
+	stream := newSegmentStream(source.dao, source.segments)
+	source.streams = append(source.streams, stream)
+	return stream
+}
+
+// regionsFile writes the region to a temporary BED file, used to filter
+// streamed objects that can't be queried through an index
+func (source *objectSource) regionsFile(region *htsrequest.Region) (string, error) {
+	file, err := htsconfig.CreateTempFile(uuid.New().String() + "_regions.bed")
+	if err != nil {
+		return "", err
+	}
+	source.tempFiles = append(source.tempFiles, file)
+	if _, err := file.WriteString(region.ExportBED()); err != nil {
+		return "", err
+	}
+	return file.Name(), file.Sync()
+}
+
+// close closes all streams opened from the object, and removes temporary
+// files
+func (source *objectSource) close() {
+	for _, stream := range source.streams {
+		stream.Close()
+	}
+	for _, file := range source.tempFiles {
+		file.Close()
+		htsconfig.RemoveTempfile(file)
+	}
+}
+
+// segmentStream reads segments of an object in order, opening each byte
+// range as it is reached. without segments, the whole object is read
+type segmentStream struct {
+	dao      htsdao.DataAccessObject
+	segments []*htsindex.Segment
+	current  io.ReadCloser
+}
+
+func newSegmentStream(dao htsdao.DataAccessObject, segments []*htsindex.Segment) *segmentStream {
+	stream := new(segmentStream)
+	stream.dao = dao
+	if segments == nil {
+		stream.segments = []*htsindex.Segment{{Start: 0, End: -1}}
+	} else {
+		// segments are terminated by the BGZF EOF marker
+		eof := &htsindex.Segment{Data: htsconstants.BamEOF}
+		stream.segments = append(append([]*htsindex.Segment{}, segments...), eof)
+	}
+	return stream
+}
+
+func (stream *segmentStream) Read(p []byte) (int, error) {
+	for {
+		if stream.current == nil {
+			if len(stream.segments) == 0 {
+				return 0, io.EOF
+			}
+			segment := stream.segments[0]
+			stream.segments = stream.segments[1:]
+			if segment.IsInline() {
+				stream.current = ioutil.NopCloser(bytes.NewReader(segment.Data))
+			} else {
+				current, err := stream.dao.ReadByteRange(segment.Start, segment.End)
+				if err != nil {
+					return 0, err
+				}
+				stream.current = current
+			}
+		}
+		n, err := stream.current.Read(p)
+		if err == io.EOF {
+			stream.current.Close()
+			stream.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (stream *segmentStream) Close() error {
+	stream.segments = nil
+	if stream.current != nil {
+		err := stream.current.Close()
+		stream.current = nil
+		return err
+	}
+	return nil
+}
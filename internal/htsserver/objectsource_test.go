@@ -0,0 +1,74 @@
+package htsserver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htscli"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsdao"
+	"github.com/ga4gh/htsget-refserver/internal/htsindex"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/stretchr/testify/assert"
+)
+
+var segmentStreamTC = []struct {
+	segments []*htsindex.Segment
+	exp      string
+}{
+	// the whole object is streamed without segments
+	{nil, "0123456789"},
+	{
+		[]*htsindex.Segment{
+			{Start: 2, End: 4},
+			{Data: []byte("ab")},
+			{Start: 8, End: 9},
+		},
+		"234ab89" + string(htsconstants.BamEOF),
+	},
+}
+
+func TestSegmentStream(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "objectsource")
+	defer os.RemoveAll(tempDir)
+	objPath := filepath.Join(tempDir, "object0001")
+	ioutil.WriteFile(objPath, []byte("0123456789"), 0644)
+	dao := htsdao.NewFilePathDao("object0001", objPath, htsconstants.APIEndpointReadsData)
+
+	for _, tc := range segmentStreamTC {
+		stream := newSegmentStream(dao, tc.segments)
+		streamed, err := ioutil.ReadAll(stream)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.exp, string(streamed))
+		assert.Nil(t, stream.Close())
+	}
+}
+
+func TestObjectSourceNotStreamed(t *testing.T) {
+	source, err := newObjectSource(htsrequest.NewHtsgetRequest(), "./data/object0001.bam")
+	assert.Nil(t, err)
+	assert.False(t, source.isStreamed())
+	assert.Equal(t, "./data/object0001.bam", source.path)
+	assert.Nil(t, source.stdin())
+	source.close()
+}
+
+func TestSamtoolsViewRegionFileError(t *testing.T) {
+	loadTestConfig(t, func(container map[string]interface{}) {
+		container["props"].(map[string]interface{})["tempDir"] = "/nonexistent/htsget"
+	})
+	defer loadTestConfig(t, func(container map[string]interface{}) {})
+
+	dao := htsdao.NewFilePathDao("object0001", "./data/object0001.bam", htsconstants.APIEndpointReadsData)
+	source := &objectSource{path: stdinPath, dao: dao}
+	defer source.close()
+	region := htsrequest.NewRegion()
+	region.SetReferenceName("chr1")
+
+	// the region can't be dropped, or all alignments would be streamed
+	samtoolsView, err := samtoolsViewRegion(htscli.SamtoolsView(), source, region, -1)
+	assert.Nil(t, samtoolsView)
+	assert.NotNil(t, err)
+}
@@ -3,14 +3,17 @@ package htsserver
 import (
 	"net/http"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 )
 
-func postReadsTicket(writer http.ResponseWriter, request *http.Request) {
-	newRequestHandler(
-		htsconstants.PostMethod,
-		htsconstants.APIEndpointReadsTicket,
-		noAfterSetup,
-		ticketRequestHandler,
-	).handleRequest(writer, request)
+func postReadsTicket(urlSigner *htsauth.URLSigner) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		newRequestHandler(
+			htsconstants.PostMethod,
+			htsconstants.APIEndpointReadsTicket,
+			noAfterSetup,
+			ticketRequestHandler,
+		).setURLSigner(urlSigner).handleRequest(writer, request)
+	}
 }
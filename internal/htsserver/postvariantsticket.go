@@ -3,14 +3,17 @@ package htsserver
 import (
 	"net/http"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 )
 
-func postVariantsTicket(writer http.ResponseWriter, request *http.Request) {
-	newRequestHandler(
-		htsconstants.PostMethod,
-		htsconstants.APIEndpointVariantsTicket,
-		noAfterSetup,
-		ticketRequestHandler,
-	).handleRequest(writer, request)
+func postVariantsTicket(urlSigner *htsauth.URLSigner) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		newRequestHandler(
+			htsconstants.PostMethod,
+			htsconstants.APIEndpointVariantsTicket,
+			noAfterSetup,
+			ticketRequestHandler,
+		).setURLSigner(urlSigner).handleRequest(writer, request)
+	}
 }
@@ -0,0 +1,173 @@
+package htsserver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+var authTestKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+var authTestVisa = "https://dac.example.org/datasets/giab"
+
+// signAuthTestToken creates a token signed by the test key, optionally
+// holding a ControlledAccessGrants visa
+func signAuthTestToken(key *rsa.PrivateKey, visa string) string {
+	claims := map[string]interface{}{
+		"iss": "https://issuer.example.org",
+		"sub": "user0001",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	if visa != "" {
+		claims["ga4gh_visa_v1"] = map[string]interface{}{
+			"type":  "ControlledAccessGrants",
+			"value": visa,
+		}
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// setAuthTestConfig loads the integration test config with auth enabled,
+// restricting the variants data source to holders of the test visa
+func setAuthTestConfig(t *testing.T, jwksFile string, urlSigningKey string) {
+	loadTestConfig(t, func(container map[string]interface{}) {
+		container["props"].(map[string]interface{})["urlSigningKey"] = urlSigningKey
+		container["auth"] = map[string]interface{}{
+			"enabled":  true,
+			"jwksFile": jwksFile,
+			"issuer":   "https://issuer.example.org",
+		}
+		variants := container["variants"].(map[string]interface{})
+		sources := variants["dataSourceRegistry"].(map[string]interface{})["sources"].([]interface{})
+		sources[0].(map[string]interface{})["visas"] = []string{authTestVisa}
+	})
+}
+
+var httpRequestAuthTC = []struct {
+	endpoint      string
+	token         string
+	headers       [][]string
+	expCode       int
+	expError      string
+	expSignedURLs bool
+}{
+	// service info is public
+	{"/variants/service-info", "", nil, 200, "", false},
+	// no token
+	{"/variants/HG002_GIAB", "", nil, 401, "InvalidAuthentication", false},
+	// token not signed by a trusted key
+	{"/variants/HG002_GIAB", "untrusted", nil, 401, "InvalidAuthentication", false},
+	// valid token, without the visa required by the data source
+	{"/variants/HG002_GIAB", "novisa", nil, 403, "PermissionDenied", false},
+	// valid token with the visa
+	{"/variants/HG002_GIAB", "visa", nil, 200, "", true},
+	// data source without visas, open to all authenticated users
+	{"/reads/tabulamuris.A1-B000168-3_57_F-1-1_R2", "novisa", nil, 200, "", true},
+	// ids not matching any data source are not found
+	{"/variants/unregistered", "novisa", nil, 404, "NotFound", false},
+	// the file bytes endpoint only serves signed urls, regardless of token
+	{"/variants/file-bytes/HG002_GIAB", "", [][]string{{"Range", "bytes=0-99"}}, 401, "InvalidAuthentication", false},
+	{"/variants/file-bytes/HG002_GIAB", "visa", [][]string{{"Range", "bytes=0-99"}}, 401, "InvalidAuthentication", false},
+	{"/variants/data/HG002_GIAB", "visa", nil, 401, "InvalidAuthentication", false},
+}
+
+func TestHTTPRequestAuth(t *testing.T) {
+	// JWKS file trusting the test key
+	tempDir, _ := ioutil.TempDir("", "htsauth")
+	defer os.RemoveAll(tempDir)
+	jwksFile := filepath.Join(tempDir, "jwks.json")
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(authTestKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(authTestKey.E)).Bytes()),
+		}},
+	})
+	ioutil.WriteFile(jwksFile, jwks, 0644)
+
+	setAuthTestConfig(t, jwksFile, "auth")
+	router, err := SetRouter()
+	assert.Nil(t, err)
+
+	untrustedKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tokens := map[string]string{
+		"untrusted": signAuthTestToken(untrustedKey, authTestVisa),
+		"novisa":    signAuthTestToken(authTestKey, ""),
+		"visa":      signAuthTestToken(authTestKey, authTestVisa),
+	}
+
+	for _, tc := range httpRequestAuthTC {
+		request := httptest.NewRequest("GET", "http://localhost:3000"+tc.endpoint, nil)
+		if tc.token != "" {
+			request.Header.Set("Authorization", "Bearer "+tokens[tc.token])
+		}
+		for _, header := range tc.headers {
+			request.Header.Set(header[0], header[1])
+		}
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+		body, _ := ioutil.ReadAll(writer.Body)
+
+		assert.Equal(t, tc.expCode, writer.Code, tc.endpoint)
+		if tc.expError != "" {
+			assert.Contains(t, string(body), "\"error\":\""+tc.expError+"\"", tc.endpoint)
+		}
+
+		// ticket urls pointing back at the server are signed, and can be
+		// followed without a token, but not with modified headers
+		if tc.expSignedURLs {
+			ticket := new(htsticket.Ticket)
+			json.Unmarshal(body, ticket)
+			assert.NotEmpty(t, ticket.HTSget.URLS)
+			for _, ticketURL := range ticket.HTSget.URLS {
+				assert.Contains(t, ticketURL.URL, "signature=", tc.endpoint)
+				follow := func(rangeHeader string) int {
+					dataRequest := httptest.NewRequest("GET", ticketURL.URL, nil)
+					dataRequest.Header = ticketURLHeaders(ticketURL.Headers)
+					if rangeHeader != "" {
+						dataRequest.Header.Set("Range", rangeHeader)
+					}
+					dataWriter := httptest.NewRecorder()
+					router.ServeHTTP(dataWriter, dataRequest)
+					return dataWriter.Code
+				}
+				assert.Equal(t, 200, follow(""), tc.endpoint)
+				assert.Equal(t, 401, follow("bytes=0-1"), tc.endpoint)
+			}
+		}
+	}
+
+	// a missing key file prevents the server from starting
+	setAuthTestConfig(t, filepath.Join(tempDir, "missing.json"), "auth")
+	_, err = SetRouter()
+	assert.NotNil(t, err)
+
+	// unsigned data urls would bypass auth, so a signing key is required
+	setAuthTestConfig(t, jwksFile, "")
+	_, err = SetRouter()
+	assert.NotNil(t, err)
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
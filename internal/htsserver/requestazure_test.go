@@ -0,0 +1,130 @@
+package htsserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/azureutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+var azureTestBlobs = map[string]string{
+	"/devstoreaccount1/cohort/reads/object0001.bam": "object0001 content",
+}
+
+// newAzureTestServer serves blobs from path-style urls, as Azurite does,
+// only to requests carrying a SAS token
+func newAzureTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := azureTestBlobs[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("sig") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+}
+
+func TestHTTPRequestAzure(t *testing.T) {
+	server := newAzureTestServer()
+	defer server.Close()
+	defer setTestEnv(azureutils.AzureStorageBlobEndpoint, server.URL+"/devstoreaccount1")()
+	defer setTestEnv(azureutils.AzureStorageKey, "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")()
+
+	setReadsDataSourceTestConfig(t, "az://devstoreaccount1/cohort/reads/{name}.bam")
+	router, _ := SetRouter()
+
+	// blobs missing from the container are not found
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0002", nil))
+	assert.Equal(t, 404, writer.Code)
+
+	// ticket urls carry SAS tokens, and are downloaded from the container
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0001", nil))
+	assert.Equal(t, 200, writer.Code)
+	ticket := new(htsticket.Ticket)
+	json.Unmarshal(writer.Body.Bytes(), ticket)
+	assert.NotEmpty(t, ticket.HTSget.URLS)
+
+	content := ""
+	for _, ticketURL := range ticket.HTSget.URLS {
+		assert.True(t, strings.HasPrefix(ticketURL.URL, server.URL+"/devstoreaccount1/cohort/reads/object0001.bam?"))
+		assert.Contains(t, ticketURL.URL, "sig=")
+		dataRequest, _ := http.NewRequest("GET", ticketURL.URL, nil)
+		dataRequest.Header = ticketURLHeaders(ticketURL.Headers)
+		res, err := http.DefaultClient.Do(dataRequest)
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		content += string(body)
+	}
+	assert.Equal(t, azureTestBlobs["/devstoreaccount1/cohort/reads/object0001.bam"], content)
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
+
+// TestHTTPRequestAzureRegion tests region requests, for which the blob header
+// is streamed to samtools to validate the reference name
+func TestHTTPRequestAzureRegion(t *testing.T) {
+	if _, err := exec.LookPath("samtools"); err != nil {
+		t.Skip("samtools not found")
+	}
+	content, err := ioutil.ReadFile("../../data/test/sources/tabulamuris/A1-B000168-3_57_F-1-1_R2.mus.Aligned.out.sorted.bam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	azureTestBlobs["/devstoreaccount1/cohort/reads/object0003.bam"] = string(content)
+	defer delete(azureTestBlobs, "/devstoreaccount1/cohort/reads/object0003.bam")
+
+	server := newAzureTestServer()
+	defer server.Close()
+	defer setTestEnv(azureutils.AzureStorageBlobEndpoint, server.URL+"/devstoreaccount1")()
+	defer setTestEnv(azureutils.AzureStorageKey, "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")()
+
+	setReadsDataSourceTestConfig(t, "az://devstoreaccount1/cohort/reads/{name}.bam")
+	router, _ := SetRouter()
+
+	// reference names missing from the header are invalid
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0003?referenceName=chr25", nil))
+	assert.Equal(t, 400, writer.Code)
+
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0003?referenceName=chr1", nil))
+	assert.Equal(t, 200, writer.Code)
+	ticket := new(htsticket.Ticket)
+	json.Unmarshal(writer.Body.Bytes(), ticket)
+	assert.NotEmpty(t, ticket.HTSget.URLS)
+	for _, ticketURL := range ticket.HTSget.URLS {
+		if strings.HasPrefix(ticketURL.URL, "data:") {
+			continue
+		}
+		assert.Contains(t, ticketURL.URL, "/reads/data/object0003")
+		dataRequest := httptest.NewRequest("GET", ticketURL.URL, nil)
+		dataRequest.Header = ticketURLHeaders(ticketURL.Headers)
+		dataWriter := httptest.NewRecorder()
+		router.ServeHTTP(dataWriter, dataRequest)
+		assert.Equal(t, 200, dataWriter.Code)
+		assert.NotEmpty(t, dataWriter.Body.Bytes())
+	}
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
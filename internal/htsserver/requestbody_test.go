@@ -0,0 +1,145 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+// httpRequestBodyTicketTC test cases for class=body tickets, which omit the
+// header block
+var httpRequestBodyTicketTC = []struct {
+	method     string
+	path       string
+	body       string
+	expClasses []string
+	expHeaders []htsticket.Headers
+}{
+	{
+		"GET", "/reads/public?class=body", "",
+		[]string{"body"},
+		[]htsticket.Headers{{BlockClass: "body", CurrentBlock: "0", TotalBlocks: "1"}},
+	},
+	{
+		"GET", "/reads/public?class=body&referenceName=*", "",
+		[]string{"body"},
+		[]htsticket.Headers{{BlockClass: "body", CurrentBlock: "0", TotalBlocks: "1"}},
+	},
+	{
+		"POST", "/reads/public", "{\"class\": \"body\"}",
+		[]string{"body"},
+		[]htsticket.Headers{{BlockClass: "body", CurrentBlock: "0", TotalBlocks: "1"}},
+	},
+	{
+		"GET", "/reads/public?referenceName=*", "",
+		[]string{"header", "body"},
+		[]htsticket.Headers{
+			{BlockClass: "header", CurrentBlock: "0", TotalBlocks: "2"},
+			{CurrentBlock: "1", TotalBlocks: "2"},
+		},
+	},
+}
+
+func TestHTTPRequestBodyTicket(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "bodyticket")
+	defer os.RemoveAll(tempDir)
+	setFileBytesTestConfig(t, tempDir)
+	router, _ := SetRouter()
+
+	for _, tc := range httpRequestBodyTicketTC {
+		request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+		assert.Equal(t, 200, writer.Code, tc.path)
+
+		var ticket struct {
+			Htsget struct {
+				URLs []*htsticket.URL `json:"urls"`
+			} `json:"htsget"`
+		}
+		assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &ticket))
+		assert.Equal(t, len(tc.expClasses), len(ticket.Htsget.URLs), tc.path)
+		for i, blockURL := range ticket.Htsget.URLs {
+			assert.Equal(t, tc.expClasses[i], blockURL.Class, tc.path)
+			assert.Equal(t, tc.expHeaders[i], *blockURL.Headers, tc.path)
+		}
+	}
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
+
+// fakeSamtools stands in for samtools, logging its arguments. the text
+// header lists two references, binary output is a 6 byte header (and the
+// records of body blocks) followed by a 28 byte end of file marker
+const fakeSamtools = `#!/bin/sh
+echo "$@" >> "$(dirname "$0")/samtools.log"
+case " $* " in
+*" -H "*)
+	case " $* " in
+	*" -b "*) printf 'HEADER'; printf '%028d' 0 ;;
+	*) printf '@SQ\tSN:chr1\tLN:1000\n@SQ\tSN:chr2\tLN:1000\n' ;;
+	esac ;;
+*) printf 'HEADERBODY'; printf '%028d' 0 ;;
+esac
+`
+
+// TestHTTPRequestBodyTicketHeaderBytes tests the header removed from the
+// body blocks of a ticket is measured once, when the ticket is issued
+func TestHTTPRequestBodyTicketHeaderBytes(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "bodyheaderbytes")
+	defer os.RemoveAll(tempDir)
+	ioutil.WriteFile(filepath.Join(tempDir, "object.sam"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(tempDir, "samtools"), []byte(fakeSamtools), 0755)
+	defer setTestEnv("PATH", tempDir+string(os.PathListSeparator)+os.Getenv("PATH"))()
+	setReadsDataSourceTestConfig(t, filepath.Join(tempDir, "{name}.sam"))
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	router, _ := SetRouter()
+
+	body := `{"class": "body", "regions": [{"referenceName": "chr1"}, {"referenceName": "chr2"}]}`
+	request := httptest.NewRequest("POST", "/reads/object", strings.NewReader(body))
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, request)
+	assert.Equal(t, 200, writer.Code)
+	var ticket struct {
+		Htsget struct {
+			URLs []*htsticket.URL `json:"urls"`
+		} `json:"htsget"`
+	}
+	assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &ticket))
+	assert.Equal(t, 2, len(ticket.Htsget.URLs))
+
+	for i, blockURL := range ticket.Htsget.URLs {
+		assert.Contains(t, blockURL.URL, "headerBytes=6")
+		request := httptest.NewRequest("GET", blockURL.URL, nil)
+		request.Header = ticketURLHeaders(blockURL.Headers)
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+		assert.Equal(t, 200, writer.Code, blockURL.URL)
+		expBody := "BODY"
+		if i == len(ticket.Htsget.URLs)-1 {
+			expBody += string(readsEOF("BAM"))
+		}
+		assert.Equal(t, expBody, writer.Body.String(), blockURL.URL)
+	}
+
+	// one header-only command for the ticket, none for its blocks
+	logBytes, _ := ioutil.ReadFile(filepath.Join(tempDir, "samtools.log"))
+	nHeaderCommands := 0
+	for _, args := range strings.Split(string(logBytes), "\n") {
+		if strings.Contains(" "+args+" ", " -H ") && strings.Contains(" "+args+" ", " -b ") {
+			nHeaderCommands++
+		}
+	}
+	assert.Equal(t, 1, nHeaderCommands)
+}
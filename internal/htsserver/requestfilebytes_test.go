@@ -0,0 +1,105 @@
+package htsserver
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+var httpRequestFileBytesTC = []struct {
+	endpoint string
+	rangeHdr string
+	expCode  int
+	expBody  string
+}{
+	// files within the data source root are served
+	{"/variants/file-bytes/HG002_GIAB", "bytes=0-3", 200, "\x1f\x8b\x08\x04"},
+	{"/reads/file-bytes/public", "bytes=0-5", 200, "public"},
+	{"/reads/file-bytes/public", "bytes=2-3", 200, "bl"},
+	// ids not matching a data source, or missing files
+	{"/variants/file-bytes/unregistered", "bytes=0-1", 404, ""},
+	{"/reads/file-bytes/missing", "bytes=0-1", 404, ""},
+	// ids traversing out of the data source root, to an existing file
+	{"/reads/file-bytes/../secret", "bytes=0-5", 404, ""},
+	{"/reads/file-bytes/..%2Fsecret", "bytes=0-5", 404, ""},
+	{"/reads/file-bytes/subdir/../../secret", "bytes=0-5", 404, ""},
+	// invalid range
+	{"/reads/file-bytes/public", "", 400, ""},
+}
+
+// setFileBytesTestConfig loads the integration test config, serving reads
+// from a root directory next to a file that must not be served
+func setFileBytesTestConfig(t *testing.T, tempDir string) {
+	os.Mkdir(filepath.Join(tempDir, "root"), 0755)
+	ioutil.WriteFile(filepath.Join(tempDir, "root", "public.txt"), []byte("public"), 0644)
+	ioutil.WriteFile(filepath.Join(tempDir, "secret.txt"), []byte("secret"), 0644)
+	setReadsDataSourceTestConfig(t, filepath.Join(tempDir, "root")+"/{name}.txt")
+}
+
+func TestHTTPRequestFileBytes(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "filebytes")
+	defer os.RemoveAll(tempDir)
+	setFileBytesTestConfig(t, tempDir)
+	router, _ := SetRouter()
+	urlSigner, err := newURLSigner()
+	assert.Nil(t, err)
+
+	for _, tc := range httpRequestFileBytesTC {
+		// sign the url as the ticket endpoint would, so that only the file
+		// resolution is tested
+		blockURL := htsticket.NewURL()
+		blockURL.SetURL(htsconfig.GetHost() + tc.endpoint[1:])
+		blockURL.SetHeaders(htsticket.NewHeaders())
+		blockURL.Headers.Range = tc.rangeHdr
+		signBlockURLs(urlSigner, []*htsticket.URL{blockURL})
+
+		request := httptest.NewRequest("GET", blockURL.URL, nil)
+		request.Header = ticketURLHeaders(blockURL.Headers)
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+		body, _ := ioutil.ReadAll(writer.Body)
+
+		assert.Equal(t, tc.expCode, writer.Code, tc.endpoint)
+		if tc.expCode == 200 {
+			assert.Equal(t, tc.expBody, string(body), tc.endpoint)
+		}
+	}
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
+
+// TestHTTPRequestDataTraversal tests data blocks are not served for ids
+// traversing out of the data source root
+func TestHTTPRequestDataTraversal(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "datatraversal")
+	defer os.RemoveAll(tempDir)
+	setFileBytesTestConfig(t, tempDir)
+	os.Mkdir(filepath.Join(tempDir, "root", "subdir"), 0755)
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	defer htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	router, _ := SetRouter()
+	urlSigner, err := newURLSigner()
+	assert.Nil(t, err)
+
+	for _, endpoint := range []string{"/reads/data/..%2Fsecret", "/reads/data/subdir/../../secret", "/reads/data/subdir%2F..%2F..%2Fsecret"} {
+		blockURL := htsticket.NewURL()
+		blockURL.SetURL(htsconfig.GetHost() + endpoint[1:])
+		blockURL.SetHeaders(htsticket.NewHeaders())
+		signBlockURLs(urlSigner, []*htsticket.URL{blockURL})
+
+		request := httptest.NewRequest("GET", blockURL.URL, nil)
+		request.Header = ticketURLHeaders(blockURL.Headers)
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, request)
+		assert.Equal(t, 404, writer.Code, endpoint)
+		assert.NotContains(t, writer.Body.String(), "secret", endpoint)
+	}
+}
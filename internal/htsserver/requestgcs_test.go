@@ -0,0 +1,135 @@
+package htsserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ga4gh/htsget-refserver/internal/gcsutils"
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+var gcsTestObjects = map[string]string{
+	"bucket/reads/object0001.bam": "object0001 content",
+}
+
+// newGCSTestServer serves object metadata and media from the GCS JSON API
+// paths, as the GCS emulator does
+func newGCSTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(r.URL.EscapedPath(), "/")
+		if len(segments) != 7 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		objName, _ := url.PathUnescape(segments[6])
+		content, ok := gcsTestObjects[segments[4]+"/"+objName]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			http.ServeContent(w, r, objName, time.Time{}, bytes.NewReader([]byte(content)))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"size": strconv.Itoa(len(content))})
+	}))
+}
+
+func TestHTTPRequestGCS(t *testing.T) {
+	server := newGCSTestServer()
+	defer server.Close()
+	defer setTestEnv(gcsutils.GCSEmulatorHost, server.URL)()
+
+	setReadsDataSourceTestConfig(t, "gs://bucket/reads/{name}.bam")
+	router, _ := SetRouter()
+
+	// objects missing from the bucket are not found
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0002", nil))
+	assert.Equal(t, 404, writer.Code)
+
+	// without a service account key, the object is proxied by the file bytes
+	// endpoint
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0001", nil))
+	assert.Equal(t, 200, writer.Code)
+	ticket := new(htsticket.Ticket)
+	json.Unmarshal(writer.Body.Bytes(), ticket)
+	assert.NotEmpty(t, ticket.HTSget.URLS)
+
+	content := ""
+	for _, ticketURL := range ticket.HTSget.URLS {
+		assert.Contains(t, ticketURL.URL, "/reads/file-bytes/object0001")
+		dataRequest := httptest.NewRequest("GET", ticketURL.URL, nil)
+		dataRequest.Header = ticketURLHeaders(ticketURL.Headers)
+		dataWriter := httptest.NewRecorder()
+		router.ServeHTTP(dataWriter, dataRequest)
+		assert.Equal(t, 200, dataWriter.Code)
+		content += dataWriter.Body.String()
+	}
+	assert.Equal(t, gcsTestObjects["bucket/reads/object0001.bam"], content)
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
+
+// TestHTTPRequestGCSRegion tests region requests, for which the object header
+// is streamed to samtools to validate the reference name
+func TestHTTPRequestGCSRegion(t *testing.T) {
+	if _, err := exec.LookPath("samtools"); err != nil {
+		t.Skip("samtools not found")
+	}
+	content, err := ioutil.ReadFile("../../data/test/sources/tabulamuris/A1-B000168-3_57_F-1-1_R2.mus.Aligned.out.sorted.bam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcsTestObjects["bucket/reads/object0003.bam"] = string(content)
+	defer delete(gcsTestObjects, "bucket/reads/object0003.bam")
+
+	server := newGCSTestServer()
+	defer server.Close()
+	defer setTestEnv(gcsutils.GCSEmulatorHost, server.URL)()
+
+	setReadsDataSourceTestConfig(t, "gs://bucket/reads/{name}.bam")
+	router, _ := SetRouter()
+
+	// reference names missing from the header are invalid
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0003?referenceName=chr25", nil))
+	assert.Equal(t, 400, writer.Code)
+
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "http://localhost:3000/reads/object0003?referenceName=chr1", nil))
+	assert.Equal(t, 200, writer.Code)
+	ticket := new(htsticket.Ticket)
+	json.Unmarshal(writer.Body.Bytes(), ticket)
+	assert.NotEmpty(t, ticket.HTSget.URLS)
+	for _, ticketURL := range ticket.HTSget.URLS {
+		if strings.HasPrefix(ticketURL.URL, "data:") {
+			continue
+		}
+		assert.Contains(t, ticketURL.URL, "/reads/data/object0003")
+		dataRequest := httptest.NewRequest("GET", ticketURL.URL, nil)
+		dataRequest.Header = ticketURLHeaders(ticketURL.Headers)
+		dataWriter := httptest.NewRecorder()
+		router.ServeHTTP(dataWriter, dataRequest)
+		assert.Equal(t, 200, dataWriter.Code)
+		assert.NotEmpty(t, dataWriter.Body.Bytes())
+	}
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
@@ -3,6 +3,7 @@ package htsserver
 import (
 	"net/http"
 
+	"github.com/ga4gh/htsget-refserver/internal/htsauth"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
 )
@@ -13,6 +14,7 @@ type requestHandler struct {
 	Writer         http.ResponseWriter
 	Request        *http.Request
 	HtsReq         *htsrequest.HtsgetRequest
+	urlSigner      *htsauth.URLSigner
 	afterSetupFunc func(handler *requestHandler) error
 	handlerFunc    func(handler *requestHandler)
 }
@@ -27,9 +29,16 @@ func newRequestHandler(method htsconstants.HTTPMethod, endpoint htsconstants.API
 	return reqHandler
 }
 
+// setURLSigner sets the signer of the ticket urls, which are left unsigned if
+// nil
+func (reqHandler *requestHandler) setURLSigner(urlSigner *htsauth.URLSigner) *requestHandler {
+	reqHandler.urlSigner = urlSigner
+	return reqHandler
+}
+
 func (reqHandler *requestHandler) setup(writer http.ResponseWriter, request *http.Request) error {
 	// set all parameters
-	htsgetReq, err := htsrequest.SetAllParameters(reqHandler.method, reqHandler.endpoint, writer, request)
+	htsgetReq, err := htsrequest.SetAllParameters(reqHandler.method, reqHandler.endpoint, writer, request, readObjectHeader)
 	if err != nil {
 		return err
 	}
@@ -53,6 +62,9 @@ func (reqHandler *requestHandler) handleRequest(writer http.ResponseWriter, requ
 		return afterSetupErr
 	}
 
+	// record the parsed request in the access log
+	logRequest(reqHandler)
+
 	// execute the main handler function
 	reqHandler.handlerFunc(reqHandler)
 	return nil
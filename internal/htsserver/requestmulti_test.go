@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/md5"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -171,7 +172,7 @@ var httpRequestMultiTC = []E2ETestCase{
 			[]string{"referenceName", "22"},
 		},
 		"",
-		"variants-tc-01.vcf",
+		"variants-tc-01.vcf.gz",
 	},
 
 	// POST VARIANTS, NOTHING SPECIFIED
@@ -190,7 +191,7 @@ var httpRequestMultiTC = []E2ETestCase{
 		"/variants/HG002_GIAB",
 		nil,
 		"{\"format\":\"VCF\",\"regions\":[{\"referenceName\":\"10\",\"start\":60000000,\"end\":90000000}]}",
-		"variants-tc-02.vcf",
+		"variants-tc-02.vcf.gz",
 	},
 
 	// POST VARIANTS, MULTI REGION SPECIFIED
@@ -199,7 +200,7 @@ var httpRequestMultiTC = []E2ETestCase{
 		"/variants/HG002_GIAB",
 		nil,
 		"{\"format\":\"VCF\",\"regions\":[{\"referenceName\":\"10\",\"start\":60000000,\"end\":90000000},{\"referenceName\":\"11\",\"start\":70000000,\"end\":100000000},{\"referenceName\":\"12\",\"start\":80000000,\"end\":110000000}]}",
-		"variants-tc-03.vcf",
+		"variants-tc-03.vcf.gz",
 	},
 }
 
@@ -213,9 +214,7 @@ func getHtsgetTicket(server *httptest.Server, tc E2ETestCase) *htsticket.Ticket
 		response, _ = http.Post(url, "application/json", bytes.NewReader([]byte(tc.requestBody)))
 	}
 
-	responseBodyBytesAll := make([]byte, 4096)
-	nBytes, _ := response.Body.Read(responseBodyBytesAll)
-	responseBodyBytes := responseBodyBytesAll[0:nBytes]
+	responseBodyBytes, _ := ioutil.ReadAll(response.Body)
 	ticket := new(htsticket.Ticket)
 	json.Unmarshal(responseBodyBytes, ticket)
 
@@ -235,11 +234,22 @@ func assignQueryParams(url string, queryParams [][]string) string {
 }
 
 func downloadFilepart(server *httptest.Server, i int, ticketURL *htsticket.URL, writer *bufio.Writer) error {
+	// inline data urls are decoded rather than requested
+	if strings.HasPrefix(ticketURL.URL, "data:") {
+		body, err := base64.StdEncoding.DecodeString(ticketURL.URL[strings.Index(ticketURL.URL, ",")+1:])
+		if err != nil {
+			return err
+		}
+		writer.Write(body)
+		writer.Flush()
+		return nil
+	}
+
 	request, _ := http.NewRequest("GET", ticketURL.URL, nil)
 
 	h := ticketURL.Headers
-	headerKeys := []string{"HtsgetCurrentBlock", "HtsgetTotalBlocks", "Range", "HtsgetBlockClass", "HtsgetFilePath"}
-	headerVals := []string{h.CurrentBlock, h.TotalBlocks, h.Range, h.BlockClass, h.FilePath}
+	headerKeys := []string{"HtsgetCurrentBlock", "HtsgetTotalBlocks", "Range", "HtsgetBlockClass"}
+	headerVals := []string{h.CurrentBlock, h.TotalBlocks, h.Range, h.BlockClass}
 	for a := range headerKeys {
 		if headerVals[a] != "" {
 			request.Header.Set(headerKeys[a], headerVals[a])
@@ -0,0 +1,113 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsrequest"
+	"github.com/ga4gh/htsget-refserver/internal/htsticket"
+	"github.com/stretchr/testify/assert"
+)
+
+// ticketOverlappingRegionsTC test cases for POST bodies with overlapping
+// regions, which are merged into one data block each, the expected queries
+// of the body blocks being listed in order
+var ticketOverlappingRegionsTC = []struct {
+	body       string
+	expQueries []map[string]string
+}{
+	{
+		// overlapping regions
+		`{"regions": [{"referenceName": "chr1", "start": 100, "end": 300}, {"referenceName": "chr1", "start": 200, "end": 400}]}`,
+		[]map[string]string{
+			{"referenceName": "chr1", "start": "100", "end": "400"},
+		},
+	},
+	{
+		// out of order, with a region contained in another
+		`{"regions": [{"referenceName": "chr2", "start": 50}, {"referenceName": "chr1", "start": 500, "end": 600}, {"referenceName": "chr1", "start": 100, "end": 1000}, {"referenceName": "chr2", "start": 0, "end": 10}]}`,
+		[]map[string]string{
+			{"referenceName": "chr2", "start": "0", "end": "10"},
+			{"referenceName": "chr2", "start": "50", "minStart": "10"},
+			{"referenceName": "chr1", "start": "100", "end": "1000"},
+		},
+	},
+	{
+		// adjacent regions are merged, and an open end absorbs later regions
+		`{"regions": [{"referenceName": "chr1", "start": 100, "end": 200}, {"referenceName": "chr1", "start": 200, "end": 300}, {"referenceName": "chr1", "start": 250}, {"referenceName": "chr1", "start": 5000, "end": 6000}]}`,
+		[]map[string]string{
+			{"referenceName": "chr1", "start": "100"},
+		},
+	},
+	{
+		// separate regions on one reference, later blocks skip records
+		// overlapping the previous region
+		`{"regions": [{"referenceName": "chr1", "start": 500, "end": 600}, {"referenceName": "chr1", "end": 200}]}`,
+		[]map[string]string{
+			{"referenceName": "chr1", "end": "200"},
+			{"referenceName": "chr1", "start": "500", "end": "600", "minStart": "200"},
+		},
+	},
+}
+
+func TestTicketOverlappingRegions(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "overlappingregions")
+	defer os.RemoveAll(tempDir)
+	setFileBytesTestConfig(t, tempDir)
+
+	for _, tc := range ticketOverlappingRegionsTC {
+		var body struct {
+			Regions []*htsrequest.Region `json:"regions"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(tc.body), &body))
+		htsReq := htsrequest.NewHtsgetRequest()
+		htsReq.SetEndpoint(htsconstants.APIEndpointReadsTicket)
+		htsReq.SetID("public")
+		htsReq.SetFormat("BAM")
+		htsReq.SetFields([]string{"ALL"})
+		htsReq.SetTags([]string{"ALL"})
+		htsReq.SetNoTags([]string{"NONE"})
+		htsReq.SetRegions(body.Regions)
+
+		writer := httptest.NewRecorder()
+		ticketRequestHandler(&requestHandler{
+			method:   htsconstants.PostMethod,
+			endpoint: htsconstants.APIEndpointReadsTicket,
+			Writer:   writer,
+			Request:  httptest.NewRequest("POST", "/reads/public", nil),
+			HtsReq:   htsReq,
+		})
+		assert.Equal(t, 200, writer.Code, tc.body)
+
+		var ticket struct {
+			Htsget struct {
+				URLs []*htsticket.URL `json:"urls"`
+			} `json:"htsget"`
+		}
+		assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &ticket))
+		// one header block, followed by one block per merged region
+		assert.Equal(t, len(tc.expQueries)+1, len(ticket.Htsget.URLs), tc.body)
+		for i, blockURL := range ticket.Htsget.URLs[1:] {
+			if i >= len(tc.expQueries) {
+				break
+			}
+			u, err := url.Parse(blockURL.URL)
+			assert.Nil(t, err)
+			query := map[string]string{}
+			for key := range u.Query() {
+				query[key] = u.Query().Get(key)
+			}
+			assert.Equal(t, tc.expQueries[i], query, tc.body)
+		}
+	}
+
+	// set the configuration back to default
+	htsconfig.SetConfigFile(htsconfig.DefaultConfiguration)
+	htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -14,6 +15,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// signedURLParams matches the signature added to ticket urls, which changes
+// with the request time and signing key
+var signedURLParams = regexp.MustCompile(`(\?|\\u0026)expires=[0-9]+\\u0026signature=[0-9a-f]+`)
+
 var httpRequestSingleTC = []struct {
 	method      string
 	endpoint    string
@@ -31,7 +36,7 @@ var httpRequestSingleTC = []struct {
 		nil,
 		"",
 		200,
-		"{\"id\":\"htsgetref.reads\",\"name\":\"GA4GH htsget reference server reads endpoint\",\"type\":{\"group\":\"org.ga4gh\",\"artifact\":\"htsget\",\"version\":\"1.2.0\"},\"description\":\"Stream alignment files (BAM/CRAM) according to GA4GH htsget protocol\",\"organization\":{\"name\":\"Global Alliance for Genomics and Health\",\"url\":\"https://ga4gh.org\"},\"contactUrl\":\"mailto:jeremy.adams@ga4gh.org\",\"documentationUrl\":\"https://ga4gh.org\",\"createdAt\":\"2020-09-01T12:00:00Z\",\"updatedAt\":\"2020-09-01T12:00:00Z\",\"environment\":\"test\",\"version\":\"1.5.0\",\"htsget\":{\"datatype\":\"reads\",\"formats\":[\"BAM\"],\"fieldsParameterEffective\":true,\"tagsParametersEffective\":true}}\n",
+		"{\"id\":\"htsgetref.reads\",\"name\":\"GA4GH htsget reference server reads endpoint\",\"type\":{\"group\":\"org.ga4gh\",\"artifact\":\"htsget\",\"version\":\"1.2.0\"},\"description\":\"Stream alignment files (BAM/CRAM) according to GA4GH htsget protocol\",\"organization\":{\"name\":\"Global Alliance for Genomics and Health\",\"url\":\"https://ga4gh.org\"},\"contactUrl\":\"mailto:jeremy.adams@ga4gh.org\",\"documentationUrl\":\"https://ga4gh.org\",\"createdAt\":\"2020-09-01T12:00:00Z\",\"updatedAt\":\"2020-09-01T12:00:00Z\",\"environment\":\"test\",\"version\":\"1.5.0\",\"htsget\":{\"datatype\":\"reads\",\"formats\":[\"BAM\",\"CRAM\"],\"fieldsParameterEffective\":true,\"tagsParametersEffective\":true,\"readFilterParameters\":[\"minMappingQuality\",\"requiredFlags\",\"excludedFlags\",\"readGroups\",\"library\"]}}\n",
 	},
 	{
 		"GET",
@@ -40,7 +45,7 @@ var httpRequestSingleTC = []struct {
 		nil,
 		"",
 		200,
-		"{\"id\":\"htsgetref.variants\",\"name\":\"GA4GH htsget reference server variants endpoint\",\"type\":{\"group\":\"org.ga4gh\",\"artifact\":\"htsget\",\"version\":\"1.2.0\"},\"description\":\"Stream variant files (VCF/BCF) according to GA4GH htsget protocol\",\"organization\":{\"name\":\"Global Alliance for Genomics and Health\",\"url\":\"https://ga4gh.org\"},\"contactUrl\":\"mailto:jeremy.adams@ga4gh.org\",\"documentationUrl\":\"https://ga4gh.org\",\"createdAt\":\"2020-09-01T12:00:00Z\",\"updatedAt\":\"2020-09-01T12:00:00Z\",\"environment\":\"test\",\"version\":\"1.5.0\",\"htsget\":{\"datatype\":\"variants\",\"formats\":[\"VCF\"],\"fieldsParameterEffective\":false,\"tagsParametersEffective\":false}}\n",
+		"{\"id\":\"htsgetref.variants\",\"name\":\"GA4GH htsget reference server variants endpoint\",\"type\":{\"group\":\"org.ga4gh\",\"artifact\":\"htsget\",\"version\":\"1.2.0\"},\"description\":\"Stream variant files (VCF/BCF) according to GA4GH htsget protocol\",\"organization\":{\"name\":\"Global Alliance for Genomics and Health\",\"url\":\"https://ga4gh.org\"},\"contactUrl\":\"mailto:jeremy.adams@ga4gh.org\",\"documentationUrl\":\"https://ga4gh.org\",\"createdAt\":\"2020-09-01T12:00:00Z\",\"updatedAt\":\"2020-09-01T12:00:00Z\",\"environment\":\"test\",\"version\":\"1.5.0\",\"htsget\":{\"datatype\":\"variants\",\"formats\":[\"VCF\",\"BCF\"],\"fieldsParameterEffective\":false,\"tagsParametersEffective\":true}}\n",
 	},
 	/* GET READS TICKET CASES */
 	{
@@ -69,7 +74,7 @@ var httpRequestSingleTC = []struct {
 		nil,
 		"",
 		200,
-		"{\"htsget\":{\"format\":\"BAM\",\"urls\":[{\"url\":\"http://localhost:3000/file-bytes\",\"headers\":{\"HtsgetFilePath\":\"../../data/test/sources/tabulamuris/A1-B000168-3_57_F-1-1_R2.mus.Aligned.out.sorted.bam\",\"Range\":\"bytes=0-41157\"}}]}}\n",
+		"{\"htsget\":{\"format\":\"BAM\",\"urls\":[{\"url\":\"http://localhost:3000/reads/file-bytes/tabulamuris.A1-B000168-3_57_F-1-1_R2\",\"headers\":{\"Range\":\"bytes=0-41157\"}}]}}\n",
 	},
 
 	{
@@ -81,7 +86,7 @@ var httpRequestSingleTC = []struct {
 		nil,
 		"",
 		200,
-		"{\"htsget\":{\"format\":\"BAM\",\"urls\":[{\"url\":\"http://localhost:3000/reads/data/tabulamuris.A1-B000168-3_57_F-1-1_R2?class=header\",\"headers\":{\"HtsgetBlockClass\":\"header\",\"HtsgetCurrentBlock\":\"0\",\"HtsgetTotalBlocks\":\"1\"},\"class\":\"header\"}]}}\n",
+		"{\"htsget\":{\"format\":\"BAM\",\"urls\":[{\"url\":\"http://localhost:3000/reads/file-bytes/tabulamuris.A1-B000168-3_57_F-1-1_R2\",\"headers\":{\"Range\":\"bytes=0-2579\"},\"class\":\"header\"}]}}\n",
 	},
 
 	{
@@ -124,7 +129,7 @@ var httpRequestSingleTC = []struct {
 		nil,
 		"",
 		200,
-		"{\"htsget\":{\"format\":\"VCF\",\"urls\":[{\"url\":\"http://localhost:3000/file-bytes\",\"headers\":{\"HtsgetFilePath\":\"../../data/test/sources/giab/HG002_GIAB.filtered.vcf.gz\",\"Range\":\"bytes=0-185237\"}}]}}\n",
+		"{\"htsget\":{\"format\":\"VCF\",\"urls\":[{\"url\":\"http://localhost:3000/variants/file-bytes/HG002_GIAB\",\"headers\":{\"Range\":\"bytes=0-185237\"}}]}}\n",
 	},
 }
 
@@ -177,7 +182,7 @@ func TestHTTPRequestSingle(t *testing.T) {
 		writer := httptest.NewRecorder()
 		router.ServeHTTP(writer, request)
 		responseBodyBytes, _ := ioutil.ReadAll(writer.Body)
-		responseBody := string(responseBodyBytes)
+		responseBody := signedURLParams.ReplaceAllString(string(responseBodyBytes), "")
 
 		// assert status code, response body
 		assert.Equal(t, tc.expCode, writer.Code)
@@ -4,28 +4,54 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/ga4gh/htsget-refserver/internal/assumerole"
 	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsmetrics"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 )
 
-// SetRouter sets up and returns a go-chi router to caller
-func SetRouter() (*chi.Mux, error) {
-	router := chi.NewRouter()
-
-	// Setup CORS
+// corsOptions gets the CORS options of the current configuration
+func corsOptions() cors.Options {
 	corsAllowedHeaders := strings.Split(htsconfig.GetCorsAllowedHeaders(), ",")
-	allowedHeaders := append(corsAllowedHeaders, "HtsgetBlockClass", "HtsgetCurrentBlock", "HtsgetTotalBlocks", "HtsgetFilePath")
-	router.Use(cors.Handler(cors.Options{
+	allowedHeaders := append(corsAllowedHeaders, "HtsgetBlockClass", "HtsgetCurrentBlock", "HtsgetTotalBlocks")
+	return cors.Options{
 		AllowedOrigins:   strings.Split(htsconfig.GetCorsAllowedOrigins(), ","),
 		AllowedMethods:   strings.Split(htsconfig.GetCorsAllowedMethods(), ","),
 		AllowedHeaders:   allowedHeaders,
 		AllowCredentials: htsconfig.GetCorsAllowCredentials(),
 		MaxAge:           htsconfig.GetCorsMaxAge(),
-	}))
+	}
+}
+
+// corsHandler applies the CORS options of the current configuration, the
+// CORS middleware is rebuilt whenever the configuration is reloaded
+func corsHandler(next http.Handler) http.Handler {
+	var mu sync.Mutex
+	var config *htsconfig.Configuration
+	var handler http.Handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if current := htsconfig.GetConfig(); current != config {
+			config = current
+			handler = cors.Handler(corsOptions())(next)
+		}
+		h := handler
+		mu.Unlock()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// SetRouter sets up and returns a go-chi router to caller
+func SetRouter() (*chi.Mux, error) {
+	router := chi.NewRouter()
+
+	// Setup access logging, CORS
+	router.Use(accessLogHandler)
+	router.Use(corsHandler)
 
 	// Setup AWS AssumeRole middleware
 	if htsconfig.IsAwsAssumeRole() {
@@ -34,26 +60,50 @@ func SetRouter() (*chi.Mux, error) {
 		}))
 	}
 
+	// Load keys for bearer token auth, if enabled. tokens are checked when
+	// tickets are requested, while the data and file bytes endpoints only
+	// serve the signed urls in tickets. service info routes are always public
+	authKeySet, err := loadAuthKeySet()
+	if err != nil {
+		return nil, err
+	}
+	withAuth := func(ep htsconstants.APIEndpoint) chi.Router {
+		return router.With(authMiddlewares(authKeySet, ep)...)
+	}
+	urlSigner, err := newURLSigner()
+	if err != nil {
+		return nil, err
+	}
+	withSignedURL := router.With(signedURLMiddlewares(urlSigner)...)
+
 	// Add API Routes
 
+	// add the liveness and readiness routes
+	router.Get("/healthz", getHealthz)
+	router.Get("/readyz", getReadyz)
+
 	// if reads enabled, add reads routes
 	if htsconfig.IsEndpointEnabled(htsconstants.APIEndpointReadsTicket) {
-		router.Get(htsconstants.APIEndpointReadsTicket.String(), getReadsTicket)
-		router.Post(htsconstants.APIEndpointReadsTicket.String(), postReadsTicket)
-		router.Get(htsconstants.APIEndpointReadsData.String(), getReadsData)
+		withAuth(htsconstants.APIEndpointReadsTicket).Get(htsconstants.APIEndpointReadsTicket.String(), getReadsTicket(urlSigner))
+		withAuth(htsconstants.APIEndpointReadsTicket).Post(htsconstants.APIEndpointReadsTicket.String(), postReadsTicket(urlSigner))
+		withSignedURL.Get(htsconstants.APIEndpointReadsData.String(), getReadsData)
+		withSignedURL.Get(htsconstants.APIEndpointReadsFileBytes.String(), getReadsFileBytes)
 		router.Get(htsconstants.APIEndpointReadsServiceInfo.String(), getReadsServiceInfo)
 	}
 
 	// if variants enabled, add variants routes
 	if htsconfig.IsEndpointEnabled(htsconstants.APIEndpointVariantsTicket) {
-		router.Get(htsconstants.APIEndpointVariantsTicket.String(), getVariantsTicket)
-		router.Post(htsconstants.APIEndpointVariantsTicket.String(), postVariantsTicket)
-		router.Get(htsconstants.APIEndpointVariantsData.String(), getVariantsData)
+		withAuth(htsconstants.APIEndpointVariantsTicket).Get(htsconstants.APIEndpointVariantsTicket.String(), getVariantsTicket(urlSigner))
+		withAuth(htsconstants.APIEndpointVariantsTicket).Post(htsconstants.APIEndpointVariantsTicket.String(), postVariantsTicket(urlSigner))
+		withSignedURL.Get(htsconstants.APIEndpointVariantsData.String(), getVariantsData)
+		withSignedURL.Get(htsconstants.APIEndpointVariantsFileBytes.String(), getVariantsFileBytes)
 		router.Get(htsconstants.APIEndpointVariantsServiceInfo.String(), getVariantsServiceInfo)
 	}
 
-	// add the file bytes endpoint for streaming byte indices of local files
-	router.Get(htsconstants.APIEndpointFileBytes.String(), getFileBytes)
+	// if metrics enabled, add the metrics route
+	if htsconfig.IsMetricsEnabled() {
+		router.Method("GET", "/metrics", htsmetrics.Handler())
+	}
 
 	// add the static files route
 	docsDir := htsconfig.GetDocsDir()
@@ -0,0 +1,44 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCorsTestConfig copies the default configuration, allowing the origin
+func newCorsTestConfig(t *testing.T, origin string) *htsconfig.Configuration {
+	configJSONBytes, _ := json.Marshal(htsconfig.DefaultConfiguration)
+	config := new(htsconfig.Configuration)
+	if err := json.Unmarshal(configJSONBytes, config); err != nil {
+		t.Fatal(err)
+	}
+	config.Container.ServerProps.CorsAllowedOrigins = origin
+	return config
+}
+
+func TestCorsHandlerReload(t *testing.T) {
+	htsconfig.GetConfig()
+	defer htsconfig.SetConfig(htsconfig.DefaultConfiguration)
+	handler := corsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	allowedOrigin := func(origin string) string {
+		request := httptest.NewRequest("GET", "/reads/object", nil)
+		request.Header.Set("Origin", origin)
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, request)
+		return writer.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	htsconfig.SetConfig(newCorsTestConfig(t, "https://a.example.org"))
+	assert.Equal(t, "https://a.example.org", allowedOrigin("https://a.example.org"))
+	assert.Equal(t, "", allowedOrigin("https://b.example.org"))
+
+	// reloaded origins apply to the following requests
+	htsconfig.SetConfig(newCorsTestConfig(t, "https://b.example.org"))
+	assert.Equal(t, "", allowedOrigin("https://a.example.org"))
+	assert.Equal(t, "https://b.example.org", allowedOrigin("https://b.example.org"))
+}
@@ -0,0 +1,69 @@
+package htsserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ga4gh/htsget-refserver/internal/htsconfig"
+)
+
+// loadTestConfig loads the integration test config, after modifying its
+// decoded htsgetConfig object
+func loadTestConfig(t *testing.T, modify func(container map[string]interface{})) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFilePath := filepath.Join(filepath.Dir(filepath.Dir(wd)), "data", "config", "integration-tests.config.json")
+	configJSONBytes, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var configMap map[string]map[string]interface{}
+	if err := json.Unmarshal(configJSONBytes, &configMap); err != nil {
+		t.Fatal(err)
+	}
+	modify(configMap["htsgetConfig"])
+
+	configJSONBytes, err = json.Marshal(configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newConfig := new(htsconfig.Configuration)
+	if err := json.Unmarshal(configJSONBytes, newConfig); err != nil {
+		t.Fatal(err)
+	}
+	htsconfig.SetConfigFile(newConfig)
+	htsconfig.LoadConfig()
+}
+
+// setReadsDataSourceTestConfig loads the integration test config, serving
+// reads of any id from the path template, and signing ticket urls
+func setReadsDataSourceTestConfig(t *testing.T, path string) {
+	loadTestConfig(t, func(container map[string]interface{}) {
+		container["props"].(map[string]interface{})["urlSigningKey"] = "test"
+		reads := container["reads"].(map[string]interface{})
+		reads["dataSourceRegistry"] = map[string]interface{}{
+			"sources": []map[string]string{{
+				"pattern": "^(?P<name>.*)$",
+				"path":    path,
+			}},
+		}
+	})
+}
+
+// setTestEnv sets an env var, returning a function restoring it
+func setTestEnv(key string, value string) func() {
+	prev, set := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if set {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
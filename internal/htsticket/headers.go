@@ -4,9 +4,8 @@
 package htsticket
 
 import (
-	"strconv"
-
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
+	"github.com/ga4gh/htsget-refserver/internal/htsutils"
 )
 
 // Headers contains any headers needed by the server from the client
@@ -14,7 +13,6 @@ type Headers struct {
 	BlockClass   string `json:"HtsgetBlockClass,omitempty"`
 	CurrentBlock string `json:"HtsgetCurrentBlock,omitempty"` // number of current block
 	TotalBlocks  string `json:"HtsgetTotalBlocks,omitempty"`  // total number of blocks
-	FilePath     string `json:"HtsgetFilePath,omitempty"`
 	Range        string `json:"Range,omitempty"`
 }
 
@@ -37,7 +35,7 @@ func (headers *Headers) SetTotalBlocks(totalBlocks string) *Headers {
 
 // SetRangeHeader assigns the Range header value
 func (headers *Headers) SetRangeHeader(start int64, end int64) *Headers {
-	headers.Range = "bytes=" + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(end, 10)
+	headers.Range = htsutils.FormatRangeHeader(start, end)
 	return headers
 }
 
@@ -60,10 +58,3 @@ func (headers *Headers) SetClassBody() *Headers {
 	headers.setBlockClass(htsconstants.ClassBody)
 	return headers
 }
-
-// SetFilePathHeader assigns the FilePath header, informing the data or
-// file bytes endpoint of which file to stream back to client
-func (headers *Headers) SetFilePathHeader(filePath string) *Headers {
-	headers.FilePath = filePath
-	return headers
-}
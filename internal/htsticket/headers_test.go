@@ -34,14 +34,6 @@ var headersSetRangeHeaderTC = []struct {
 	{999, 1000, "bytes=999-1000"},
 }
 
-// headersSetFilePathHeaderTC test cases for SetFilePathHeader
-var headersSetFilePathHeaderTC = []struct {
-	filepath string
-}{
-	{"./data/gcp/gatk-test-data/wgs_bam/NA12878.bam"},
-	{"./data/gcp/gatk-test-data/wgs_bam/NA12878_20k_b37.bam"},
-}
-
 // TestHeadersSetCurrentBlock tests SetCurrentBlock function
 func TestHeadersSetCurrentBlock(t *testing.T) {
 	for _, tc := range headersSetCurrentBlockTC {
@@ -86,12 +78,3 @@ func TestHeadersSetClass(t *testing.T) {
 		assert.Equal(t, exp[i], h.BlockClass)
 	}
 }
-
-// TestHeadersSetFilePath tests SetFilePath function
-func TestHeadersSetFilePath(t *testing.T) {
-	for _, tc := range headersSetFilePathHeaderTC {
-		h := NewHeaders()
-		h.SetFilePathHeader(tc.filepath)
-		assert.Equal(t, tc.filepath, h.FilePath)
-	}
-}
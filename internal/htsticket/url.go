@@ -4,6 +4,8 @@
 package htsticket
 
 import (
+	"encoding/base64"
+
 	"github.com/ga4gh/htsget-refserver/internal/htsconstants"
 )
 
@@ -25,6 +27,13 @@ func (urlObj *URL) SetURL(url string) *URL {
 	return urlObj
 }
 
+// SetDataURI assigns the filepart data inline, as a base64 encoded data uri,
+// so the client does not need to make a request to obtain it
+func (urlObj *URL) SetDataURI(data []byte) *URL {
+	urlObj.URL = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data)
+	return urlObj
+}
+
 // SetHeaders assign all headers necessary to access the data
 func (urlObj *URL) SetHeaders(headers *Headers) *URL {
 	urlObj.Headers = headers
@@ -21,9 +21,11 @@ var urlSetURLTC = []struct {
 
 // urlSetHeadersTC test cases for SetHeaders
 var urlSetHeadersTC = []struct {
-	currentBlock, totalBlocks, filepath string
+	currentBlock, totalBlocks string
+	start, end                int64
+	expRange                  string
 }{
-	{"1", "10", "./gatk/test1.bam"},
+	{"1", "10", 0, 999, "bytes=0-999"},
 }
 
 // TestUrlSetURL tests SetURL function
@@ -35,18 +37,25 @@ func TestUrlSetURL(t *testing.T) {
 	}
 }
 
+// TestUrlSetDataURI tests SetDataURI function
+func TestUrlSetDataURI(t *testing.T) {
+	url := NewURL()
+	url.SetDataURI([]byte("BAM\x01"))
+	assert.Equal(t, "data:application/octet-stream;base64,QkFNAQ==", url.URL)
+}
+
 // TestUrlSetHeaders tests SetHeaders function
 func TestUrlSetHeaders(t *testing.T) {
 	for _, tc := range urlSetHeadersTC {
 		h := NewHeaders()
 		h.SetCurrentBlock(tc.currentBlock)
 		h.SetTotalBlocks(tc.totalBlocks)
-		h.SetFilePathHeader(tc.filepath)
+		h.SetRangeHeader(tc.start, tc.end)
 		url := NewURL()
 		url.SetHeaders(h)
 		assert.Equal(t, tc.currentBlock, url.Headers.CurrentBlock)
 		assert.Equal(t, tc.totalBlocks, url.Headers.TotalBlocks)
-		assert.Equal(t, tc.filepath, url.Headers.FilePath)
+		assert.Equal(t, tc.expRange, url.Headers.Range)
 	}
 }
 
@@ -84,6 +84,16 @@ func IsValidURL(toTest string) bool {
 	return true
 }
 
+// FormatRangeHeader constructs the canonical range header for the inclusive
+// byte range start to end. a negative end indicates an open-ended range,
+// extending to the end of the resource
+func FormatRangeHeader(start int64, end int64) string {
+	if end < 0 {
+		return "bytes=" + strconv.FormatInt(start, 10) + "-"
+	}
+	return "bytes=" + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(end, 10)
+}
+
 // ParseRangeHeader attempts to parse the canonical range header, converting
 // start and end to integers if possible. an error is returned if the range
 // header could not be successfully parsed
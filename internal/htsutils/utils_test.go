@@ -81,6 +81,16 @@ var utilsParseRangeHeaderTC = []struct {
 	{"bytes=10.2-20.4", 0, 0, false},
 }
 
+// utilsFormatRangeHeaderTC test cases for FormatRangeHeader
+var utilsFormatRangeHeaderTC = []struct {
+	start, end int64
+	exp        string
+}{
+	{10, 50, "bytes=10-50"},
+	{0, 0, "bytes=0-0"},
+	{2048, -1, "bytes=2048-"},
+}
+
 // TestUtilsAddTrailingSlash tests AddTrailingSlash function
 func TestUtilsAddTrailingSlash(t *testing.T) {
 	for _, tc := range utilsAddTrailingSlashTC {
@@ -124,6 +134,13 @@ func TestUtilsIsValidUrl(t *testing.T) {
 	}
 }
 
+// TestUtilsFormatRangeHeader tests FormatRangeHeader function
+func TestUtilsFormatRangeHeader(t *testing.T) {
+	for _, tc := range utilsFormatRangeHeaderTC {
+		assert.Equal(t, tc.exp, FormatRangeHeader(tc.start, tc.end))
+	}
+}
+
 // TestUtilsParseRangeHeader tests ParseRangeHeader function
 func TestUtilsParseRangeHeader(t *testing.T) {
 	for _, tc := range utilsParseRangeHeaderTC {
@@ -1,4 +1,4 @@
-SAMTOOLS_VERSION="1.9"
+SAMTOOLS_VERSION="1.12"
 
 wget https://github.com/samtools/samtools/releases/download/${SAMTOOLS_VERSION}/samtools-${SAMTOOLS_VERSION}.tar.bz2
 tar -xjf samtools-${SAMTOOLS_VERSION}.tar.bz2